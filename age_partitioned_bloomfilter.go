@@ -0,0 +1,257 @@
+package bloomfilters
+
+import (
+	"errors"
+	"iter"
+	"math"
+	"time"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/pkg/extensions/xtime"
+)
+
+const (
+	// DefaultAgePartitionsK is the default amount of slices an age-partitioned bloom filter adds an item to.
+	DefaultAgePartitionsK = 10
+	// DefaultAgePartitionsL is the default amount of extra slices of an age-partitioned bloom filter, that keep older items.
+	DefaultAgePartitionsL = 7
+)
+
+var ErrInvalidAgePartitions = errors.New("age partitions k and l must be at least 1")
+
+var _ IBloomFilter = &AgePartitionedBloomFilter{}
+
+// AgePartitionedBloomFilter is a bloom filter over a sliding window of the most recent items, based on "Age-Partitioned Bloom Filters" by Shtul, Baquero and Almeida.
+// The filter is a ring of k+l slices. An item is added to the k youngest slices, and is found while k consecutive slices contain it.
+// After every generation, a fixed amount of items or an interval of time, the oldest slice is cleared and becomes the youngest,
+// so an item is found for l more generations after the generation it was added in, and then forgotten.
+// Compared to [SlidingWindowBloomFilter] no whole generation is kept around, which gives a lower false positive rate for the same memory.
+type AgePartitionedBloomFilter struct {
+	slices []Bits
+	// newest is the index in slices of the youngest slice
+	newest   int
+	inserted uint64
+	rotated  time.Time
+
+	bits       Bits
+	hashes     []bloomhashes.HashFunction
+	derived    derivedHashes
+	k, l       int
+	generation uint64
+	interval   time.Duration
+	clock      xtime.Clock
+}
+
+// NewAgePartitionedBloomFilter creates a new age-partitioned bloom filter with the given options, the size is the size in bits of a single slice.
+// By default the slices shift after a generation of items, see [WithGenerationSize], with [WithInterval] they shift after every interval instead.
+// It returns an error if the size, the partitions or the interval are invalid, or if any of the hash functions are nil.
+func NewAgePartitionedBloomFilter(opts ...AgePartitionedBloomFilterOptions) (*AgePartitionedBloomFilter, error) {
+	bf := &AgePartitionedBloomFilter{
+		k:     DefaultAgePartitionsK,
+		l:     DefaultAgePartitionsL,
+		clock: xtime.SystemClock{},
+	}
+	for _, opt := range opts {
+		opt.applyAgePartitioned(bf)
+	}
+	if bf.bits.Size() == 0 {
+		return nil, ErrInvalidSize
+	}
+	if bf.k < 1 || bf.l < 1 {
+		return nil, ErrInvalidAgePartitions
+	}
+	if bf.interval < 0 {
+		return nil, ErrInvalidInterval
+	}
+	if len(bf.hashes) == 0 {
+		return nil, ErrRequiredHashFunction
+	}
+	for _, hashFunc := range bf.hashes {
+		if hashFunc == nil {
+			return nil, ErrHashIsNil
+		}
+	}
+
+	if bf.generation == 0 {
+		// Half full slices when they stop receiving items, like the optimal amount of hash functions of a bloom filter
+		bf.generation = max(uint64(float64(bf.bits.Size())*math.Ln2/float64(bf.k)), 1)
+	}
+	bf.slices = make([]Bits, bf.k+bf.l)
+	for i := range bf.slices {
+		bf.slices[i] = NewBits(bf.bits.Size())
+	}
+	// The given bits are the youngest slice
+	bf.slices[0] = bf.bits
+	bf.rotated = bf.clock.Now()
+
+	return bf, nil
+}
+
+// Add adds the given data to the k youngest slices of the bloom filter.
+func (bf *AgePartitionedBloomFilter) Add(data []byte) {
+	bf.insert(bf.derived.all(bf.hashes, data))
+}
+
+// Test checks if the given data is likely to be in the bloom filter, which means it is found in k consecutive slices.
+func (bf *AgePartitionedBloomFilter) Test(data []byte) bool {
+	return bf.lookup(bf.derived.all(bf.hashes, data))
+}
+
+// SetHash adds the given hash value to the k youngest slices, as if every hash function produced this hash value.
+// It counts as an item of the current generation.
+func (bf *AgePartitionedBloomFilter) SetHash(hash uint64) {
+	bf.insert(bf.same(hash))
+}
+
+// GetHash checks if the given hash value is found in k consecutive slices, as if every hash function produced this hash value.
+func (bf *AgePartitionedBloomFilter) GetHash(hash uint64) bool {
+	return bf.lookup(bf.same(hash))
+}
+
+// BitsCount returns the total number of bits that are set to 1 in any slice.
+func (bf *AgePartitionedBloomFilter) BitsCount() uint64 {
+	bits := bf.Bits()
+
+	return bits.BitsCount()
+}
+
+// Bits returns a Bits struct with every bit set that is set in any slice.
+// Modifying the returned Bits will not affect the internal state of the bloom filter.
+func (bf *AgePartitionedBloomFilter) Bits() Bits {
+	bf.rotate()
+
+	union := NewBits(bf.bits.Size())
+	for _, slice := range bf.slices {
+		for i, word := range slice.data {
+			union.data[i] |= word
+		}
+	}
+
+	return union
+}
+
+// Partitions returns the amount of slices an item is added to, and the amount of extra slices that keep older items.
+func (bf *AgePartitionedBloomFilter) Partitions() (k, l int) {
+	return bf.k, bf.l
+}
+
+// GenerationSize returns the amount of items per generation, see [WithGenerationSize].
+func (bf *AgePartitionedBloomFilter) GenerationSize() uint64 {
+	return bf.generation
+}
+
+// Capacity returns the amount of most recent items that are always found, l generations.
+// With [WithInterval] this assumes at most [AgePartitionedBloomFilter.GenerationSize] items per interval.
+func (bf *AgePartitionedBloomFilter) Capacity() uint64 {
+	return bf.generation * uint64(bf.l)
+}
+
+// Window returns the duration an added item is guaranteed to be found for, l intervals, or zero if the slices shift after a generation of items.
+func (bf *AgePartitionedBloomFilter) Window() time.Duration {
+	return bf.interval * time.Duration(bf.l)
+}
+
+// FalsePositiveRate returns the highest false positive rate of the filter, reached right before the slices shift, see bloomsettings.AgePartitionedFalsePositiveRate.
+func (bf *AgePartitionedBloomFilter) FalsePositiveRate() float64 {
+	return bloomsettings.AgePartitionedFalsePositiveRate(uint64(bf.k), uint64(bf.l), bf.bits.Size(), bf.generation)
+}
+
+// insert sets the bits of the given hash values in the k youngest slices, and shifts the slices once a generation of items is added.
+func (bf *AgePartitionedBloomFilter) insert(hashes iter.Seq2[int, uint64]) {
+	bf.rotate()
+	for i, hash := range hashes {
+		for slice := i; slice < len(bf.slices); slice += len(bf.hashes) {
+			if bf.age(slice) < bf.k {
+				bf.slices[slice].Setbit(bf.index(hash, slice))
+			}
+		}
+	}
+
+	if bf.interval > 0 {
+		return
+	}
+	bf.inserted++
+	if bf.inserted >= bf.generation {
+		bf.inserted = 0
+		bf.shift()
+	}
+}
+
+// lookup checks for a run of k consecutive slices, from young to old, that contain the given hash values.
+func (bf *AgePartitionedBloomFilter) lookup(hashes iter.Seq2[int, uint64]) bool {
+	bf.rotate()
+	// The hash functions are spread over the slices, so check every slice before looking for a run
+	found := make([]bool, len(bf.slices))
+	for i, hash := range hashes {
+		for slice := i; slice < len(bf.slices); slice += len(bf.hashes) {
+			found[slice] = bf.slices[slice].Getbit(bf.index(hash, slice))
+		}
+	}
+
+	run := 0
+	for age := range bf.slices {
+		// Not enough slices left to complete a run
+		if run+len(bf.slices)-age < bf.k {
+			return false
+		}
+
+		if !found[(bf.newest+age)%len(bf.slices)] {
+			run = 0
+
+			continue
+		}
+		run++
+		if run == bf.k {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rotate shifts the slices for every interval that passed since the last shift, if the slices shift on time.
+func (bf *AgePartitionedBloomFilter) rotate() {
+	if bf.interval <= 0 {
+		return
+	}
+
+	now := bf.clock.Now()
+	steps := int64(now.Sub(bf.rotated) / bf.interval)
+	if steps <= 0 {
+		return
+	}
+
+	bf.rotated = bf.rotated.Add(time.Duration(steps) * bf.interval)
+	for range min(steps, int64(len(bf.slices))) {
+		bf.shift()
+	}
+}
+
+// shift clears the oldest slice and makes it the youngest.
+func (bf *AgePartitionedBloomFilter) shift() {
+	bf.newest = (bf.newest + len(bf.slices) - 1) % len(bf.slices)
+	clear(bf.slices[bf.newest].data)
+}
+
+// same returns the given hash value for every hash function.
+func (bf *AgePartitionedBloomFilter) same(hash uint64) iter.Seq2[int, uint64] {
+	return func(yield func(int, uint64) bool) {
+		for i := range bf.hashes {
+			if !yield(i, hash) {
+				return
+			}
+		}
+	}
+}
+
+// age returns how many shifts ago the given slice was the youngest.
+func (bf *AgePartitionedBloomFilter) age(slice int) int {
+	return (slice - bf.newest + len(bf.slices)) % len(bf.slices)
+}
+
+// index returns the bit index in the given slice. The slices take turns using the hash functions,
+// and the hash value is mixed with the slice so slices sharing a hash function use independent bits.
+func (bf *AgePartitionedBloomFilter) index(hash uint64, slice int) uint64 {
+	return xorMix(hash, uint64(slice)) % bf.bits.Size()
+}
@@ -0,0 +1,185 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/pkg/extensions/xtime"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AgePartitionedBloomFilter_Window(t *testing.T) {
+	data := testutil.MoreBytes(5000, 16)
+	bf, err := bloomfilters.NewAgePartitionedBloomFilter(
+		bloomfilters.WithSize(2048),
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithAgePartitions(4, 3),
+		bloomfilters.WithGenerationSize(100),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(300), bf.Capacity())
+	assert.Equal(t, time.Duration(0), bf.Window())
+
+	for i, d := range data {
+		bf.Add(d)
+		// The most recent Capacity items are always found
+		for j := max(0, i+1-int(bf.Capacity())); j <= i; j += 7 {
+			require.True(t, bf.Test(data[j]), "Expected item %d to be found after adding item %d", j, i)
+		}
+	}
+
+	// Items older than l+1 generations are forgotten, apart from false positives
+	forgotten := 0
+	for _, d := range data[:len(data)-400] {
+		if !bf.Test(d) {
+			forgotten++
+		}
+	}
+	assert.Greater(t, forgotten, (len(data)-400)*9/10)
+}
+
+func Test_AgePartitionedBloomFilter_FalsePositiveRate(t *testing.T) {
+	data := testutil.MoreBytes(20000, 16)
+	bf, err := bloomfilters.NewAgePartitionedBloomFilter(
+		bloomfilters.WithSize(4096),
+		bloomfilters.WithDefaultHashFunctions(),
+	)
+	require.NoError(t, err)
+	k, l := bf.Partitions()
+	assert.Equal(t, bloomfilters.DefaultAgePartitionsK, k)
+	assert.Equal(t, bloomfilters.DefaultAgePartitionsL, l)
+	assert.Equal(t, uint64(283), bf.GenerationSize())
+
+	// Fill the filter into its steady state, the rest of the data has never been added
+	for _, d := range data[:2000] {
+		bf.Add(d)
+	}
+
+	falsePositives := 0
+	for _, d := range data[2000:] {
+		if bf.Test(d) {
+			falsePositives++
+		}
+	}
+	rate := float64(falsePositives) / float64(len(data)-2000)
+	assert.LessOrEqual(t, rate, bf.FalsePositiveRate()*1.5)
+	assert.Less(t, bf.FalsePositiveRate(), 0.01)
+}
+
+func Test_AgePartitionedBloomFilter_Interval(t *testing.T) {
+	clock := xtime.NewManualClock(time.Unix(1700000000, 0))
+	bf, err := bloomfilters.NewAgePartitionedBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithAgePartitions(5, 3),
+		bloomfilters.WithInterval(time.Minute),
+		bloomfilters.WithClock(clock),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Minute, bf.Window())
+
+	bf.Add([]byte("hello"))
+	// Adding many items does not shift the slices when they shift on time
+	for _, d := range testutil.MoreBytes(200, 16) {
+		bf.Add(d)
+	}
+	clock.Advance(3*time.Minute + 59*time.Second)
+	assert.True(t, bf.Test([]byte("hello")), "Expected 'hello' to be found within the window")
+
+	clock.Advance(time.Second)
+	assert.False(t, bf.Test([]byte("hello")), "Expected 'hello' to be forgotten after l+1 intervals")
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, uint64(0), bf.BitsCount())
+}
+
+func Test_AgePartitionedBloomFilter_Invalid(t *testing.T) {
+	hashes := bloomfilters.WithDefaultHashFunctions()
+
+	tests := []struct {
+		name string
+		opts []bloomfilters.AgePartitionedBloomFilterOptions
+		err  error
+	}{
+		{"size", []bloomfilters.AgePartitionedBloomFilterOptions{hashes}, bloomfilters.ErrInvalidSize},
+		{"k", []bloomfilters.AgePartitionedBloomFilterOptions{bloomfilters.WithSize(1024), bloomfilters.WithAgePartitions(0, 3), hashes}, bloomfilters.ErrInvalidAgePartitions},
+		{"l", []bloomfilters.AgePartitionedBloomFilterOptions{bloomfilters.WithSize(1024), bloomfilters.WithAgePartitions(3, 0), hashes}, bloomfilters.ErrInvalidAgePartitions},
+		{"interval", []bloomfilters.AgePartitionedBloomFilterOptions{bloomfilters.WithSize(1024), bloomfilters.WithInterval(-time.Second), hashes}, bloomfilters.ErrInvalidInterval},
+		{"no hashes", []bloomfilters.AgePartitionedBloomFilterOptions{bloomfilters.WithSize(1024)}, bloomfilters.ErrRequiredHashFunction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bf, err := bloomfilters.NewAgePartitionedBloomFilter(tt.opts...)
+			require.ErrorIs(t, err, tt.err)
+			require.Nil(t, bf)
+		})
+	}
+}
+
+func Test_AgePartitionedFalsePositiveRate(t *testing.T) {
+	// With a single slice per item and no generations it is a single slice of a bloom filter
+	assert.InDelta(t, bloomsettings.PartitionedFalsePositiveRate(1000, 100, 1), bloomsettings.AgePartitionedFalsePositiveRate(1, 0, 1000, 100), 1e-12)
+	// More extra slices means more places to find a run, and a higher rate
+	assert.Less(t,
+		bloomsettings.AgePartitionedFalsePositiveRate(10, 7, 4096, 283),
+		bloomsettings.AgePartitionedFalsePositiveRate(10, 14, 4096, 283),
+	)
+}
+
+// Fuzz test for AgePartitionedBloomFilter Add and Test
+func Fuzz_AgePartitionedBloomFilter_AddTest(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		bf, err := bloomfilters.NewAgePartitionedBloomFilter(
+			bloomfilters.WithSize(1024),
+			bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.MD5}),
+			bloomfilters.WithAgePartitions(4, 2),
+			bloomfilters.WithGenerationSize(1),
+		)
+		require.NoError(t, err)
+
+		bf.Add(data)
+		require.True(t, bf.Test(data), "Expected data to be found within the window")
+		// The window is 2 generations of 1 item
+		bf.Add([]byte("other"))
+		require.True(t, bf.Test(data), "Expected data to be found within the window")
+		assert.Equal(t, uint64(2), bf.Capacity())
+	})
+}
+
+// Example of remembering the most recent items with an AgePartitionedBloomFilter
+func ExampleAgePartitionedBloomFilter() {
+	bf, _ := bloomfilters.NewAgePartitionedBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithAgePartitions(6, 4),
+		bloomfilters.WithGenerationSize(10),
+	)
+
+	bf.Add([]byte("apple"))
+	fmt.Println(bf.Test([]byte("apple")))
+	fmt.Println(bf.Capacity())
+
+	// Push "apple" out of the window
+	for i := range 50 {
+		bf.Add(fmt.Appendf(nil, "item-%d", i))
+	}
+	fmt.Println(bf.Test([]byte("apple")))
+
+	// Output:
+	// true
+	// 40
+	// false
+}
@@ -0,0 +1,152 @@
+package bloomfilters
+
+import (
+	"errors"
+	"math"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+const (
+	// BIP37MaxFilterBytes is the largest filter in bytes a Bitcoin peer accepts in a filterload message.
+	BIP37MaxFilterBytes = 36000
+	// BIP37MaxHashFuncs is the most hash functions a Bitcoin peer accepts in a filterload message.
+	BIP37MaxHashFuncs = 50
+
+	// bip37SeedStep is the difference between the MurmurHash3 seeds of two hash functions, chosen by BIP37 to give large differences between the seeds.
+	bip37SeedStep uint32 = 0xfba4c795
+)
+
+// BIP37Flags is the nFlags field of a BIP37 filter, it tells the peer how to update the filter when a transaction matches.
+// The flags are only carried by [BIP37BloomFilter], matching transactions is up to the peer.
+type BIP37Flags uint8
+
+const (
+	// BIP37UpdateNone never updates the filter.
+	BIP37UpdateNone BIP37Flags = iota
+	// BIP37UpdateAll adds the outpoint of every output that matched the filter.
+	BIP37UpdateAll
+	// BIP37UpdateP2PubKeyOnly adds the outpoint of outputs that matched the filter, only for pay-to-pubkey and multisig outputs.
+	BIP37UpdateP2PubKeyOnly
+)
+
+var (
+	ErrInvalidElements       = errors.New("elements must be at least 1")
+	ErrBIP37FilterTooLarge   = errors.New("filter is larger than BIP37 allows")
+	ErrBIP37TooManyHashFuncs = errors.New("filter uses more hash functions than BIP37 allows")
+)
+
+var _ IBloomFilter = &BIP37BloomFilter{}
+
+// BIP37BloomFilter is the bloom filter Bitcoin SPV clients send to their peers, as specified by BIP37, see https://github.com/bitcoin/bips/blob/master/bip-0037.mediawiki
+// Hash function i is MurmurHash3 with the seed i * 0xfba4c795 + tweak, modulo the size of the filter in bits. Bit i is bit i % 8 of byte i / 8.
+// Its binary form is the exact payload of a filterload message, and its size and amount of hash functions never exceed the limits of the protocol.
+// A filter of zero bytes matches everything, like it does for a peer.
+type BIP37BloomFilter struct {
+	bits   Bits
+	bytes  uint64
+	hashes []bloomhashes.HashFunction
+	tweak  uint32
+	flags  BIP37Flags
+}
+
+// NewBIP37BloomFilter creates a new BIP37 bloom filter for the given amount of elements and false positive rate, sized like Bitcoin Core does.
+// The size and the amount of hash functions are capped to [BIP37MaxFilterBytes] and [BIP37MaxHashFuncs], so the false positive rate of very large filters is higher.
+// The tweak changes the seeds of the hash functions, so filters of the same elements differ. It returns an error if elements or the false positive rate are invalid.
+func NewBIP37BloomFilter(elements uint32, fpRate float64, tweak uint32, flags BIP37Flags) (*BIP37BloomFilter, error) {
+	if elements == 0 {
+		return nil, ErrInvalidElements
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		return nil, ErrInvalidFalsePositiveRate
+	}
+
+	// The same floating point operations in the same order as Bitcoin Core, so the truncated results are the same
+	ln2Squared := math.Ln2 * math.Ln2
+	filterBits := min(-1/ln2Squared*float64(elements)*math.Log(fpRate), BIP37MaxFilterBytes*8)
+	filterBytes := uint64(filterBits) / 8
+	hashFuncs := min(uint32(float64(filterBytes*8/uint64(elements))*math.Ln2), BIP37MaxHashFuncs)
+
+	return newBIP37BloomFilter(NewBits(filterBytes*8), filterBytes, hashFuncs, tweak, flags), nil
+}
+
+func newBIP37BloomFilter(bits Bits, bytes uint64, hashFuncs, tweak uint32, flags BIP37Flags) *BIP37BloomFilter {
+	hashes := make([]bloomhashes.HashFunction, hashFuncs)
+	for i := range hashes {
+		hashes[i] = bloomhashes.Murmur3_32(uint32(i)*bip37SeedStep + tweak)
+	}
+
+	return &BIP37BloomFilter{
+		bits:   bits,
+		bytes:  bytes,
+		hashes: hashes,
+		tweak:  tweak,
+		flags:  flags,
+	}
+}
+
+// Add adds the given data to the bloom filter, such as a public key, a public key hash or a serialized outpoint.
+func (bf *BIP37BloomFilter) Add(data []byte) {
+	for _, hashFunc := range bf.hashes {
+		bf.SetHash(hashFunc(data))
+	}
+}
+
+// Test checks if the given data is likely to be in the bloom filter, a filter of zero bytes always returns true.
+func (bf *BIP37BloomFilter) Test(data []byte) bool {
+	for _, hashFunc := range bf.hashes {
+		if !bf.GetHash(hashFunc(data)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetHash sets the bit corresponding to the given hash value to 1, a filter of zero bytes is left unchanged.
+func (bf *BIP37BloomFilter) SetHash(hash uint64) {
+	if bf.bytes == 0 {
+		return
+	}
+	bf.bits.Setbit(hash % (bf.bytes * 8))
+}
+
+// GetHash checks if the bit corresponding to the given hash value is set to 1, a filter of zero bytes always returns true.
+func (bf *BIP37BloomFilter) GetHash(hash uint64) bool {
+	if bf.bytes == 0 {
+		return true
+	}
+
+	return bf.bits.Getbit(hash % (bf.bytes * 8))
+}
+
+// BitsCount returns the total number of bits that are set to 1 in the bloom filter.
+func (bf *BIP37BloomFilter) BitsCount() uint64 {
+	return bf.bits.BitsCount()
+}
+
+// Bits returns a copy of the Bits struct representing the bits of the bloom filter, rounded up to whole 64 bit words.
+// Modifying the returned Bits will not affect the internal state of the bloom filter.
+func (bf *BIP37BloomFilter) Bits() Bits {
+	return bf.bits.Copy()
+}
+
+// NumBytes returns the size of the filter in bytes, as sent in a filterload message.
+func (bf *BIP37BloomFilter) NumBytes() uint64 {
+	return bf.bytes
+}
+
+// HashFuncs returns the amount of hash functions, the nHashFuncs field.
+func (bf *BIP37BloomFilter) HashFuncs() uint32 {
+	return uint32(len(bf.hashes))
+}
+
+// Tweak returns the value added to the seed of every hash function, the nTweak field.
+func (bf *BIP37BloomFilter) Tweak() uint32 {
+	return bf.tweak
+}
+
+// Flags returns how the peer updates the filter when a transaction matches, the nFlags field.
+func (bf *BIP37BloomFilter) Flags() BIP37Flags {
+	return bf.flags
+}
@@ -0,0 +1,71 @@
+package bloomfilters
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrInvalidBIP37Data = errors.New("bip37 bloom filter data is truncated or has trailing bytes")
+
+// bip37TrailerSize is the size of nHashFuncs, nTweak and nFlags after the filter bytes of a filterload payload.
+const bip37TrailerSize = 4 + 4 + 1
+
+var (
+	_ encoding.BinaryMarshaler   = (*BIP37BloomFilter)(nil)
+	_ encoding.BinaryUnmarshaler = (*BIP37BloomFilter)(nil)
+)
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler].
+// The data is the payload of a filterload message, it returns [ErrBIP37FilterTooLarge] or [ErrBIP37TooManyHashFuncs] if the filter exceeds the limits of the protocol.
+func (bf *BIP37BloomFilter) UnmarshalBinary(data []byte) error {
+	filterBytes, size := readCompactSize(data)
+	if size == 0 {
+		return ErrInvalidBIP37Data
+	}
+	if filterBytes > BIP37MaxFilterBytes {
+		return ErrBIP37FilterTooLarge
+	}
+	data = data[size:]
+	if uint64(len(data)) != filterBytes+bip37TrailerSize {
+		return ErrInvalidBIP37Data
+	}
+
+	hashFuncs := binary.LittleEndian.Uint32(data[filterBytes:])
+	if hashFuncs > BIP37MaxHashFuncs {
+		return ErrBIP37TooManyHashFuncs
+	}
+	tweak := binary.LittleEndian.Uint32(data[filterBytes+4:])
+	flags := BIP37Flags(data[filterBytes+8])
+
+	// Bits reads whole 64 bit words, so the filter bytes are padded with zeros
+	padded := make([]byte, (filterBytes+7)/8*8)
+	copy(padded, data[:filterBytes])
+	bits := NewBits(filterBytes * 8)
+	err := bits.UnmarshalBinary(padded)
+	if err != nil {
+		return err
+	}
+
+	*bf = *newBIP37BloomFilter(bits, filterBytes, hashFuncs, tweak, flags)
+
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler].
+// The data is the payload of a filterload message: the filter bytes prefixed with their length as a Bitcoin CompactSize,
+// followed by nHashFuncs and nTweak as little-endian uint32 and nFlags as a single byte.
+func (bf *BIP37BloomFilter) MarshalBinary() (data []byte, err error) {
+	words, err := bf.bits.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	data = appendCompactSize(make([]byte, 0, 3+bf.bytes+bip37TrailerSize), bf.bytes)
+	// Little-endian words are the same bytes as the byte array of the protocol
+	data = append(data, words[:bf.bytes]...)
+	data = binary.LittleEndian.AppendUint32(data, bf.HashFuncs())
+	data = binary.LittleEndian.AppendUint32(data, bf.tweak)
+
+	return append(data, byte(bf.flags)), nil
+}
@@ -0,0 +1,177 @@
+package bloomfilters_test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+
+	return b
+}
+
+// Test the bloom_create_insert_serialize test vectors of Bitcoin Core
+func Test_BIP37BloomFilter_Vectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		tweak    uint32
+		expected string
+	}{
+		{"no tweak", 0, "03614e9b050000000000000001"},
+		{"tweak", 2147483649, "03ce4299050000000100008001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bf, err := bloomfilters.NewBIP37BloomFilter(3, 0.01, tt.tweak, bloomfilters.BIP37UpdateAll)
+			require.NoError(t, err)
+
+			bf.Add(mustHex(t, "99108ad8ed9bb6274d3980bab5a85c048f0950c8"))
+			assert.True(t, bf.Test(mustHex(t, "99108ad8ed9bb6274d3980bab5a85c048f0950c8")))
+			// One bit different
+			assert.False(t, bf.Test(mustHex(t, "19108ad8ed9bb6274d3980bab5a85c048f0950c8")))
+
+			bf.Add(mustHex(t, "b5a2c786d9ef4658287ced5914b37a1b4aa32eee"))
+			assert.True(t, bf.Test(mustHex(t, "b5a2c786d9ef4658287ced5914b37a1b4aa32eee")))
+			bf.Add(mustHex(t, "b9300670b4c5366e95b2699e8b18bc75e5f729c5"))
+			assert.True(t, bf.Test(mustHex(t, "b9300670b4c5366e95b2699e8b18bc75e5f729c5")))
+
+			data, err := bf.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, hex.EncodeToString(data))
+		})
+	}
+}
+
+func Test_BIP37BloomFilter_Limits(t *testing.T) {
+	bf, err := bloomfilters.NewBIP37BloomFilter(1000000, 0.0001, 0, bloomfilters.BIP37UpdateNone)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(bloomfilters.BIP37MaxFilterBytes), bf.NumBytes(), "Expected the size to be capped")
+
+	bf, err = bloomfilters.NewBIP37BloomFilter(1, 1e-20, 0, bloomfilters.BIP37UpdateNone)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(bloomfilters.BIP37MaxHashFuncs), bf.HashFuncs(), "Expected the hash functions to be capped")
+}
+
+func Test_BIP37BloomFilter_Empty(t *testing.T) {
+	// Too few bits for a single byte, peers treat such a filter as matching everything
+	bf, err := bloomfilters.NewBIP37BloomFilter(1, 0.9, 7, bloomfilters.BIP37UpdateP2PubKeyOnly)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), bf.NumBytes())
+
+	bf.Add([]byte("item"))
+	assert.True(t, bf.Test([]byte("anything")))
+	data, err := bf.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, "00000000000700000002", hex.EncodeToString(data))
+}
+
+func Test_BIP37BloomFilter_Marshal(t *testing.T) {
+	items := testutil.MoreBytes(100, 20)
+	bf, err := bloomfilters.NewBIP37BloomFilter(100, 0.001, 12345, bloomfilters.BIP37UpdateAll)
+	require.NoError(t, err)
+	for _, item := range items {
+		bf.Add(item)
+	}
+	data, err := bf.MarshalBinary()
+	require.NoError(t, err)
+
+	var parsed bloomfilters.BIP37BloomFilter
+	require.NoError(t, parsed.UnmarshalBinary(data))
+	assert.Equal(t, bf.NumBytes(), parsed.NumBytes())
+	assert.Equal(t, bf.HashFuncs(), parsed.HashFuncs())
+	assert.Equal(t, uint32(12345), parsed.Tweak())
+	assert.Equal(t, bloomfilters.BIP37UpdateAll, parsed.Flags())
+	for _, item := range items {
+		require.True(t, parsed.Test(item))
+	}
+	remarshalled, err := parsed.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, remarshalled)
+}
+
+func Test_BIP37BloomFilter_UnmarshalInvalid(t *testing.T) {
+	valid := mustHex(t, "03614e9b050000000000000001")
+	tooManyHashFuncs := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(tooManyHashFuncs[4:], bloomfilters.BIP37MaxHashFuncs+1)
+	// 36001 bytes as CompactSize
+	tooLarge := append([]byte{0xfd, 0xa1, 0x8c}, make([]byte, bloomfilters.BIP37MaxFilterBytes+1+9)...)
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, bloomfilters.ErrInvalidBIP37Data},
+		{"truncated", valid[:len(valid)-1], bloomfilters.ErrInvalidBIP37Data},
+		{"trailing", append(append([]byte(nil), valid...), 0), bloomfilters.ErrInvalidBIP37Data},
+		{"too many hash functions", tooManyHashFuncs, bloomfilters.ErrBIP37TooManyHashFuncs},
+		{"too large", tooLarge, bloomfilters.ErrBIP37FilterTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bf bloomfilters.BIP37BloomFilter
+			require.ErrorIs(t, bf.UnmarshalBinary(tt.data), tt.err)
+		})
+	}
+}
+
+func Test_BIP37BloomFilter_Invalid(t *testing.T) {
+	_, err := bloomfilters.NewBIP37BloomFilter(0, 0.01, 0, bloomfilters.BIP37UpdateNone)
+	require.ErrorIs(t, err, bloomfilters.ErrInvalidElements)
+	_, err = bloomfilters.NewBIP37BloomFilter(10, 0, 0, bloomfilters.BIP37UpdateNone)
+	require.ErrorIs(t, err, bloomfilters.ErrInvalidFalsePositiveRate)
+	_, err = bloomfilters.NewBIP37BloomFilter(10, 1, 0, bloomfilters.BIP37UpdateNone)
+	require.ErrorIs(t, err, bloomfilters.ErrInvalidFalsePositiveRate)
+}
+
+// Fuzz test for BIP37BloomFilter Add, Test and MarshalBinary
+func Fuzz_BIP37BloomFilter_AddTest(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"), uint32(0))
+	f.Add([]byte("hello world"), uint32(1))
+	f.Add([]byte(""), uint32(2147483649))
+	f.Add([]byte("a"), uint32(0xffffffff))
+
+	f.Fuzz(func(t *testing.T, data []byte, tweak uint32) {
+		bf, err := bloomfilters.NewBIP37BloomFilter(10, 0.01, tweak, bloomfilters.BIP37UpdateAll)
+		require.NoError(t, err)
+		bf.Add(data)
+		require.True(t, bf.Test(data))
+
+		marshalled, err := bf.MarshalBinary()
+		require.NoError(t, err)
+		var parsed bloomfilters.BIP37BloomFilter
+		require.NoError(t, parsed.UnmarshalBinary(marshalled))
+		require.True(t, parsed.Test(data))
+	})
+}
+
+// Example of building the payload of a filterload message for a Bitcoin peer
+func ExampleBIP37BloomFilter() {
+	bf, _ := bloomfilters.NewBIP37BloomFilter(3, 0.01, 0, bloomfilters.BIP37UpdateAll)
+
+	bf.Add([]byte("public key hash"))
+	fmt.Println(bf.NumBytes(), bf.HashFuncs())
+	fmt.Println(bf.Test([]byte("public key hash")))
+
+	payload, _ := bf.MarshalBinary()
+	fmt.Println(len(payload))
+
+	// Output:
+	// 3 5
+	// true
+	// 13
+}
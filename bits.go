@@ -35,6 +35,16 @@ func (b *Bits) Setbit(index uint64) {
 	b.data[word] |= 1 << bit
 }
 
+// Clearbit sets the bit at the specified index to 0. If the index is out of bounds (greater than or equal to the size of the Bits), it will not clear any bit.
+func (b *Bits) Clearbit(index uint64) {
+	word, bit := b.calcaluteIndex(index)
+
+	if word >= uint64(len(b.data)) {
+		return // Index is out of bounds, do not clear any bit
+	}
+	b.data[word] &^= 1 << bit
+}
+
 // Getbit returns the value of the bit at the specified index (true if set, false otherwise).
 func (b *Bits) Getbit(index uint64) bool {
 	word, bit := b.calcaluteIndex(index)
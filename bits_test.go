@@ -29,6 +29,20 @@ func Fuzz_Bits_SetGet(f *testing.F) {
 	})
 }
 
+func Test_Bits_Clearbit(t *testing.T) {
+	bits := bloomfilters.NewBits(128)
+	bits.Setbit(5)
+	bits.Setbit(6)
+
+	bits.Clearbit(5)
+	bits.Clearbit(7)   // Clearing a bit that is not set does nothing
+	bits.Clearbit(500) // Out of bounds, it should not clear any bit
+
+	assert.False(t, bits.Getbit(5))
+	assert.True(t, bits.Getbit(6))
+	assert.Equal(t, uint64(1), bits.BitsCount())
+}
+
 func Test_Bits_Marshal(t *testing.T) {
 	bits := bloomfilters.NewBits(128)
 	for i := uint64(0); i < bits.Size(); i += 2 {
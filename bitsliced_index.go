@@ -0,0 +1,142 @@
+package bloomfilters
+
+import (
+	"errors"
+	"iter"
+	"math/bits"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+var (
+	ErrIncompatibleFilter = errors.New("filter does not have the same size or amount of hash functions as the index")
+	ErrUnknownFilter      = errors.New("no filter with this id in the index")
+)
+
+// BitSlicedIndex holds many bloom filters of the same size and hash functions transposed, as done by BitFunnel, see "BitFunnel: Revisiting Signatures for Search" by Goodwin et al.
+// Row i holds bit i of every filter, with the filter id as column, so a query ANDs the k rows of its bits
+// and finds every matching filter at once, instead of testing the filters one by one.
+// Removed ids are reused by the next added filter.
+type BitSlicedIndex struct {
+	hashes  []bloomhashes.HashFunction
+	derived derivedHashes
+	rows    []Bits
+	live    Bits
+	free    []int
+	ids     int
+}
+
+// NewBitSlicedIndex creates a new empty index for bloom filters created with the given options, see [NewBloomFilter].
+// It returns the same errors as [NewBloomFilter] if the options are invalid.
+func NewBitSlicedIndex(opts ...BloomFilterOptions) (*BitSlicedIndex, error) {
+	bf, err := NewBloomFilter(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BitSlicedIndex{
+		hashes:  bf.hashes,
+		derived: bf.derived,
+		rows:    make([]Bits, bf.bits.Size()),
+	}, nil
+}
+
+// Add adds a copy of the bits of the given filter to the index and returns its id, changes to the filter afterwards are not seen by the index.
+// The filter must have the same size and amount of hash functions as the index, or it returns [ErrIncompatibleFilter].
+// The hash functions themselves can't be compared, they must be the same functions in the same order for queries to find the filter.
+func (bsi *BitSlicedIndex) Add(bf *BloomFilter) (int, error) {
+	if bf.bits.Size() != uint64(len(bsi.rows)) || len(bf.hashes) != len(bsi.hashes) {
+		return 0, ErrIncompatibleFilter
+	}
+
+	id := bsi.ids
+	if len(bsi.free) > 0 {
+		id, bsi.free = bsi.free[len(bsi.free)-1], bsi.free[:len(bsi.free)-1]
+	} else {
+		bsi.grow()
+	}
+
+	column := uint64(id)
+	for i, word := range bf.bits.data {
+		for word != 0 {
+			bsi.rows[i*64+bits.TrailingZeros64(word)].Setbit(column)
+			word &= word - 1
+		}
+	}
+	bsi.live.Setbit(column)
+
+	return id, nil
+}
+
+// grow adds the column of a new id, every row grows by a word at a time.
+func (bsi *BitSlicedIndex) grow() {
+	if uint64(bsi.ids) == bsi.live.Size() {
+		for i := range bsi.rows {
+			bsi.rows[i].data = append(bsi.rows[i].data, 0)
+		}
+		bsi.live.data = append(bsi.live.data, 0)
+	}
+	bsi.ids++
+}
+
+// Remove removes the filter with the given id from the index, the id is given to the next added filter.
+// It returns [ErrUnknownFilter] if no filter has the id.
+func (bsi *BitSlicedIndex) Remove(id int) error {
+	if !bsi.Contains(id) {
+		return ErrUnknownFilter
+	}
+
+	column := uint64(id)
+	for i := range bsi.rows {
+		bsi.rows[i].Clearbit(column)
+	}
+	bsi.live.Clearbit(column)
+	bsi.free = append(bsi.free, id)
+
+	return nil
+}
+
+// Contains checks if a filter with the given id is in the index.
+func (bsi *BitSlicedIndex) Contains(id int) bool {
+	return id >= 0 && id < bsi.ids && bsi.live.Getbit(uint64(id))
+}
+
+// Match returns a bitmap of the ids of the filters that likely contain the given data, bit id is set if filter id matches.
+func (bsi *BitSlicedIndex) Match(data []byte) Bits {
+	match := bsi.live.Copy()
+	and := func(hash uint64) {
+		for i, word := range bsi.rows[hash%uint64(len(bsi.rows))].data {
+			match.data[i] &= word
+		}
+	}
+
+	for _, hash := range bsi.derived.all(bsi.hashes, data) {
+		and(hash)
+	}
+
+	return match
+}
+
+// Query returns the ids of the filters that likely contain the given data, in ascending order.
+func (bsi *BitSlicedIndex) Query(data []byte) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i, word := range bsi.Match(data).data {
+			for word != 0 {
+				if !yield(i*64 + bits.TrailingZeros64(word)) {
+					return
+				}
+				word &= word - 1
+			}
+		}
+	}
+}
+
+// Len returns the amount of filters in the index.
+func (bsi *BitSlicedIndex) Len() int {
+	return bsi.ids - len(bsi.free)
+}
+
+// Size returns the size in bits of every filter of the index, the amount of rows.
+func (bsi *BitSlicedIndex) Size() uint64 {
+	return uint64(len(bsi.rows))
+}
@@ -0,0 +1,172 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bitSlicedOptions() []bloomfilters.BloomFilterOptions {
+	return []bloomfilters.BloomFilterOptions{
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64a, bloomhashes.Crc64_ECMA, bloomhashes.XXHash64}),
+	}
+}
+
+// Every filter found by a query is a filter whose own Test matches, and the other way around
+func Test_BitSlicedIndex_QueryMatchesFilters(t *testing.T) {
+	index, err := bloomfilters.NewBitSlicedIndex(bitSlicedOptions()...)
+	require.NoError(t, err)
+
+	items := testutil.MoreBytes(1000, 8)
+	var filters []*bloomfilters.BloomFilter
+	for i := range 200 {
+		bf, err := bloomfilters.NewBloomFilter(bitSlicedOptions()...)
+		require.NoError(t, err)
+		for _, item := range items[i*4 : i*4+50] {
+			bf.Add(item)
+		}
+		id, err := index.Add(bf)
+		require.NoError(t, err)
+		require.Equal(t, i, id)
+		filters = append(filters, bf)
+	}
+	assert.Equal(t, 200, index.Len())
+
+	for _, item := range items {
+		var expected []int
+		for id, bf := range filters {
+			if bf.Test(item) {
+				expected = append(expected, id)
+			}
+		}
+		require.Equal(t, expected, slices.Collect(index.Query(item)))
+	}
+}
+
+func Test_BitSlicedIndex_Remove(t *testing.T) {
+	index, err := bloomfilters.NewBitSlicedIndex(bitSlicedOptions()...)
+	require.NoError(t, err)
+
+	ids := make([]int, 3)
+	for i := range ids {
+		bf, err := bloomfilters.NewBloomFilter(bitSlicedOptions()...)
+		require.NoError(t, err)
+		bf.Add([]byte("shared"))
+		bf.Add(fmt.Appendf(nil, "filter %d", i))
+		ids[i], err = index.Add(bf)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []int{0, 1, 2}, slices.Collect(index.Query([]byte("shared"))))
+
+	require.NoError(t, index.Remove(ids[1]))
+	assert.False(t, index.Contains(ids[1]))
+	assert.Equal(t, 2, index.Len())
+	assert.Equal(t, []int{0, 2}, slices.Collect(index.Query([]byte("shared"))))
+	assert.Empty(t, slices.Collect(index.Query([]byte("filter 1"))))
+	require.ErrorIs(t, index.Remove(ids[1]), bloomfilters.ErrUnknownFilter)
+	require.ErrorIs(t, index.Remove(-1), bloomfilters.ErrUnknownFilter)
+	require.ErrorIs(t, index.Remove(3), bloomfilters.ErrUnknownFilter)
+
+	// The removed id is reused
+	bf, err := bloomfilters.NewBloomFilter(bitSlicedOptions()...)
+	require.NoError(t, err)
+	bf.Add([]byte("new"))
+	id, err := index.Add(bf)
+	require.NoError(t, err)
+	assert.Equal(t, ids[1], id)
+	assert.Equal(t, []int{ids[1]}, slices.Collect(index.Query([]byte("new"))))
+	assert.Equal(t, []int{0, 2}, slices.Collect(index.Query([]byte("shared"))))
+}
+
+func Test_BitSlicedIndex_Match(t *testing.T) {
+	index, err := bloomfilters.NewBitSlicedIndex(bitSlicedOptions()...)
+	require.NoError(t, err)
+	assert.Empty(t, slices.Collect(index.Query([]byte("anything"))))
+
+	for i := range 130 {
+		bf, err := bloomfilters.NewBloomFilter(bitSlicedOptions()...)
+		require.NoError(t, err)
+		if i%2 == 0 {
+			bf.Add([]byte("even"))
+		}
+		_, err = index.Add(bf)
+		require.NoError(t, err)
+	}
+
+	match := index.Match([]byte("even"))
+	assert.Equal(t, uint64(65), match.BitsCount())
+	assert.True(t, match.Getbit(128))
+	assert.False(t, match.Getbit(129))
+	assert.Equal(t, uint64(1024), index.Size())
+}
+
+func Test_BitSlicedIndex_Incompatible(t *testing.T) {
+	index, err := bloomfilters.NewBitSlicedIndex(bitSlicedOptions()...)
+	require.NoError(t, err)
+
+	bf, err := bloomfilters.NewBloomFilter(bloomfilters.WithSize(2048), bloomfilters.WithDefaultHashFunctions())
+	require.NoError(t, err)
+	_, err = index.Add(bf)
+	require.ErrorIs(t, err, bloomfilters.ErrIncompatibleFilter)
+
+	bf, err = bloomfilters.NewBloomFilter(bloomfilters.WithSize(1024), bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64}))
+	require.NoError(t, err)
+	_, err = index.Add(bf)
+	require.ErrorIs(t, err, bloomfilters.ErrIncompatibleFilter)
+
+	_, err = bloomfilters.NewBitSlicedIndex(bloomfilters.WithSize(1024))
+	require.ErrorIs(t, err, bloomfilters.ErrRequiredHashFunction)
+}
+
+// Fuzz test for BitSlicedIndex, a filter holding the data is always found
+func Fuzz_BitSlicedIndex_Query(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		index, err := bloomfilters.NewBitSlicedIndex(bitSlicedOptions()...)
+		require.NoError(t, err)
+		for i := range 3 {
+			bf, err := bloomfilters.NewBloomFilter(bitSlicedOptions()...)
+			require.NoError(t, err)
+			if i == 1 {
+				bf.Add(data)
+			}
+			_, err = index.Add(bf)
+			require.NoError(t, err)
+		}
+		require.Equal(t, []int{1}, slices.Collect(index.Query(data)))
+	})
+}
+
+// Example of finding the segments that may hold a key with a single query
+func ExampleBitSlicedIndex() {
+	opts := []bloomfilters.BloomFilterOptions{bloomfilters.WithSize(1024), bloomfilters.WithDefaultHashFunctions()}
+	index, _ := bloomfilters.NewBitSlicedIndex(opts...)
+
+	for _, keys := range [][]string{{"alice", "bob"}, {"carol"}, {"bob", "dave"}} {
+		segment, _ := bloomfilters.NewBloomFilter(opts...)
+		for _, key := range keys {
+			segment.Add([]byte(key))
+		}
+		_, _ = index.Add(segment)
+	}
+
+	for id := range index.Query([]byte("bob")) {
+		fmt.Println(id)
+	}
+
+	// Output:
+	// 0
+	// 2
+}
@@ -0,0 +1,145 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BlockedBloomFilter_SingleBlock(t *testing.T) {
+	data := testutil.MoreBytes(100, 32)
+
+	bf, err := bloomfilters.NewBlockedBloomFilter(
+		bloomfilters.WithSize(64*bloomfilters.BlockSize),
+		bloomfilters.WithDefaultHashFunctions(),
+	)
+	require.NoError(t, err)
+	require.Equal(t, uint64(64), bf.Blocks())
+
+	// Every item should only touch the bits of a single block
+	for _, d := range data {
+		single, err := bloomfilters.NewBlockedBloomFilter(
+			bloomfilters.WithSize(64*bloomfilters.BlockSize),
+			bloomfilters.WithDefaultHashFunctions(),
+		)
+		require.NoError(t, err)
+		single.Add(d)
+
+		bits := single.Bits()
+		words := bits.Words()
+		blocks := 0
+		for b := 0; b < len(words); b += bloomfilters.BlockSize / 64 {
+			for _, w := range words[b : b+bloomfilters.BlockSize/64] {
+				if w != 0 {
+					blocks++
+
+					break
+				}
+			}
+		}
+		require.Equal(t, 1, blocks, "Expected a single item to only touch one block")
+	}
+}
+
+func Test_BlockedBloomFilter_RoundsUpSize(t *testing.T) {
+	bf, err := bloomfilters.NewBlockedBloomFilter(
+		bloomfilters.WithSize(1000),
+		bloomfilters.WithDefaultHashFunctions(),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), bf.Blocks())
+
+	bits := bf.Bits()
+	assert.Equal(t, uint64(2*bloomfilters.BlockSize), bits.Size())
+}
+
+func Test_BlockedBloomFilter_TooSmall(t *testing.T) {
+	bf, err := bloomfilters.NewBlockedBloomFilter(
+		bloomfilters.WithWords(make([]uint64, 4)), // Only half a block
+		bloomfilters.WithDefaultHashFunctions(),
+	)
+	require.ErrorIs(t, err, bloomfilters.ErrInvalidSize)
+	require.Nil(t, bf)
+}
+
+func Test_BlockedBloomFilter_FalsePositiveRate(t *testing.T) {
+	const m, n = 64 * bloomfilters.BlockSize, 4000
+	data := testutil.MoreBytes(3*n, 32)
+	added, other := data[:n], data[n:]
+	hashes := bloomhashes.DefaultHashFunctions()
+	k := uint64(len(hashes))
+
+	bf, err := bloomfilters.NewBlockedBloomFilter(
+		bloomfilters.WithSize(m),
+		bloomfilters.WithHashFunctions(hashes),
+	)
+	require.NoError(t, err)
+
+	for _, d := range added {
+		bf.Add(d)
+	}
+	for _, d := range added {
+		require.True(t, bf.Test(d), "Expected added data to be found in the filter")
+	}
+
+	falsePositives := 0
+	for _, d := range other {
+		if bf.Test(d) {
+			falsePositives++
+		}
+	}
+
+	expected := bloomsettings.BlockedFalsePositiveRate(m, n, k, bloomfilters.BlockSize)
+	assert.Greater(t, expected, bloomsettings.FalsePositiveRate(m, n, k), "Expected blocking to cost some accuracy")
+	assert.InDelta(t, expected, float64(falsePositives)/float64(len(other)), 0.01, "Expected the measured false positive rate to match the predicted rate")
+}
+
+// Fuzz test for BlockedBloomFilter Add and Test
+func Fuzz_BlockedBloomFilter_AddTest(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		bf, err := bloomfilters.NewBlockedBloomFilter(
+			bloomfilters.WithSize(4096),
+			bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{
+				bloomhashes.Fnv1_64,
+				bloomhashes.MD5,
+			}),
+		)
+		require.NoError(t, err)
+
+		bf.Add(data)
+		require.True(t, bf.Test(data), "Expected added data to be found in the filter")
+	})
+}
+
+// Example of basic BlockedBloomFilter usage
+func ExampleBlockedBloomFilter() {
+	// 4 blocks of 512 bits
+	bf, _ := bloomfilters.NewBlockedBloomFilter(
+		bloomfilters.WithSize(2048),
+		bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.Fnv1_64a}),
+	)
+
+	bf.Add([]byte("apple"))
+	bf.Add([]byte("banana"))
+
+	fmt.Println(bf.Blocks())
+	fmt.Println(bf.Test([]byte("apple")))
+	fmt.Println(bf.Test([]byte("grape")))
+
+	// Output:
+	// 4
+	// true
+	// false
+}
@@ -2,6 +2,7 @@ package bloomfilters
 
 import (
 	"errors"
+	"iter"
 
 	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
 )
@@ -17,8 +18,9 @@ var _ IBloomFilter = &BloomFilter{}
 // BloomFilter is a probabilistic data structure that tests whether an element is a member of a set.
 // False positive matches are possible, but false negatives are not.
 type BloomFilter struct {
-	bits   Bits
-	hashes []bloomhashes.HashFunction
+	bits    Bits
+	hashes  []bloomhashes.HashFunction
+	derived derivedHashes
 }
 
 // NewBloomFilter creates a new bloom filter with the given options.
@@ -45,27 +47,14 @@ func NewBloomFilter(opts ...BloomFilterOptions) (*BloomFilter, error) {
 
 // Add adds the given data to the bloom filter by applying each hash function to the data and setting the corresponding bits in the filter.
 func (bf *BloomFilter) Add(data []byte) {
-	indexes := make([]uint64, 0, len(bf.hashes))
-
-	for _, hashFunc := range bf.hashes {
-		if hashFunc == nil {
-			continue
-		}
-		indexes = append(indexes, bf.index(hashFunc(data)))
-	}
-
-	for _, hash := range indexes {
+	for _, hash := range bf.derived.all(bf.hashes, data) {
 		bf.SetHash(hash)
 	}
 }
 
 // Test checks if the given data is likely to be in the bloom filter by applying each hash function to the data and checking if the corresponding bits in the filter are set. It returns true if all bits are set, indicating that the data is likely to be in the filter, and false otherwise.
 func (bf *BloomFilter) Test(data []byte) bool {
-	for _, hashFunc := range bf.hashes {
-		if hashFunc == nil {
-			continue
-		}
-		hash := hashFunc(data)
+	for _, hash := range bf.derived.all(bf.hashes, data) {
 		if !bf.GetHash(hash) {
 			return false
 		}
@@ -103,3 +92,33 @@ func (bf *BloomFilter) Bits() Bits {
 func (bf *BloomFilter) index(hash uint64) uint64 {
 	return hash % bf.bits.Size()
 }
+
+// derivedHashes is the 128-bit hash the hash functions of a filter are derived from, see [WithDerivedHashes].
+// Filters that hold it hash the data once for all hash functions, instead of once per hash function.
+type derivedHashes struct {
+	hash       bloomhashes.HashFunction128
+	derivation bloomhashes.Derivation
+}
+
+// all returns the hash value of the data for every hash function, together with the index of the hash function.
+// Derived hash functions hash the data once for all of them, otherwise every hash function hashes the data itself.
+func (d derivedHashes) all(hashes []bloomhashes.HashFunction, data []byte) iter.Seq2[int, uint64] {
+	return func(yield func(int, uint64) bool) {
+		if d.hash == nil {
+			for i, hashFunc := range hashes {
+				if !yield(i, hashFunc(data)) {
+					return
+				}
+			}
+
+			return
+		}
+
+		h1, h2 := d.hash(data)
+		for i := range hashes {
+			if !yield(i, d.derivation.Derive(h1, h2, uint64(i))) {
+				return
+			}
+		}
+	}
+}
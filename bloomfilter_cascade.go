@@ -0,0 +1,154 @@
+package bloomfilters
+
+import (
+	"errors"
+	"iter"
+	"math"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+)
+
+const (
+	// CascadeMaxLevels is the most levels a [BloomFilterCascade] may have, every level shrinks the remaining false positives to about half.
+	CascadeMaxLevels = 64
+	// CascadeMaxHashFunctions is the most hash functions a single level of a [BloomFilterCascade] may use.
+	CascadeMaxHashFunctions = 64
+	// cascadeDeepRate is the false positive rate of every level after the first, as chosen by CRLite.
+	cascadeDeepRate = 0.5
+)
+
+var (
+	ErrCascadeOverlap     = errors.New("a key can't be both included and excluded")
+	ErrCascadeBuildFailed = errors.New("could not build filter cascade, too many levels")
+)
+
+// BloomFilterCascade is a static stack of [BloomFilter] levels that answers without false positives for every key of a known universe,
+// as used by CRLite to ship certificate revocations, see "CRLite: A Scalable System for Pushing All TLS Revocations to All Browsers" by Larisch et al.
+// The first level holds the included keys, the second level the excluded keys that are false positives of the first level,
+// the third level the included keys that are false positives of the second level, and so on until a level has no false positives left.
+// Every level hashes with xxHash64 seeded by the level and the index of the hash function, so keys colliding in one level are unlikely to collide in the next.
+// Keys outside the included and excluded keys are answered like a bloom filter with the false positive rate of the first level.
+type BloomFilterCascade struct {
+	levels []*BloomFilter
+}
+
+// BuildBloomFilterCascade builds a filter cascade from the given included and excluded keys, duplicate keys are allowed.
+// The first level is sized for the false positive rate fpRate, later levels for a rate of 1/2. A rate of 0 picks the rate
+// that makes the cascade the smallest, see bloomsettings.CascadeFalsePositiveRate.
+// It returns [ErrInvalidFalsePositiveRate] if fpRate is not below 1, [ErrCascadeOverlap] if a key is both included and excluded,
+// and [ErrCascadeBuildFailed] if the cascade needs more than [CascadeMaxLevels] levels, which is extremely unlikely.
+func BuildBloomFilterCascade(included, excluded iter.Seq[[]byte], fpRate float64) (*BloomFilterCascade, error) {
+	if fpRate < 0 || fpRate >= 1 || math.IsNaN(fpRate) {
+		return nil, ErrInvalidFalsePositiveRate
+	}
+
+	insert, seen := cascadeKeys(included, nil)
+	check, _ := cascadeKeys(excluded, seen)
+	if check == nil {
+		return nil, ErrCascadeOverlap
+	}
+	if fpRate == 0 {
+		fpRate = bloomsettings.CascadeFalsePositiveRate(uint64(len(insert)), uint64(len(check)))
+	}
+
+	cascade := &BloomFilterCascade{}
+	for level := 0; len(insert) > 0; level++ {
+		if level == CascadeMaxLevels {
+			return nil, ErrCascadeBuildFailed
+		}
+
+		bf := newCascadeLevel(level, uint64(len(insert)), fpRate)
+		for _, key := range insert {
+			bf.Add(key)
+		}
+		// The keys of the other side this level lets through must be stopped by the next level
+		var falsePositives [][]byte
+		for _, key := range check {
+			if bf.Test(key) {
+				falsePositives = append(falsePositives, key)
+			}
+		}
+
+		cascade.levels = append(cascade.levels, bf)
+		insert, check = falsePositives, insert
+		fpRate = cascadeDeepRate
+	}
+
+	return cascade, nil
+}
+
+// cascadeKeys collects the unique keys, or returns nil if one of them is in exclude.
+// The returned set holds every collected key, to exclude them from the other side.
+func cascadeKeys(keys iter.Seq[[]byte], exclude map[string]struct{}) ([][]byte, map[string]struct{}) {
+	unique := [][]byte{}
+	seen := map[string]struct{}{}
+	for key := range keys {
+		if _, ok := exclude[string(key)]; ok {
+			return nil, nil
+		}
+		if _, ok := seen[string(key)]; ok {
+			continue
+		}
+		seen[string(key)] = struct{}{}
+		unique = append(unique, key)
+	}
+
+	return unique, seen
+}
+
+// newCascadeLevel creates the bloom filter of the given level, sized for n keys and the false positive rate p.
+func newCascadeLevel(level int, n uint64, p float64) *BloomFilter {
+	k := min(max(uint64(math.Round(-math.Log2(p))), 1), CascadeMaxHashFunctions)
+	size := uint64(math.Ceil(float64(n) * bloomsettings.BloomBitsPerKey(p)))
+
+	return &BloomFilter{
+		bits:   NewBits(size),
+		hashes: cascadeHashes(level, k),
+	}
+}
+
+// cascadeHashes returns the k hash functions of the given level, xxHash64 seeded with the level in the upper and the index of the hash function in the lower 32 bits.
+// Seeding salts the hash without copying the data on every call.
+func cascadeHashes(level int, k uint64) []bloomhashes.HashFunction {
+	hashes := make([]bloomhashes.HashFunction, k)
+	for i := range hashes {
+		hashes[i] = bloomhashes.XXHash64Seed(uint64(level)<<32 | uint64(i))
+	}
+
+	return hashes
+}
+
+// Test walks the levels and checks if the given data is one of the included keys.
+// Data that is not stopped by any level belongs to the side of the last level, for included and excluded keys the answer is always right.
+func (c *BloomFilterCascade) Test(data []byte) bool {
+	for i, level := range c.levels {
+		if !level.Test(data) {
+			// Stopped by a level of excluded keys, so it is an included key
+			return i%2 == 1
+		}
+	}
+
+	return len(c.levels)%2 == 1
+}
+
+// Levels returns the amount of bloom filter levels of the cascade.
+func (c *BloomFilterCascade) Levels() int {
+	return len(c.levels)
+}
+
+// Level returns a copy of the Bits of the given level, the even levels hold included keys and the odd levels excluded keys.
+// Modifying the returned Bits will not affect the internal state of the cascade.
+func (c *BloomFilterCascade) Level(level int) Bits {
+	return c.levels[level].Bits()
+}
+
+// SizeInBits returns the total size of the bits of all levels.
+func (c *BloomFilterCascade) SizeInBits() uint64 {
+	var size uint64
+	for _, level := range c.levels {
+		size += level.bits.Size()
+	}
+
+	return size
+}
@@ -0,0 +1,73 @@
+package bloomfilters
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrInvalidCascadeData = errors.New("filter cascade data is truncated or malformed")
+
+var (
+	_ encoding.BinaryMarshaler   = (*BloomFilterCascade)(nil)
+	_ encoding.BinaryUnmarshaler = (*BloomFilterCascade)(nil)
+)
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler].
+func (c *BloomFilterCascade) UnmarshalBinary(data []byte) error {
+	count, size := readCompactSize(data)
+	if size == 0 || count > CascadeMaxLevels {
+		return ErrInvalidCascadeData
+	}
+	data = data[size:]
+
+	levels := make([]*BloomFilter, count)
+	for i := range levels {
+		k, size := readCompactSize(data)
+		if size == 0 || k < 1 || k > CascadeMaxHashFunctions {
+			return ErrInvalidCascadeData
+		}
+		data = data[size:]
+
+		words, size := readCompactSize(data)
+		if size == 0 || words == 0 || words > uint64(len(data)-size)/8 {
+			return ErrInvalidCascadeData
+		}
+		data = data[size:]
+
+		bits := Bits{data: make([]uint64, words)}
+		for j := range bits.data {
+			bits.data[j] = binary.LittleEndian.Uint64(data[j*8:])
+		}
+		data = data[words*8:]
+
+		levels[i] = &BloomFilter{
+			bits:   bits,
+			hashes: cascadeHashes(i, k),
+		}
+	}
+	if len(data) != 0 {
+		return ErrInvalidCascadeData
+	}
+
+	c.levels = levels
+
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler].
+// The data is the amount of levels, followed by the amount of hash functions and the amount of words of every level,
+// each as a Bitcoin CompactSize, and the little-endian words of its bits.
+// The hash functions are derived from the level, so they are not part of the data.
+func (c *BloomFilterCascade) MarshalBinary() (data []byte, err error) {
+	data = appendCompactSize(make([]byte, 0, 1+c.SizeInBits()/8+uint64(len(c.levels))*10), uint64(len(c.levels)))
+	for _, level := range c.levels {
+		data = appendCompactSize(data, uint64(len(level.hashes)))
+		data = appendCompactSize(data, uint64(len(level.bits.data)))
+		for _, word := range level.bits.data {
+			data = binary.LittleEndian.AppendUint64(data, word)
+		}
+	}
+
+	return data, nil
+}
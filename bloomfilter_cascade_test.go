@@ -0,0 +1,181 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BloomFilterCascade_NoFalsePositives(t *testing.T) {
+	keys := testutil.MoreBytes(11000, 16)
+	included, excluded := keys[:1000], keys[1000:]
+
+	for _, fpRate := range []float64{0, 0.01, 0.5} {
+		t.Run(fmt.Sprint(fpRate), func(t *testing.T) {
+			cascade, err := bloomfilters.BuildBloomFilterCascade(slices.Values(included), slices.Values(excluded), fpRate)
+			require.NoError(t, err)
+			assert.Greater(t, cascade.Levels(), 1)
+
+			for _, key := range included {
+				require.True(t, cascade.Test(key), "Expected %x to be included", key)
+			}
+			for _, key := range excluded {
+				require.False(t, cascade.Test(key), "Expected %x to be excluded", key)
+			}
+
+			allocs := testing.AllocsPerRun(100, func() {
+				cascade.Test(included[0])
+				cascade.Test(excluded[0])
+			})
+			assert.Zero(t, allocs, "Expected walking the levels to not allocate")
+		})
+	}
+}
+
+func Test_BloomFilterCascade_Size(t *testing.T) {
+	keys := testutil.MoreBytes(11000, 16)
+	included, excluded := keys[:1000], keys[1000:]
+
+	optimal, err := bloomfilters.BuildBloomFilterCascade(slices.Values(included), slices.Values(excluded), 0)
+	require.NoError(t, err)
+	wasteful, err := bloomfilters.BuildBloomFilterCascade(slices.Values(included), slices.Values(excluded), 0.5)
+	require.NoError(t, err)
+	assert.Less(t, optimal.SizeInBits(), wasteful.SizeInBits())
+
+	// The first level takes most of the space
+	level := optimal.Level(0)
+	first := level.Size()
+	p := bloomsettings.CascadeFalsePositiveRate(uint64(len(included)), uint64(len(excluded)))
+	assert.InDelta(t, bloomsettings.BloomBitsPerKey(p)*float64(len(included)), float64(first), 64)
+	assert.Less(t, optimal.SizeInBits(), 2*first)
+}
+
+func Test_BloomFilterCascade_Duplicates(t *testing.T) {
+	included := [][]byte{[]byte("a"), []byte("a"), []byte("b")}
+	excluded := [][]byte{[]byte("c"), []byte("c")}
+
+	cascade, err := bloomfilters.BuildBloomFilterCascade(slices.Values(included), slices.Values(excluded), 0.01)
+	require.NoError(t, err)
+	assert.True(t, cascade.Test([]byte("a")))
+	assert.True(t, cascade.Test([]byte("b")))
+	assert.False(t, cascade.Test([]byte("c")))
+}
+
+func Test_BloomFilterCascade_Empty(t *testing.T) {
+	cascade, err := bloomfilters.BuildBloomFilterCascade(slices.Values([][]byte{}), slices.Values(testutil.MoreBytes(10, 8)), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cascade.Levels())
+	assert.False(t, cascade.Test([]byte("anything")))
+
+	cascade, err = bloomfilters.BuildBloomFilterCascade(slices.Values(testutil.MoreBytes(10, 8)), slices.Values([][]byte{}), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cascade.Levels())
+	for _, key := range testutil.MoreBytes(10, 8) {
+		assert.True(t, cascade.Test(key))
+	}
+}
+
+func Test_BloomFilterCascade_Invalid(t *testing.T) {
+	keys := slices.Values(testutil.MoreBytes(10, 8))
+
+	_, err := bloomfilters.BuildBloomFilterCascade(keys, keys, 0)
+	require.ErrorIs(t, err, bloomfilters.ErrCascadeOverlap)
+	_, err = bloomfilters.BuildBloomFilterCascade(keys, nil, 1)
+	require.ErrorIs(t, err, bloomfilters.ErrInvalidFalsePositiveRate)
+	_, err = bloomfilters.BuildBloomFilterCascade(keys, nil, -0.1)
+	require.ErrorIs(t, err, bloomfilters.ErrInvalidFalsePositiveRate)
+}
+
+func Test_BloomFilterCascade_Marshal(t *testing.T) {
+	keys := testutil.MoreBytes(5000, 16)
+	included, excluded := keys[:500], keys[500:]
+
+	cascade, err := bloomfilters.BuildBloomFilterCascade(slices.Values(included), slices.Values(excluded), 0)
+	require.NoError(t, err)
+	data, err := cascade.MarshalBinary()
+	require.NoError(t, err)
+	assert.Less(t, uint64(len(data)), cascade.SizeInBits()/8+uint64(cascade.Levels())*10)
+
+	var parsed bloomfilters.BloomFilterCascade
+	require.NoError(t, parsed.UnmarshalBinary(data))
+	assert.Equal(t, cascade.Levels(), parsed.Levels())
+	for _, key := range included {
+		require.True(t, parsed.Test(key))
+	}
+	for _, key := range excluded {
+		require.False(t, parsed.Test(key))
+	}
+
+	remarshalled, err := parsed.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, remarshalled)
+}
+
+func Test_BloomFilterCascade_UnmarshalInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"too many levels", []byte{bloomfilters.CascadeMaxLevels + 1}},
+		{"no hash functions", []byte{1, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{"too many hash functions", []byte{1, bloomfilters.CascadeMaxHashFunctions + 1, 1, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{"no words", []byte{1, 1, 0}},
+		{"truncated", []byte{1, 1, 1, 0, 0, 0, 0, 0, 0, 0}},
+		{"trailing", []byte{1, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{"missing level", []byte{2, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cascade bloomfilters.BloomFilterCascade
+			require.ErrorIs(t, cascade.UnmarshalBinary(tt.data), bloomfilters.ErrInvalidCascadeData)
+		})
+	}
+}
+
+// Fuzz test for BloomFilterCascade, an included key is never excluded and the other way around
+func Fuzz_BloomFilterCascade_Test(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"), []byte("hello world"))
+	f.Add([]byte("hello world"), []byte("test"))
+	f.Add([]byte(""), []byte("a"))
+	f.Add([]byte("a"), []byte(""))
+
+	f.Fuzz(func(t *testing.T, included []byte, excluded []byte) {
+		others := testutil.MoreBytes(100, 4)
+		cascade, err := bloomfilters.BuildBloomFilterCascade(slices.Values([][]byte{included}), slices.Values(append(others, excluded)), 0)
+		if slices.ContainsFunc(append(others, excluded), func(key []byte) bool { return string(key) == string(included) }) {
+			require.ErrorIs(t, err, bloomfilters.ErrCascadeOverlap)
+
+			return
+		}
+		require.NoError(t, err)
+		require.True(t, cascade.Test(included))
+		require.False(t, cascade.Test(excluded))
+	})
+}
+
+// Example of a revocation list that answers every known certificate without false positives
+func ExampleBloomFilterCascade() {
+	revoked := [][]byte{[]byte("serial 1"), []byte("serial 7")}
+	valid := [][]byte{[]byte("serial 2"), []byte("serial 3"), []byte("serial 4"), []byte("serial 5"), []byte("serial 6")}
+
+	cascade, _ := bloomfilters.BuildBloomFilterCascade(slices.Values(revoked), slices.Values(valid), 0)
+	data, _ := cascade.MarshalBinary()
+
+	var received bloomfilters.BloomFilterCascade
+	_ = received.UnmarshalBinary(data)
+	fmt.Println(received.Test([]byte("serial 7")))
+	fmt.Println(received.Test([]byte("serial 3")))
+
+	// Output:
+	// true
+	// false
+}
@@ -3,9 +3,12 @@ package bloomfilters_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	bloomfilters "github.com/daanv2/go-bloom-filters"
 	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -22,9 +25,64 @@ func testFilters() map[string]filterFactory {
 		"ConcurrentBloomFilter": func(opts ...bloomfilters.BloomFilterOptions) (bloomfilters.IBloomFilter, error) {
 			return bloomfilters.NewConcurrentBloomFilter(opts...)
 		},
+		"CountingBloomFilter": func(opts ...bloomfilters.BloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewCountingBloomFilter(convertOptions[bloomfilters.CountingBloomFilterOptions](opts)...)
+		},
+		"ConcurrentCountingBloomFilter": func(opts ...bloomfilters.BloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewConcurrentCountingBloomFilter(convertOptions[bloomfilters.CountingBloomFilterOptions](opts)...)
+		},
+		"ScalableBloomFilter": func(opts ...bloomfilters.BloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewScalableBloomFilter(convertOptions[bloomfilters.ScalableBloomFilterOptions](opts)...)
+		},
+		"PartitionedBloomFilter": func(opts ...bloomfilters.BloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewPartitionedBloomFilter(convertOptions[bloomfilters.PartitionedBloomFilterOptions](opts)...)
+		},
+		"BlockedBloomFilter": func(opts ...bloomfilters.BloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewBlockedBloomFilter(convertOptions[bloomfilters.BlockedBloomFilterOptions](opts)...)
+		},
+		"StableBloomFilter": func(opts ...bloomfilters.BloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewStableBloomFilter(convertOptions[bloomfilters.StableBloomFilterOptions](opts)...)
+		},
+		"SlidingWindowBloomFilter": func(opts ...bloomfilters.BloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewSlidingWindowBloomFilter(append(
+				convertOptions[bloomfilters.SlidingWindowBloomFilterOptions](opts),
+				bloomfilters.WithInterval(time.Hour),
+			)...)
+		},
+		"ConcurrentSlidingWindowBloomFilter": func(opts ...bloomfilters.BloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewConcurrentSlidingWindowBloomFilter(append(
+				convertOptions[bloomfilters.SlidingWindowBloomFilterOptions](opts),
+				bloomfilters.WithInterval(time.Hour),
+			)...)
+		},
+		"AgePartitionedBloomFilter": func(opts ...bloomfilters.BloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewAgePartitionedBloomFilter(append(
+				convertOptions[bloomfilters.AgePartitionedBloomFilterOptions](opts),
+				bloomfilters.WithInterval(time.Hour),
+			)...)
+		},
+		"ExpiringBloomFilter": func(opts ...bloomfilters.BloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewExpiringBloomFilter(convertOptions[bloomfilters.ExpiringBloomFilterOptions](opts)...)
+		},
+		"RetouchedBloomFilter": func(opts ...bloomfilters.BloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewRetouchedBloomFilter(convertOptions[bloomfilters.RetouchedBloomFilterOptions](opts)...)
+		},
+		"DeletableBloomFilter": func(opts ...bloomfilters.BloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewDeletableBloomFilter(convertOptions[bloomfilters.DeletableBloomFilterOptions](opts)...)
+		},
 	}
 }
 
+// convertOptions converts the shared bloom filter options into the options of a specific filter.
+func convertOptions[T any](opts []bloomfilters.BloomFilterOptions) []T {
+	result := make([]T, 0, len(opts))
+	for _, opt := range opts {
+		result = append(result, any(opt).(T))
+	}
+
+	return result
+}
+
 // Test NewBloomFilter with valid options
 func Test_NewBloomFilter_Valid(t *testing.T) {
 	for name, factory := range testFilters() {
@@ -342,6 +400,101 @@ func Test_BloomFilter_AllHashFunctions(t *testing.T) {
 	}
 }
 
+// Test with k hash functions derived from a single 128-bit hash
+func Test_BloomFilter_DerivedHashes(t *testing.T) {
+	for name, factory := range testFilters() {
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			counted := func(data []byte) (uint64, uint64) {
+				calls++
+
+				return bloomhashes.Fnv1_128aSum(data)
+			}
+			bf, err := factory(
+				bloomfilters.WithSize(8192),
+				bloomfilters.WithDerivedHashes(counted, bloomhashes.EnhancedDoubleHashing, 12),
+			)
+			require.NoError(t, err)
+
+			data := []byte("derived test")
+			bf.Add(data)
+			assert.Equal(t, 1, calls, "Expected the data to be hashed once per add, not once per hash function")
+
+			calls = 0
+			assert.True(t, bf.Test(data), "Expected data to be in the filter with derived hash functions")
+			assert.Equal(t, 1, calls, "Expected the data to be hashed once per test, not once per hash function")
+		})
+	}
+}
+
+// Hashing once per item sets the same bits as calling every derived hash function
+func Test_BloomFilter_DerivedHashes_SameBits(t *testing.T) {
+	items := testutil.MoreBytes(500, 16)
+
+	for _, derivation := range []bloomhashes.Derivation{bloomhashes.DoubleHashing, bloomhashes.EnhancedDoubleHashing, bloomhashes.TripleHashing} {
+		t.Run(fmt.Sprint(derivation), func(t *testing.T) {
+			derived, err := bloomfilters.NewBloomFilter(bloomfilters.WithSize(4096), bloomfilters.WithDerivedHashes(bloomhashes.Fnv1_128aSum, derivation, 7))
+			require.NoError(t, err)
+			concurrent, err := bloomfilters.NewConcurrentBloomFilter(bloomfilters.WithSize(4096), bloomfilters.WithDerivedHashes(bloomhashes.Fnv1_128aSum, derivation, 7))
+			require.NoError(t, err)
+			separate, err := bloomfilters.NewBloomFilter(
+				bloomfilters.WithSize(4096),
+				bloomfilters.WithHashFunctions(bloomhashes.DerivedHashFunctions(bloomhashes.Fnv1_128aSum, derivation, 7)),
+			)
+			require.NoError(t, err)
+
+			for _, item := range items {
+				derived.Add(item)
+				concurrent.Add(item)
+				separate.Add(item)
+			}
+			assert.Equal(t, separate.Words(), derived.Words())
+			assert.Equal(t, separate.Bits(), concurrent.Bits())
+			for _, item := range testutil.MoreBytes(1000, 8) {
+				require.Equal(t, separate.Test(item), derived.Test(item))
+				require.Equal(t, separate.Test(item), concurrent.Test(item))
+			}
+		})
+	}
+}
+
+// The false positive rate of derived hash functions is the one of independent hash functions
+func Test_BloomFilter_DerivedHashes_FalsePositiveRate(t *testing.T) {
+	const m, n, k = 8192, 1000, 6
+	bf, err := bloomfilters.NewBloomFilter(bloomfilters.WithSize(m), bloomfilters.WithDerivedHashes(bloomhashes.Fnv1_128aSum, bloomhashes.EnhancedDoubleHashing, k))
+	require.NoError(t, err)
+
+	items := testutil.MoreBytes(n+20000, 16)
+	for _, item := range items[:n] {
+		bf.Add(item)
+	}
+	falsePositives := 0
+	for _, item := range items[n:] {
+		if bf.Test(item) {
+			falsePositives++
+		}
+	}
+	assert.InDelta(t, bloomsettings.FalsePositiveRate(m, n, k), float64(falsePositives)/20000, 0.01)
+}
+
+func Test_BloomFilter_DerivedHashes_Invalid(t *testing.T) {
+	_, err := bloomfilters.NewBloomFilter(bloomfilters.WithSize(1024), bloomfilters.WithDerivedHashes(nil, bloomhashes.DoubleHashing, 3))
+	require.ErrorIs(t, err, bloomfilters.ErrHashIsNil)
+	_, err = bloomfilters.NewBloomFilter(bloomfilters.WithSize(1024), bloomfilters.WithDerivedHashes(bloomhashes.Fnv1_128aSum, bloomhashes.DoubleHashing, 0))
+	require.ErrorIs(t, err, bloomfilters.ErrRequiredHashFunction)
+
+	// Later hash functions replace or extend the derived ones
+	bf, err := bloomfilters.NewBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithDerivedHashes(bloomhashes.Fnv1_128aSum, bloomhashes.DoubleHashing, 3),
+		bloomfilters.WithAppendHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64}),
+	)
+	require.NoError(t, err)
+	bf.Add([]byte("appended"))
+	assert.True(t, bf.Test([]byte("appended")))
+	assert.Equal(t, uint64(4), bf.BitsCount())
+}
+
 // Fuzz test for BloomFilter Add and Test
 func Fuzz_BloomFilter_AddTest(f *testing.F) {
 	// Add seed corpus
@@ -0,0 +1,101 @@
+package bloomfilters
+
+import (
+	"errors"
+	"iter"
+	"maps"
+	"slices"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+var (
+	ErrBloomierBuildFailed = errors.New("could not build bloomier filter, too many attempts")
+	ErrConflictingValues   = errors.New("key is given twice with different values")
+)
+
+// BloomierFilter is a static map from keys to 8 bit values, built once from a known set of key/value pairs, based on "The Bloomier Filter" by Chazelle, Kilian, Rubinfeld and Tal.
+// It uses the construction of [XorFilter]: every key maps to three slots and the xor of those slots equals an 8 bit fingerprint of the key next to its value.
+// Looking up a key that was not in the set is detected with a probability of 255/256, otherwise an arbitrary value is returned.
+// It uses about 1.23 slots of 16 bits per key. Keys are hashed with xxHash64, pairs can't be added or removed after the filter is built.
+type BloomierFilter struct {
+	seed        uint64
+	blockLength uint32
+	slots       []uint16
+}
+
+// BuildBloomierFilter builds a bloomier filter from the given key/value pairs. A key can be given more than once with the same value.
+// It returns [ErrConflictingValues] if a key is given with different values,
+// and [ErrBloomierBuildFailed] if no seed was found that maps every key to its own slot, which is extremely unlikely.
+func BuildBloomierFilter(pairs iter.Seq2[[]byte, uint8]) (*BloomierFilter, error) {
+	values := map[uint64]uint8{}
+	for key, value := range pairs {
+		hash := bloomhashes.XXHash64(key)
+		if existing, ok := values[hash]; ok && existing != value {
+			return nil, ErrConflictingValues
+		}
+		values[hash] = value
+	}
+	hashes := slices.Sorted(maps.Keys(values))
+
+	blockLength := xorBlockLength(len(hashes))
+	rng := uint64(0x9e3779b97f4a7c15)
+	for range xorMaxAttempts {
+		seed := splitmix64(&rng)
+		stack, ok := xorPeel(hashes, seed, blockLength)
+		if !ok {
+			continue
+		}
+
+		// The peeled hashes are mixed with the seed, which maps every key hash to a unique mixed hash
+		mixed := make(map[uint64]uint8, len(values))
+		for hash, value := range values {
+			mixed[xorMix(hash, seed)] = value
+		}
+
+		bf := &BloomierFilter{
+			seed:        seed,
+			blockLength: blockLength,
+			slots:       make([]uint16, 3*blockLength),
+		}
+		// Assign in reverse peeling order, every slot is the last unassigned slot of its key
+		for i := len(stack) - 1; i >= 0; i-- {
+			h := stack[i].hash
+			p0, p1, p2 := xorPositions(h, blockLength)
+			bf.slots[stack[i].index] = bloomierSlot(h, mixed[h]) ^ bf.slots[p0] ^ bf.slots[p1] ^ bf.slots[p2]
+		}
+
+		return bf, nil
+	}
+
+	return nil, ErrBloomierBuildFailed
+}
+
+// Get returns the value of the given key, and false if the key was not one of the keys the filter was built from.
+// A key that was not in the set returns true with a probability of 1/256. It does not allocate.
+func (bf *BloomierFilter) Get(key []byte) (value uint8, ok bool) {
+	return bf.GetHash(bloomhashes.XXHash64(key))
+}
+
+// GetHash returns the value of the key with the given xxHash64 hash, and false if the key was not one of the keys the filter was built from.
+// It does not allocate, so callers that already have the hash of a key can skip hashing it again.
+func (bf *BloomierFilter) GetHash(hash uint64) (value uint8, ok bool) {
+	h := xorMix(hash, bf.seed)
+	p0, p1, p2 := xorPositions(h, bf.blockLength)
+	slot := bf.slots[p0] ^ bf.slots[p1] ^ bf.slots[p2]
+	if slot>>8 != uint16(xorFingerprint[uint8](h)) {
+		return 0, false
+	}
+
+	return uint8(slot), true
+}
+
+// SizeInBits returns the size of the slot table in bits.
+func (bf *BloomierFilter) SizeInBits() uint64 {
+	return uint64(len(bf.slots)) * 16
+}
+
+// bloomierSlot returns the fingerprint of the mixed hash in the high byte and the value in the low byte.
+func bloomierSlot(h uint64, value uint8) uint16 {
+	return uint16(xorFingerprint[uint8](h))<<8 | uint16(value)
+}
@@ -0,0 +1,50 @@
+package bloomfilters
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrInvalidBloomierData = errors.New("bloomier filter data has an invalid length")
+
+var (
+	_ encoding.BinaryMarshaler   = (*BloomierFilter)(nil)
+	_ encoding.BinaryUnmarshaler = (*BloomierFilter)(nil)
+)
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler].
+func (bf *BloomierFilter) UnmarshalBinary(data []byte) error {
+	if len(data) < xorHeaderSize {
+		return ErrInvalidBloomierData
+	}
+
+	seed := binary.LittleEndian.Uint64(data)
+	blockLength := binary.LittleEndian.Uint32(data[8:])
+	data = data[xorHeaderSize:]
+	if blockLength == 0 || uint64(len(data)) != 3*uint64(blockLength)*2 {
+		return ErrInvalidBloomierData
+	}
+
+	slots := make([]uint16, 3*blockLength)
+	for i := range slots {
+		slots[i] = binary.LittleEndian.Uint16(data[2*i:])
+	}
+
+	bf.seed, bf.blockLength, bf.slots = seed, blockLength, slots
+
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler].
+// The data has the layout of a 16 bit [XorFilter]: the seed as little-endian uint64, the block length as little-endian uint32, followed by the little-endian slots.
+func (bf *BloomierFilter) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, xorHeaderSize+len(bf.slots)*2)
+	binary.LittleEndian.PutUint64(data, bf.seed)
+	binary.LittleEndian.PutUint32(data[8:], bf.blockLength)
+	for i, slot := range bf.slots {
+		binary.LittleEndian.PutUint16(data[xorHeaderSize+2*i:], slot)
+	}
+
+	return data, nil
+}
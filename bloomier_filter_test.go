@@ -0,0 +1,148 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"iter"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shardPairs returns every key with the value i % 256 of its position i.
+func shardPairs(keys [][]byte) iter.Seq2[[]byte, uint8] {
+	return func(yield func([]byte, uint8) bool) {
+		for i, key := range keys {
+			if !yield(key, uint8(i%256)) {
+				return
+			}
+		}
+	}
+}
+
+func Test_BloomierFilter_Get(t *testing.T) {
+	data := testutil.MoreBytes(60000, 16)
+	keys, others := data[:10000], data[10000:]
+
+	bf, err := bloomfilters.BuildBloomierFilter(shardPairs(keys))
+	require.NoError(t, err)
+
+	for i, key := range keys {
+		value, ok := bf.Get(key)
+		require.True(t, ok, "Expected key to be found in the bloomier filter")
+		require.Equal(t, uint8(i%256), value)
+	}
+
+	found := 0
+	for _, d := range others {
+		if _, ok := bf.Get(d); ok {
+			found++
+		}
+	}
+	assert.InDelta(t, 1.0/256, float64(found)/float64(len(others)), 0.2/256)
+
+	// About 1.23 slots of 16 bits per key
+	assert.Less(t, float64(bf.SizeInBits())/float64(len(keys)), 20.0)
+}
+
+func Test_BloomierFilter_Duplicates(t *testing.T) {
+	keys := testutil.MoreBytes(100, 16)
+
+	bf, err := bloomfilters.BuildBloomierFilter(shardPairs(append(keys, keys[:50]...)))
+	require.Error(t, err, "Expected duplicate keys at other positions to have other values")
+	require.ErrorIs(t, err, bloomfilters.ErrConflictingValues)
+	require.Nil(t, bf)
+
+	// The same value twice is allowed
+	bf, err = bloomfilters.BuildBloomierFilter(func(yield func([]byte, uint8) bool) {
+		for _, key := range append(keys, keys...) {
+			if !yield(key, 7) {
+				return
+			}
+		}
+	})
+	require.NoError(t, err)
+	for _, key := range keys {
+		value, ok := bf.Get(key)
+		require.True(t, ok)
+		require.Equal(t, uint8(7), value)
+	}
+}
+
+func Test_BloomierFilter_Empty(t *testing.T) {
+	bf, err := bloomfilters.BuildBloomierFilter(shardPairs(nil))
+	require.NoError(t, err)
+
+	_, ok := bf.Get([]byte("missing"))
+	assert.False(t, ok)
+}
+
+func Test_BloomierFilter_Marshal(t *testing.T) {
+	keys := testutil.MoreBytes(1000, 16)
+	bf, err := bloomfilters.BuildBloomierFilter(shardPairs(keys))
+	require.NoError(t, err)
+
+	data, err := bf.MarshalBinary()
+	require.NoError(t, err)
+	assert.Len(t, data, 12+int(bf.SizeInBits()/8))
+
+	restored := &bloomfilters.BloomierFilter{}
+	require.NoError(t, restored.UnmarshalBinary(data))
+	assert.Equal(t, bf, restored)
+
+	require.ErrorIs(t, restored.UnmarshalBinary(data[:len(data)-1]), bloomfilters.ErrInvalidBloomierData)
+	require.ErrorIs(t, restored.UnmarshalBinary(data[:4]), bloomfilters.ErrInvalidBloomierData)
+	// A block length of 0 has no slots to look up
+	require.ErrorIs(t, restored.UnmarshalBinary(make([]byte, 12)), bloomfilters.ErrInvalidBloomierData)
+}
+
+// Fuzz test for BuildBloomierFilter and Get
+func Fuzz_BloomierFilter_Get(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"), uint8(1))
+	f.Add([]byte("hello world"), uint8(42))
+	f.Add([]byte(""), uint8(0))
+	f.Add([]byte("a"), uint8(255))
+
+	f.Fuzz(func(t *testing.T, data []byte, value uint8) {
+		bf, err := bloomfilters.BuildBloomierFilter(func(yield func([]byte, uint8) bool) {
+			_ = yield(data, value) && yield([]byte("other key"), value+1)
+		})
+		if string(data) == "other key" {
+			require.ErrorIs(t, err, bloomfilters.ErrConflictingValues)
+
+			return
+		}
+		require.NoError(t, err)
+
+		got, ok := bf.Get(data)
+		require.True(t, ok, "Expected data to be found in the bloomier filter")
+		require.Equal(t, value, got)
+	})
+}
+
+// Example of a static map from keys to shard IDs with a BloomierFilter
+func ExampleBloomierFilter() {
+	shards := map[string]uint8{
+		"alice": 1,
+		"bob":   7,
+		"carol": 3,
+	}
+
+	bf, _ := bloomfilters.BuildBloomierFilter(func(yield func([]byte, uint8) bool) {
+		for key, shard := range shards {
+			if !yield([]byte(key), shard) {
+				return
+			}
+		}
+	})
+
+	fmt.Println(bf.Get([]byte("bob")))
+	fmt.Println(bf.Get([]byte("carol")))
+
+	// Output:
+	// 7 true
+	// 3 true
+}
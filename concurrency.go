@@ -10,9 +10,10 @@ var _ IBloomFilter = &ConcurrentBloomFilter{}
 // ConcurrentBloomFilter is a thread-safe bloom filter that uses a spinlock for concurrent access.
 // It is safe to call Add and Test methods from multiple goroutines.
 type ConcurrentBloomFilter struct {
-	bits   Bits
-	hashes []bloomhashes.HashFunction
-	lock   xsync.SpinLock
+	bits    Bits
+	hashes  []bloomhashes.HashFunction
+	derived derivedHashes
+	lock    xsync.SpinLock
 }
 
 // NewConcurrentBloomFilter creates a new concurrent bloom filter with the given options.
@@ -43,34 +44,13 @@ func NewConcurrentBloomFilter(opts ...BloomFilterOptions) (*ConcurrentBloomFilte
 // Add adds the given data to the bloom filter by applying each hash function to the data and setting the corresponding bits in the filter.
 // This method is thread-safe.
 func (bf *ConcurrentBloomFilter) Add(data []byte) {
-	indexes := make([]uint64, 0, len(bf.hashes))
-
-	for _, hashFunc := range bf.hashes {
-		if hashFunc == nil {
-			continue
-		}
-		hash := hashFunc(data)
-		indexes = append(indexes, bf.index(hash))
-	}
-
-	bf.setHashes(indexes...)
+	bf.setHashes(bf.indexes(data)...)
 }
 
 // Test checks if the given data is likely to be in the bloom filter by applying each hash function to the data and checking if the corresponding bits in the filter are set.
 // This method is thread-safe. It returns true if all bits are set, indicating that the data is likely to be in the filter, and false otherwise.
 func (bf *ConcurrentBloomFilter) Test(data []byte) bool {
-	indexes := make([]uint64, 0, len(bf.hashes))
-
-	// Spent more time on hashing, so we don't have to lock for each bit access.
-	for _, hashFunc := range bf.hashes {
-		if hashFunc == nil {
-			continue
-		}
-		hash := hashFunc(data)
-		indexes = append(indexes, bf.index(hash))
-	}
-
-	return bf.getHashes(indexes...)
+	return bf.getHashes(bf.indexes(data)...)
 }
 
 // GetHash checks if the bit at the index corresponding to the given hash value is set to 1.
@@ -96,6 +76,16 @@ func (bf *ConcurrentBloomFilter) Bits() Bits {
 	return bf.bits.Copy()
 }
 
+// indexes hashes the data before taking the lock, so we don't have to lock for each bit access.
+func (bf *ConcurrentBloomFilter) indexes(data []byte) []uint64 {
+	indexes := make([]uint64, 0, len(bf.hashes))
+	for _, hash := range bf.derived.all(bf.hashes, data) {
+		indexes = append(indexes, bf.index(hash))
+	}
+
+	return indexes
+}
+
 func (bf *ConcurrentBloomFilter) getHashes(indexes ...uint64) bool {
 	bf.lock.Lock()
 	defer bf.lock.Unlock()
@@ -0,0 +1,179 @@
+package bloomfilters
+
+import (
+	"errors"
+	"math"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+)
+
+const (
+	// DefaultEpsilon is the default error of a Count-Min sketch relative to the total of all counts.
+	DefaultEpsilon = 0.001
+	// DefaultDelta is the default probability an estimate of a Count-Min sketch overshoots by more than its error.
+	DefaultDelta = 0.01
+)
+
+var (
+	ErrInvalidEpsilon       = errors.New("epsilon must be between 0 and 1")
+	ErrInvalidDelta         = errors.New("delta must be between 0 and 1")
+	ErrIncompatibleSketches = errors.New("sketches do not have the same width, depth or hash functions")
+)
+
+// CountMinSketch estimates how often each item was added, based on "An Improved Data Stream Summary: The Count-Min Sketch and its Applications" by Cormode and Muthukrishnan.
+// It is a grid of depth rows of width counters, an item increments one counter in every row and its estimate is the smallest of those counters.
+// Estimates never undershoot, and overshoot by at most epsilon times the total of all counts with probability 1 - delta.
+// With conservative update only the counters that are below the new estimate are raised, which gives smaller errors at the cost of reading every row on each add.
+// The rows take turns using the hash functions, and the hash value is mixed with the row so rows sharing a hash function are independent.
+type CountMinSketch struct {
+	counts []uint64
+	total  uint64
+
+	width        uint64
+	depth        uint64
+	hashes       []bloomhashes.HashFunction
+	derived      derivedHashes
+	epsilon      float64
+	delta        float64
+	conservative bool
+	restored     bool
+}
+
+// NewCountMinSketch creates a new Count-Min sketch with the given options.
+// The width is derived from [WithEpsilon] unless set with [WithSize], and the depth from [WithDelta], see bloomsettings.CountMinWidth and bloomsettings.CountMinDepth.
+// It returns an error if epsilon or delta are invalid, if any of the hash functions are nil, or if [WithWords] or [WithBits] is given.
+func NewCountMinSketch(opts ...CountMinSketchOptions) (*CountMinSketch, error) {
+	cms := &CountMinSketch{
+		epsilon: DefaultEpsilon,
+		delta:   DefaultDelta,
+	}
+	for _, opt := range opts {
+		opt.applyCountMin(cms)
+	}
+	if cms.restored {
+		return nil, ErrNoBitsToRestore
+	}
+	if cms.epsilon <= 0 || cms.epsilon >= 1 {
+		return nil, ErrInvalidEpsilon
+	}
+	if cms.delta <= 0 || cms.delta >= 1 {
+		return nil, ErrInvalidDelta
+	}
+	if len(cms.hashes) == 0 {
+		return nil, ErrRequiredHashFunction
+	}
+	for _, hashFunc := range cms.hashes {
+		if hashFunc == nil {
+			return nil, ErrHashIsNil
+		}
+	}
+
+	if cms.width == 0 {
+		cms.width = bloomsettings.CountMinWidth(cms.epsilon)
+	}
+	cms.depth = max(bloomsettings.CountMinDepth(cms.delta), 1)
+	cms.counts = make([]uint64, cms.width*cms.depth)
+
+	return cms, nil
+}
+
+// Add increments the count of the given data by one.
+func (cms *CountMinSketch) Add(data []byte) {
+	cms.AddCount(data, 1)
+}
+
+// AddCount increments the count of the given data by count.
+func (cms *CountMinSketch) AddCount(data []byte, count uint64) {
+	indexes := cms.indexes(data)
+	cms.total += count
+
+	if !cms.conservative {
+		for _, index := range indexes {
+			cms.counts[index] += count
+		}
+
+		return
+	}
+
+	// Conservative update, raise the counters to the new estimate at most
+	estimate := cms.estimate(indexes) + count
+	for _, index := range indexes {
+		cms.counts[index] = max(cms.counts[index], estimate)
+	}
+}
+
+// Estimate returns the estimated count of the given data, it is never lower than the real count.
+func (cms *CountMinSketch) Estimate(data []byte) uint64 {
+	return cms.estimate(cms.indexes(data))
+}
+
+// Merge adds the counts of the other sketch to this sketch, as if every item of the other sketch was added to this sketch.
+// Both sketches must have the same width, depth and hash functions, or it returns [ErrIncompatibleSketches].
+func (cms *CountMinSketch) Merge(other *CountMinSketch) error {
+	if cms.width != other.width || cms.depth != other.depth || len(cms.hashes) != len(other.hashes) {
+		return ErrIncompatibleSketches
+	}
+
+	for i, count := range other.counts {
+		cms.counts[i] += count
+	}
+	cms.total += other.total
+
+	return nil
+}
+
+// Total returns the total of all counts added to the sketch.
+func (cms *CountMinSketch) Total() uint64 {
+	return cms.total
+}
+
+// Width returns the amount of counters per row.
+func (cms *CountMinSketch) Width() uint64 {
+	return cms.width
+}
+
+// Depth returns the amount of rows.
+func (cms *CountMinSketch) Depth() uint64 {
+	return cms.depth
+}
+
+// ErrorBound returns the most an estimate overshoots with probability 1 - delta, e / width times the total of all counts.
+func (cms *CountMinSketch) ErrorBound() uint64 {
+	return uint64(math.Ceil(math.E / float64(cms.width) * float64(cms.total)))
+}
+
+// Copy returns a deep copy of the sketch, modifying the copy will not affect the original.
+func (cms *CountMinSketch) Copy() *CountMinSketch {
+	c := *cms
+	c.counts = append([]uint64(nil), cms.counts...)
+
+	return &c
+}
+
+func (cms *CountMinSketch) estimate(indexes []uint64) uint64 {
+	estimate := uint64(math.MaxUint64)
+	for _, index := range indexes {
+		estimate = min(estimate, cms.counts[index])
+	}
+
+	return estimate
+}
+
+// indexes returns the index in counts of the counter of the given data in every row.
+// The rows take turns using the hash functions, so a row uses the hash function of its row number modulo the amount of hash functions.
+func (cms *CountMinSketch) indexes(data []byte) []uint64 {
+	indexes := make([]uint64, cms.depth)
+	for i, hash := range cms.derived.all(cms.hashes, data) {
+		for row := uint64(i); row < cms.depth; row += uint64(len(cms.hashes)) {
+			indexes[row] = cms.index(hash, row)
+		}
+	}
+
+	return indexes
+}
+
+// index returns the index in counts of the counter of the given row, the hash value is mixed with the row so rows sharing a hash function use independent counters.
+func (cms *CountMinSketch) index(hash, row uint64) uint64 {
+	return row*cms.width + xorMix(hash, row)%cms.width
+}
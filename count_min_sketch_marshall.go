@@ -0,0 +1,122 @@
+package bloomfilters
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCountMinData = errors.New("count-min sketch data is invalid")
+
+// countMinHeaderSize is the size of the width, the depth, the total, the amount of hash functions and the flags at the start of a marshalled sketch.
+const countMinHeaderSize = 8 + 8 + 8 + 1 + 1
+
+// countMinConservative is the flag of a sketch that uses conservative update.
+const countMinConservative = 1 << 0
+
+var (
+	_ encoding.BinaryMarshaler   = (*CountMinSketch)(nil)
+	_ encoding.BinaryUnmarshaler = (*CountMinSketch)(nil)
+	_ encoding.TextUnmarshaler   = (*CountMinSketch)(nil)
+	_ encoding.TextMarshaler     = (*CountMinSketch)(nil)
+	_ json.Marshaler             = (*CountMinSketch)(nil)
+	_ json.Unmarshaler           = (*CountMinSketch)(nil)
+)
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler].
+// The sketch must be created with the same hash functions as the marshalled sketch, everything else is taken from the data.
+// It returns [ErrIncompatibleSketches] if the amount of hash functions differs.
+func (cms *CountMinSketch) UnmarshalBinary(data []byte) error {
+	if len(data) < countMinHeaderSize {
+		return ErrInvalidCountMinData
+	}
+
+	width := binary.LittleEndian.Uint64(data)
+	depth := binary.LittleEndian.Uint64(data[8:])
+	if int(data[24]) != len(cms.hashes) {
+		return ErrIncompatibleSketches
+	}
+	counts := data[countMinHeaderSize:]
+	// Check the size against the data before it is used to allocate
+	if width == 0 || depth == 0 || width > uint64(len(counts))/8/depth || uint64(len(counts)) != width*depth*8 {
+		return ErrInvalidCountMinData
+	}
+
+	restored := *cms
+	restored.width, restored.depth = width, depth
+	restored.total = binary.LittleEndian.Uint64(data[16:])
+	restored.conservative = data[25]&countMinConservative != 0
+	restored.counts = make([]uint64, width*depth)
+	for i := range restored.counts {
+		restored.counts[i] = binary.LittleEndian.Uint64(counts[i*8:])
+	}
+
+	*cms = restored
+
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler].
+// The data is the width, the depth and the total as little-endian uint64, the amount of hash functions and the flags as a byte,
+// followed by the counters row by row as little-endian uint64. The hash functions themselves are not part of the data.
+func (cms *CountMinSketch) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, countMinHeaderSize+len(cms.counts)*8)
+	binary.LittleEndian.PutUint64(data, cms.width)
+	binary.LittleEndian.PutUint64(data[8:], cms.depth)
+	binary.LittleEndian.PutUint64(data[16:], cms.total)
+	data[24] = byte(len(cms.hashes))
+	if cms.conservative {
+		data[25] |= countMinConservative
+	}
+	for i, count := range cms.counts {
+		binary.LittleEndian.PutUint64(data[countMinHeaderSize+i*8:], count)
+	}
+
+	return data, nil
+}
+
+// MarshalText implements [encoding.TextMarshaler].
+func (cms *CountMinSketch) MarshalText() (text []byte, err error) {
+	src, err := cms.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	dst := make([]byte, base64.RawStdEncoding.EncodedLen(len(src)))
+	base64.RawStdEncoding.Encode(dst, src)
+
+	return dst, nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (cms *CountMinSketch) UnmarshalText(text []byte) error {
+	src := make([]byte, base64.RawStdEncoding.DecodedLen(len(text)))
+	n, err := base64.RawStdEncoding.Decode(src, text)
+	if err != nil {
+		return err
+	}
+
+	return cms.UnmarshalBinary(src[:n])
+}
+
+// MarshalJSON implements [json.Marshaler], the sketch is a base64 string.
+func (cms *CountMinSketch) MarshalJSON() ([]byte, error) {
+	text, err := cms.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (cms *CountMinSketch) UnmarshalJSON(d []byte) error {
+	var text string
+	if err := json.Unmarshal(d, &text); err != nil {
+		return err
+	}
+
+	return cms.UnmarshalText([]byte(text))
+}
@@ -0,0 +1,257 @@
+package bloomfilters_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addSkewed adds item i of data len(data)/(i+1) times, so a few items are frequent and most are rare.
+func addSkewed(cms *bloomfilters.CountMinSketch, data [][]byte) map[string]uint64 {
+	counts := make(map[string]uint64, len(data))
+	for i, d := range data {
+		count := uint64(len(data) / (i + 1))
+		cms.AddCount(d, count)
+		counts[string(d)] = count
+	}
+
+	return counts
+}
+
+func Test_CountMinSketch_Size(t *testing.T) {
+	cms, err := bloomfilters.NewCountMinSketch(bloomfilters.WithDefaultHashFunctions())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2719), cms.Width())
+	assert.Equal(t, uint64(5), cms.Depth())
+
+	cms, err = bloomfilters.NewCountMinSketch(
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithEpsilon(0.01),
+		bloomfilters.WithDelta(0.001),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(272), cms.Width())
+	assert.Equal(t, uint64(7), cms.Depth())
+
+	cms, err = bloomfilters.NewCountMinSketch(bloomfilters.WithDefaultHashFunctions(), bloomfilters.WithSize(100))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), cms.Width())
+}
+
+func Test_CountMinSketch_DerivedHashes(t *testing.T) {
+	calls := 0
+	counted := func(data []byte) (uint64, uint64) {
+		calls++
+
+		return bloomhashes.XXH3_128(data)
+	}
+	cms, err := bloomfilters.NewCountMinSketch(bloomfilters.WithDerivedHashes(counted, bloomhashes.DoubleHashing, 5))
+	require.NoError(t, err)
+
+	cms.AddCount([]byte("apple"), 3)
+	assert.Equal(t, 1, calls, "Expected the data to be hashed once per add, not once per row")
+
+	calls = 0
+	assert.Equal(t, uint64(3), cms.Estimate([]byte("apple")))
+	assert.Equal(t, 1, calls, "Expected the data to be hashed once per estimate, not once per row")
+}
+
+func Test_CountMinSketch_ErrorBound(t *testing.T) {
+	data := testutil.MoreBytes(2000, 16)
+
+	for _, conservative := range []bool{false, true} {
+		t.Run(fmt.Sprintf("conservative=%v", conservative), func(t *testing.T) {
+			opts := []bloomfilters.CountMinSketchOptions{
+				bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64}),
+				bloomfilters.WithEpsilon(0.01),
+				bloomfilters.WithDelta(0.01),
+			}
+			if conservative {
+				opts = append(opts, bloomfilters.WithConservativeUpdate())
+			}
+			cms, err := bloomfilters.NewCountMinSketch(opts...)
+			require.NoError(t, err)
+
+			counts := addSkewed(cms, data)
+			var total uint64
+			for _, count := range counts {
+				total += count
+			}
+			assert.Equal(t, total, cms.Total())
+
+			exceeded := 0
+			for d, count := range counts {
+				estimate := cms.Estimate([]byte(d))
+				require.GreaterOrEqual(t, estimate, count, "Estimate must never undershoot")
+				if estimate-count > cms.ErrorBound() {
+					exceeded++
+				}
+			}
+			assert.LessOrEqual(t, exceeded, len(data)/100, "At most delta of the estimates may exceed the error bound")
+		})
+	}
+}
+
+func Test_CountMinSketch_Conservative(t *testing.T) {
+	data := testutil.MoreBytes(2000, 16)
+	newSketch := func(opts ...bloomfilters.CountMinSketchOptions) *bloomfilters.CountMinSketch {
+		cms, err := bloomfilters.NewCountMinSketch(append(opts, bloomfilters.WithDefaultHashFunctions(), bloomfilters.WithSize(200))...)
+		require.NoError(t, err)
+
+		return cms
+	}
+	standard, conservative := newSketch(), newSketch(bloomfilters.WithConservativeUpdate())
+	addSkewed(standard, data)
+	counts := addSkewed(conservative, data)
+
+	var standardError, conservativeError uint64
+	for d, count := range counts {
+		standardError += standard.Estimate([]byte(d)) - count
+		conservativeError += conservative.Estimate([]byte(d)) - count
+	}
+	assert.Less(t, conservativeError, standardError)
+}
+
+func Test_CountMinSketch_Merge(t *testing.T) {
+	data := testutil.MoreBytes(500, 16)
+	newSketch := func() *bloomfilters.CountMinSketch {
+		cms, err := bloomfilters.NewCountMinSketch(bloomfilters.WithDefaultHashFunctions(), bloomfilters.WithEpsilon(0.01))
+		require.NoError(t, err)
+
+		return cms
+	}
+	a, b, all := newSketch(), newSketch(), newSketch()
+	for i, d := range data {
+		if i%3 == 0 {
+			a.Add(d)
+		} else {
+			b.Add(d)
+		}
+		all.Add(d)
+	}
+
+	require.NoError(t, a.Merge(b))
+	assert.Equal(t, all.Total(), a.Total())
+	for _, d := range data {
+		assert.Equal(t, all.Estimate(d), a.Estimate(d))
+	}
+
+	other, err := bloomfilters.NewCountMinSketch(bloomfilters.WithDefaultHashFunctions(), bloomfilters.WithEpsilon(0.02))
+	require.NoError(t, err)
+	require.ErrorIs(t, a.Merge(other), bloomfilters.ErrIncompatibleSketches)
+}
+
+func Test_CountMinSketch_Marshal(t *testing.T) {
+	data := testutil.MoreBytes(300, 16)
+	cms, err := bloomfilters.NewCountMinSketch(bloomfilters.WithDefaultHashFunctions(), bloomfilters.WithEpsilon(0.05), bloomfilters.WithConservativeUpdate())
+	require.NoError(t, err)
+	addSkewed(cms, data)
+
+	restored, err := bloomfilters.NewCountMinSketch(bloomfilters.WithDefaultHashFunctions())
+	require.NoError(t, err)
+
+	binary, err := cms.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, restored.UnmarshalBinary(binary))
+	assert.Equal(t, cms.Width(), restored.Width())
+	assert.Equal(t, cms.Depth(), restored.Depth())
+	assert.Equal(t, cms.Total(), restored.Total())
+	// Conservative update is restored as well
+	cms.Add(data[0])
+	restored.Add(data[0])
+	again, err := restored.MarshalBinary()
+	require.NoError(t, err)
+	binary, err = cms.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, binary, again)
+
+	text, err := json.Marshal(cms)
+	require.NoError(t, err)
+	restored, err = bloomfilters.NewCountMinSketch(bloomfilters.WithDefaultHashFunctions())
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(text, restored))
+	for _, d := range data {
+		assert.Equal(t, cms.Estimate(d), restored.Estimate(d))
+	}
+
+	require.ErrorIs(t, restored.UnmarshalBinary(binary[:len(binary)-1]), bloomfilters.ErrInvalidCountMinData)
+	require.ErrorIs(t, restored.UnmarshalBinary(binary[:10]), bloomfilters.ErrInvalidCountMinData)
+	single, err := bloomfilters.NewCountMinSketch(bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64}))
+	require.NoError(t, err)
+	require.ErrorIs(t, single.UnmarshalBinary(binary), bloomfilters.ErrIncompatibleSketches)
+}
+
+func Test_CountMinSketch_Invalid(t *testing.T) {
+	hashes := bloomfilters.WithDefaultHashFunctions()
+
+	tests := []struct {
+		name string
+		opts []bloomfilters.CountMinSketchOptions
+		err  error
+	}{
+		{"epsilon", []bloomfilters.CountMinSketchOptions{bloomfilters.WithEpsilon(0), hashes}, bloomfilters.ErrInvalidEpsilon},
+		{"delta", []bloomfilters.CountMinSketchOptions{bloomfilters.WithDelta(1), hashes}, bloomfilters.ErrInvalidDelta},
+		{"no hashes", []bloomfilters.CountMinSketchOptions{}, bloomfilters.ErrRequiredHashFunction},
+		{"nil hash", []bloomfilters.CountMinSketchOptions{bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{nil})}, bloomfilters.ErrHashIsNil},
+		{"words", []bloomfilters.CountMinSketchOptions{bloomfilters.WithWords(make([]uint64, 1)), hashes}, bloomfilters.ErrNoBitsToRestore},
+		{"bits", []bloomfilters.CountMinSketchOptions{bloomfilters.WithSize(64), bloomfilters.WithBits(bloomfilters.NewBits(64)), hashes}, bloomfilters.ErrNoBitsToRestore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cms, err := bloomfilters.NewCountMinSketch(tt.opts...)
+			require.ErrorIs(t, err, tt.err)
+			require.Nil(t, cms)
+		})
+	}
+}
+
+// Fuzz test for CountMinSketch AddCount and Estimate
+func Fuzz_CountMinSketch_Estimate(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cms, err := bloomfilters.NewCountMinSketch(
+			bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.MD5}),
+			bloomfilters.WithEpsilon(0.1),
+		)
+		require.NoError(t, err)
+
+		cms.AddCount(data, 5)
+		cms.Add(data)
+		require.GreaterOrEqual(t, cms.Estimate(data), uint64(6), "Estimate must never undershoot")
+		require.Equal(t, uint64(6), cms.Total())
+	})
+}
+
+// Example of counting how often keys are seen with a CountMinSketch
+func ExampleCountMinSketch() {
+	cms, _ := bloomfilters.NewCountMinSketch(
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithEpsilon(0.001), // error of at most 0.1% of the total
+		bloomfilters.WithDelta(0.01),    // with 99% probability
+	)
+
+	cms.AddCount([]byte("apple"), 3)
+	cms.Add([]byte("banana"))
+	cms.Add([]byte("apple"))
+
+	fmt.Println(cms.Estimate([]byte("apple")))
+	fmt.Println(cms.Estimate([]byte("banana")))
+	fmt.Println(cms.Total())
+
+	// Output:
+	// 4
+	// 1
+	// 5
+}
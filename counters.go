@@ -0,0 +1,145 @@
+package bloomfilters
+
+import "slices"
+
+// Counters is a structure that represents a packed array of fixed-width unsigned counters.
+// It is used by filters that need more than a single bit per slot, such as the counting bloom filter.
+// Counters of any width between 1 and 64 bits are supported, counters may span two words.
+type Counters struct {
+	data  []uint64
+	width uint8
+	size  uint64
+}
+
+// NewCounters creates a new Counters structure that can hold at least the specified amount of counters of the given width in bits.
+// The width is clamped between 1 and 64 bits. Like [NewBits], at least one uint64 word is always allocated.
+func NewCounters(size uint64, width uint8) Counters {
+	width = min(max(width, 1), 64)
+	words := max((size*uint64(width)+63)/64, 1)
+
+	return Counters{
+		data:  make([]uint64, words),
+		width: width,
+		size:  words * 64 / uint64(width), // Use all the space that has been allocated
+	}
+}
+
+// Size returns the total number of counters this storage can hold.
+func (c *Counters) Size() uint64 {
+	return c.size
+}
+
+// Width returns the width of a single counter in bits.
+func (c *Counters) Width() uint8 {
+	return c.width
+}
+
+// Max returns the maximum value a single counter can hold, counters that reach this value are saturated.
+func (c *Counters) Max() uint64 {
+	if c.width >= 64 {
+		return ^uint64(0)
+	}
+
+	return (1 << c.width) - 1
+}
+
+// Get returns the value of the counter at the specified index. If the index is out of bounds, it returns 0.
+func (c *Counters) Get(index uint64) uint64 {
+	if index >= c.size {
+		return 0
+	}
+	word, shift := c.calculateIndex(index)
+
+	v := c.data[word] >> shift
+	if shift+uint64(c.width) > 64 {
+		v |= c.data[word+1] << (64 - shift)
+	}
+
+	return v & c.Max()
+}
+
+// Set sets the counter at the specified index to the given value, the value is truncated to the width of the counters.
+// If the index is out of bounds, it will not set any counter.
+func (c *Counters) Set(index, value uint64) {
+	if index >= c.size {
+		return // Index is out of bounds, do not set any counter
+	}
+	word, shift := c.calculateIndex(index)
+	mask := c.Max()
+	value &= mask
+
+	c.data[word] = (c.data[word] &^ (mask << shift)) | (value << shift)
+	if shift+uint64(c.width) > 64 {
+		// The counter spills over into the next word
+		spill := 64 - shift
+		c.data[word+1] = (c.data[word+1] &^ (mask >> spill)) | (value >> spill)
+	}
+}
+
+// Increment adds one to the counter at the specified index.
+// It returns false if the counter is already saturated or the index is out of bounds, in which case the counter is left untouched.
+func (c *Counters) Increment(index uint64) bool {
+	v := c.Get(index)
+	if index >= c.size || v >= c.Max() {
+		return false
+	}
+	c.Set(index, v+1)
+
+	return true
+}
+
+// Decrement subtracts one from the counter at the specified index.
+// Saturated counters are never decremented, since their true value is unknown.
+// It returns false if the counter is zero, saturated or the index is out of bounds, in which case the counter is left untouched.
+func (c *Counters) Decrement(index uint64) bool {
+	v := c.Get(index)
+	if v == 0 || v >= c.Max() {
+		return false
+	}
+	c.Set(index, v-1)
+
+	return true
+}
+
+// Count returns the total number of counters that are not zero.
+func (c *Counters) Count() uint64 {
+	var count uint64
+	for i := range c.size {
+		if c.Get(i) != 0 {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Equals returns true if this Counters structure is equal to the other Counters structure.
+func (c *Counters) Equals(other *Counters) bool {
+	return c.width == other.width && c.size == other.size && slices.Equal(c.data, other.data)
+}
+
+// Words returns a copy slice of uint64 words representing the packed counters.
+func (c *Counters) Words() []uint64 {
+	w := make([]uint64, len(c.data))
+	copy(w, c.data)
+
+	return w
+}
+
+// Copy returns a deep copy of the Counters structure.
+func (c *Counters) Copy() Counters {
+	return Counters{
+		data:  c.Words(),
+		width: c.width,
+		size:  c.size,
+	}
+}
+
+// calculateIndex calculates the word and bit offset of the first bit of the counter at the given index.
+func (c *Counters) calculateIndex(index uint64) (word, shift uint64) {
+	offset := index * uint64(c.width)
+	word = offset / 64
+	shift = offset % 64
+
+	return
+}
@@ -0,0 +1,107 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Fuzz_Counters_SetGet(f *testing.F) {
+	size := uint64(256) // Example size for the Counters structure
+	f.Add(uint64(0), uint8(4), uint64(3))
+	f.Add(size-1, uint8(3), uint64(7)) // Counter spanning two words
+	f.Add(uint64(21), uint8(5), uint64(31))
+	f.Add(uint64(7), uint8(64), ^uint64(0))
+	f.Add(uint64(1090), uint8(8), uint64(1))
+
+	f.Fuzz(func(t *testing.T, index uint64, width uint8, value uint64) {
+		counters := bloomfilters.NewCounters(size, width)
+		value &= counters.Max()
+		counters.Set(index, value)
+
+		// Check that other counters are not set
+		for i := range counters.Size() {
+			expect := uint64(0)
+			if i == index {
+				expect = value // Only the counter at 'index' should be set
+			}
+			require.Equal(t, expect, counters.Get(i), "Expected counter at index %d to be %v", i, expect)
+		}
+	})
+}
+
+func Test_Counters_Size(t *testing.T) {
+	testCases := []struct {
+		size, expected uint64
+		width          uint8
+	}{
+		{size: 0, width: 4, expected: 16},
+		{size: 100, width: 4, expected: 112},
+		{size: 100, width: 3, expected: 106},
+		{size: 10, width: 64, expected: 10},
+		{size: 10, width: 0, expected: 64},   // Clamped to 1 bit
+		{size: 10, width: 100, expected: 10}, // Clamped to 64 bits
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%d_%d", tc.size, tc.width), func(t *testing.T) {
+			counters := bloomfilters.NewCounters(tc.size, tc.width)
+			assert.Equal(t, tc.expected, counters.Size())
+			assert.GreaterOrEqual(t, counters.Size(), tc.size)
+		})
+	}
+}
+
+func Test_Counters_Saturation(t *testing.T) {
+	counters := bloomfilters.NewCounters(64, 2)
+	require.Equal(t, uint64(3), counters.Max())
+
+	assert.True(t, counters.Increment(5))
+	assert.True(t, counters.Increment(5))
+	assert.True(t, counters.Increment(5))
+	assert.False(t, counters.Increment(5), "Expected a saturated counter to refuse an increment")
+	assert.Equal(t, uint64(3), counters.Get(5))
+
+	assert.False(t, counters.Decrement(5), "Expected a saturated counter to never be decremented")
+	assert.Equal(t, uint64(3), counters.Get(5))
+
+	assert.True(t, counters.Increment(6))
+	assert.True(t, counters.Decrement(6))
+	assert.False(t, counters.Decrement(6), "Expected a zero counter to refuse a decrement")
+	assert.Equal(t, uint64(0), counters.Get(6))
+
+	assert.Equal(t, uint64(1), counters.Count())
+}
+
+func Test_Counters_Copy(t *testing.T) {
+	counters := bloomfilters.NewCounters(64, 4)
+	counters.Set(1, 9)
+
+	cp := counters.Copy()
+	assert.True(t, counters.Equals(&cp))
+
+	cp.Set(2, 1)
+	assert.False(t, counters.Equals(&cp), "Expected the copy to be independent of the original")
+	assert.Equal(t, uint64(0), counters.Get(2))
+}
+
+func ExampleCounters() {
+	counters := bloomfilters.NewCounters(128, 4) // Create 128 counters of 4 bits each
+	counters.Increment(5)
+	counters.Increment(5)
+	counters.Set(10, 15)
+
+	fmt.Println(counters.Get(5))
+	fmt.Println(counters.Get(10))
+	fmt.Println(counters.Increment(10)) // Saturated
+	fmt.Println(counters.Get(0))
+
+	// Output:
+	// 2
+	// 15
+	// false
+	// 0
+}
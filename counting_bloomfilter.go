@@ -0,0 +1,161 @@
+package bloomfilters
+
+import (
+	"errors"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+// DefaultCounterWidth is the default width in bits of a single counter in a counting bloom filter.
+// 4 bits is enough to make overflows extremely unlikely for filters with an optimal number of hash functions.
+const DefaultCounterWidth = 4
+
+var (
+	ErrInvalidCounterWidth = errors.New("counter width must be between 1 and 64 bits")
+	ErrNotInFilter         = errors.New("data is not in the filter")
+)
+
+var _ IBloomFilter = &CountingBloomFilter{}
+
+// CountingBloomFilter is a bloom filter that keeps a small counter per slot instead of a single bit, which allows items to be removed again.
+// Counters that overflow saturate at their maximum value and are never decremented afterwards, trading a few permanent slots for never producing false negatives.
+type CountingBloomFilter struct {
+	counters Counters
+	hashes   []bloomhashes.HashFunction
+	derived  derivedHashes
+
+	size  uint64
+	width uint8
+	seed  *Bits
+}
+
+// NewCountingBloomFilter creates a new counting bloom filter with the given options.
+// It returns an error if the size of the bloom filter or the counter width is invalid, or if any of the hash functions are nil.
+func NewCountingBloomFilter(opts ...CountingBloomFilterOptions) (*CountingBloomFilter, error) {
+	bf := &CountingBloomFilter{
+		width: DefaultCounterWidth,
+	}
+	for _, opt := range opts {
+		opt.applyCounting(bf)
+	}
+	if bf.size == 0 {
+		return nil, ErrInvalidSize
+	}
+	if bf.width == 0 || bf.width > 64 {
+		return nil, ErrInvalidCounterWidth
+	}
+	if len(bf.hashes) == 0 {
+		return nil, ErrRequiredHashFunction
+	}
+	for _, hashFunc := range bf.hashes {
+		if hashFunc == nil {
+			return nil, ErrHashIsNil
+		}
+	}
+
+	bf.counters = NewCounters(bf.size, bf.width)
+	if bf.seed != nil {
+		// Every bit that was set, has been set by at least one item
+		for i := range bf.seed.Size() {
+			if bf.seed.Getbit(i) {
+				bf.counters.Set(i, 1)
+			}
+		}
+		bf.seed = nil
+	}
+
+	return bf, nil
+}
+
+// Add adds the given data to the bloom filter by applying each hash function to the data and incrementing the corresponding counters in the filter.
+func (bf *CountingBloomFilter) Add(data []byte) {
+	bf.addIndexes(bf.indexes(data))
+}
+
+// Test checks if the given data is likely to be in the bloom filter by applying each hash function to the data and checking if the corresponding counters in the filter are not zero.
+// It returns true if all counters are set, indicating that the data is likely to be in the filter, and false otherwise.
+func (bf *CountingBloomFilter) Test(data []byte) bool {
+	return bf.testIndexes(bf.indexes(data))
+}
+
+// Remove removes the given data from the bloom filter by decrementing the corresponding counters in the filter.
+// It returns [ErrNotInFilter] and leaves the filter untouched if the data is definitely not in the filter.
+// Removing data that was never added, but is a false positive, will introduce false negatives for other items.
+func (bf *CountingBloomFilter) Remove(data []byte) error {
+	return bf.removeIndexes(bf.indexes(data))
+}
+
+// SetHash increments the counter at the index corresponding to the given hash value.
+func (bf *CountingBloomFilter) SetHash(hash uint64) {
+	bf.counters.Increment(bf.index(hash))
+}
+
+// GetHash checks if the counter at the index corresponding to the given hash value is not zero.
+func (bf *CountingBloomFilter) GetHash(hash uint64) bool {
+	return bf.counters.Get(bf.index(hash)) != 0
+}
+
+// BitsCount returns the total number of counters that are not zero in the bloom filter.
+func (bf *CountingBloomFilter) BitsCount() uint64 {
+	return bf.counters.Count()
+}
+
+// Bits returns a Bits struct where each bit is set if the corresponding counter is not zero.
+// Modifying the returned Bits will not affect the internal state of the bloom filter.
+func (bf *CountingBloomFilter) Bits() Bits {
+	b := NewBits(bf.counters.Size())
+	for i := range bf.counters.Size() {
+		if bf.counters.Get(i) != 0 {
+			b.Setbit(i)
+		}
+	}
+
+	return b
+}
+
+// Counters returns a copy of the Counters struct representing the counters of the bloom filter.
+// Modifying the returned Counters will not affect the internal state of the bloom filter.
+func (bf *CountingBloomFilter) Counters() Counters {
+	return bf.counters.Copy()
+}
+
+func (bf *CountingBloomFilter) indexes(data []byte) []uint64 {
+	indexes := make([]uint64, 0, len(bf.hashes))
+	for _, hash := range bf.derived.all(bf.hashes, data) {
+		indexes = append(indexes, bf.index(hash))
+	}
+
+	return indexes
+}
+
+func (bf *CountingBloomFilter) addIndexes(indexes []uint64) {
+	for _, index := range indexes {
+		bf.counters.Increment(index)
+	}
+}
+
+func (bf *CountingBloomFilter) testIndexes(indexes []uint64) bool {
+	for _, index := range indexes {
+		if bf.counters.Get(index) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (bf *CountingBloomFilter) removeIndexes(indexes []uint64) error {
+	// Check first, so a failed remove never leaves the filter half decremented
+	if !bf.testIndexes(indexes) {
+		return ErrNotInFilter
+	}
+	for _, index := range indexes {
+		bf.counters.Decrement(index)
+	}
+
+	return nil
+}
+
+func (bf *CountingBloomFilter) index(hash uint64) uint64 {
+	return hash % bf.counters.Size()
+}
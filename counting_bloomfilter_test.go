@@ -0,0 +1,259 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// removableFilter is a bloom filter that supports removing items.
+type removableFilter interface {
+	bloomfilters.IBloomFilter
+	Remove(data []byte) error
+}
+
+// countingFilters returns a list of counting filter factories to test against.
+func countingFilters() map[string]func(opts ...bloomfilters.CountingBloomFilterOptions) (removableFilter, error) {
+	return map[string]func(opts ...bloomfilters.CountingBloomFilterOptions) (removableFilter, error){
+		"CountingBloomFilter": func(opts ...bloomfilters.CountingBloomFilterOptions) (removableFilter, error) {
+			return bloomfilters.NewCountingBloomFilter(opts...)
+		},
+		"ConcurrentCountingBloomFilter": func(opts ...bloomfilters.CountingBloomFilterOptions) (removableFilter, error) {
+			return bloomfilters.NewConcurrentCountingBloomFilter(opts...)
+		},
+	}
+}
+
+func Test_CountingBloomFilter_Remove(t *testing.T) {
+	for name, factory := range countingFilters() {
+		t.Run(name, func(t *testing.T) {
+			bf, err := factory(
+				bloomfilters.WithSize(1024),
+				bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.Fnv1_64a}),
+			)
+			require.NoError(t, err)
+
+			bf.Add([]byte("hello"))
+			bf.Add([]byte("world"))
+			require.True(t, bf.Test([]byte("hello")))
+
+			require.NoError(t, bf.Remove([]byte("hello")))
+			assert.False(t, bf.Test([]byte("hello")), "Expected 'hello' to be removed from the filter")
+			assert.True(t, bf.Test([]byte("world")), "Expected 'world' to still be in the filter")
+
+			require.NoError(t, bf.Remove([]byte("world")))
+			assert.Equal(t, uint64(0), bf.BitsCount(), "Expected the filter to be empty again")
+		})
+	}
+}
+
+func Test_CountingBloomFilter_RemoveNotPresent(t *testing.T) {
+	for name, factory := range countingFilters() {
+		t.Run(name, func(t *testing.T) {
+			bf, err := factory(
+				bloomfilters.WithSize(1024),
+				bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.Fnv1_64a}),
+			)
+			require.NoError(t, err)
+
+			bf.Add([]byte("hello"))
+			before := bf.Bits()
+
+			err = bf.Remove([]byte("world"))
+			require.ErrorIs(t, err, bloomfilters.ErrNotInFilter)
+
+			after := bf.Bits()
+			assert.True(t, before.Equals(&after), "Expected a failed remove to leave the filter untouched")
+		})
+	}
+}
+
+func Test_CountingBloomFilter_AddTwiceRemoveOnce(t *testing.T) {
+	for name, factory := range countingFilters() {
+		t.Run(name, func(t *testing.T) {
+			bf, err := factory(
+				bloomfilters.WithSize(1024),
+				bloomfilters.WithDefaultHashFunctions(),
+			)
+			require.NoError(t, err)
+
+			data := []byte("duplicate")
+			bf.Add(data)
+			bf.Add(data)
+
+			require.NoError(t, bf.Remove(data))
+			assert.True(t, bf.Test(data), "Expected data added twice to survive a single remove")
+			require.NoError(t, bf.Remove(data))
+			assert.False(t, bf.Test(data))
+		})
+	}
+}
+
+func Test_CountingBloomFilter_Saturation(t *testing.T) {
+	for name, factory := range countingFilters() {
+		t.Run(name, func(t *testing.T) {
+			bf, err := factory(
+				bloomfilters.WithSize(64),
+				bloomfilters.WithCounterWidth(1),
+				bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64}),
+			)
+			require.NoError(t, err)
+
+			// With 1 bit counters, a second add saturates the counter
+			data := []byte("saturate")
+			bf.Add(data)
+			bf.Add(data)
+
+			require.NoError(t, bf.Remove(data))
+			assert.True(t, bf.Test(data), "Expected saturated counters to never be decremented")
+		})
+	}
+}
+
+func Test_CountingBloomFilter_NoFalseNegatives(t *testing.T) {
+	data := testutil.MoreBytes(1000, 32)
+
+	for name, factory := range countingFilters() {
+		t.Run(name, func(t *testing.T) {
+			bf, err := factory(
+				bloomfilters.WithSize(16384),
+				bloomfilters.WithDefaultHashFunctions(),
+			)
+			require.NoError(t, err)
+
+			for _, d := range data {
+				bf.Add(d)
+			}
+			// Remove the first half
+			for _, d := range data[:500] {
+				require.NoError(t, bf.Remove(d))
+			}
+			for _, d := range data[500:] {
+				require.True(t, bf.Test(d), "Expected remaining data to still be found in the filter")
+			}
+		})
+	}
+}
+
+func Test_CountingBloomFilter_InvalidCounterWidth(t *testing.T) {
+	for name, factory := range countingFilters() {
+		t.Run(name, func(t *testing.T) {
+			for _, width := range []uint8{0, 65} {
+				bf, err := factory(
+					bloomfilters.WithSize(1024),
+					bloomfilters.WithCounterWidth(width),
+					bloomfilters.WithDefaultHashFunctions(),
+				)
+				require.ErrorIs(t, err, bloomfilters.ErrInvalidCounterWidth)
+				require.Nil(t, bf)
+			}
+		})
+	}
+}
+
+func Test_CountingBloomFilter_WithBits(t *testing.T) {
+	hashes := []bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.Fnv1_64a}
+	bf, err := bloomfilters.NewBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithHashFunctions(hashes),
+	)
+	require.NoError(t, err)
+	bf.Add([]byte("hello"))
+
+	cbf, err := bloomfilters.NewCountingBloomFilter(
+		bloomfilters.WithBits(bf.Bits()),
+		bloomfilters.WithHashFunctions(hashes),
+	)
+	require.NoError(t, err)
+
+	assert.True(t, cbf.Test([]byte("hello")), "Expected the counting filter to start from the given bits")
+	require.NoError(t, cbf.Remove([]byte("hello")))
+	assert.False(t, cbf.Test([]byte("hello")))
+}
+
+func Test_ConcurrentCountingBloomFilter_Concurrent(t *testing.T) {
+	data := testutil.MoreBytes(800, 32)
+
+	bf, err := bloomfilters.NewConcurrentCountingBloomFilter(
+		bloomfilters.WithSize(16384),
+		bloomfilters.WithDefaultHashFunctions(),
+	)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	for i := range 8 {
+		chunk := data[i*100 : (i+1)*100]
+		wg.Go(func() {
+			for _, d := range chunk {
+				bf.Add(d)
+			}
+			for _, d := range chunk[:50] {
+				assert.NoError(t, bf.Remove(d))
+			}
+		})
+	}
+	wg.Wait()
+
+	for i := range 8 {
+		for _, d := range data[i*100+50 : (i+1)*100] {
+			require.True(t, bf.Test(d), "Expected remaining data to still be found in the filter")
+		}
+	}
+}
+
+// Fuzz test for CountingBloomFilter Add, Test and Remove
+func Fuzz_CountingBloomFilter_AddRemove(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		bf, err := bloomfilters.NewCountingBloomFilter(
+			bloomfilters.WithSize(1024),
+			bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{
+				bloomhashes.Fnv1_64,
+				bloomhashes.MD5,
+			}),
+		)
+		require.NoError(t, err)
+
+		bf.Add(data)
+		require.True(t, bf.Test(data), "Expected added data to be found in the filter")
+
+		require.NoError(t, bf.Remove(data))
+		require.False(t, bf.Test(data), "Expected removed data to not be found in the filter")
+	})
+}
+
+// Example of basic CountingBloomFilter usage
+func ExampleCountingBloomFilter() {
+	// Create a counting bloom filter with 1024 counters of 4 bits each
+	bf, _ := bloomfilters.NewCountingBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithCounterWidth(4),
+		bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.Fnv1_64a}),
+	)
+
+	bf.Add([]byte("apple"))
+	bf.Add([]byte("banana"))
+
+	// Remove an item again
+	err := bf.Remove([]byte("apple"))
+
+	fmt.Println(err)
+	fmt.Println(bf.Test([]byte("apple")))
+	fmt.Println(bf.Test([]byte("banana")))
+
+	// Output:
+	// <nil>
+	// false
+	// true
+}
@@ -0,0 +1,107 @@
+package bloomfilters
+
+import (
+	"github.com/daanv2/go-bloom-filters/pkg/extensions/xsync"
+)
+
+var _ IBloomFilter = &ConcurrentCountingBloomFilter{}
+
+// ConcurrentCountingBloomFilter is a thread-safe counting bloom filter that uses a spinlock for concurrent access.
+// It is safe to call Add, Test and Remove methods from multiple goroutines.
+type ConcurrentCountingBloomFilter struct {
+	base *CountingBloomFilter
+	lock xsync.SpinLock
+}
+
+// NewConcurrentCountingBloomFilter creates a new concurrent counting bloom filter with the given options.
+// It returns an error if the size of the bloom filter or the counter width is invalid, or if any of the hash functions are nil.
+func NewConcurrentCountingBloomFilter(opts ...CountingBloomFilterOptions) (*ConcurrentCountingBloomFilter, error) {
+	base, err := NewCountingBloomFilter(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConcurrentCountingBloomFilter{
+		base: base,
+		lock: *xsync.NewSpinLock(),
+	}, nil
+}
+
+// Add adds the given data to the bloom filter by applying each hash function to the data and incrementing the corresponding counters in the filter.
+// This method is thread-safe.
+func (bf *ConcurrentCountingBloomFilter) Add(data []byte) {
+	indexes := bf.base.indexes(data)
+
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	bf.base.addIndexes(indexes)
+}
+
+// Test checks if the given data is likely to be in the bloom filter by applying each hash function to the data and checking if the corresponding counters in the filter are not zero.
+// This method is thread-safe. It returns true if all counters are set, indicating that the data is likely to be in the filter, and false otherwise.
+func (bf *ConcurrentCountingBloomFilter) Test(data []byte) bool {
+	// Spent more time on hashing, so we don't have to lock for each counter access.
+	indexes := bf.base.indexes(data)
+
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	return bf.base.testIndexes(indexes)
+}
+
+// Remove removes the given data from the bloom filter by decrementing the corresponding counters in the filter.
+// This method is thread-safe. It returns [ErrNotInFilter] and leaves the filter untouched if the data is definitely not in the filter.
+func (bf *ConcurrentCountingBloomFilter) Remove(data []byte) error {
+	indexes := bf.base.indexes(data)
+
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	return bf.base.removeIndexes(indexes)
+}
+
+// GetHash checks if the counter at the index corresponding to the given hash value is not zero.
+// This method is thread-safe.
+func (bf *ConcurrentCountingBloomFilter) GetHash(hash uint64) bool {
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	return bf.base.GetHash(hash)
+}
+
+// SetHash increments the counter at the index corresponding to the given hash value.
+// This method is thread-safe.
+func (bf *ConcurrentCountingBloomFilter) SetHash(hash uint64) {
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	bf.base.SetHash(hash)
+}
+
+// BitsCount returns the total number of counters that are not zero in the bloom filter.
+// This method is thread-safe.
+func (bf *ConcurrentCountingBloomFilter) BitsCount() uint64 {
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	return bf.base.BitsCount()
+}
+
+// Bits returns a Bits struct where each bit is set if the corresponding counter is not zero.
+// This method is thread-safe. Modifying the returned Bits will not affect the internal state of the bloom filter.
+func (bf *ConcurrentCountingBloomFilter) Bits() Bits {
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	return bf.base.Bits()
+}
+
+// Counters returns a copy of the Counters struct representing the counters of the bloom filter.
+// This method is thread-safe. Modifying the returned Counters will not affect the internal state of the bloom filter.
+func (bf *ConcurrentCountingBloomFilter) Counters() Counters {
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	return bf.base.Counters()
+}
@@ -0,0 +1,99 @@
+package bloomfilters
+
+import (
+	"github.com/daanv2/go-bloom-filters/pkg/extensions/xsync"
+)
+
+// ConcurrentCuckooFilter is a thread-safe cuckoo filter that uses a spinlock for concurrent access.
+// It is safe to call Add, Test and Remove methods from multiple goroutines.
+type ConcurrentCuckooFilter struct {
+	base *CuckooFilter
+	lock xsync.SpinLock
+}
+
+// NewConcurrentCuckooFilter creates a new concurrent cuckoo filter with the given options.
+// It returns an error if the size of the filter or the fingerprint size is invalid, or if not exactly one hash function is given.
+func NewConcurrentCuckooFilter(opts ...CuckooFilterOptions) (*ConcurrentCuckooFilter, error) {
+	base, err := NewCuckooFilter(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConcurrentCuckooFilter{
+		base: base,
+		lock: *xsync.NewSpinLock(),
+	}, nil
+}
+
+// Add adds the given data to the filter, relocating existing fingerprints if both candidate buckets are full.
+// This method is thread-safe. It returns [ErrFilterFull] like [CuckooFilter.Add], once a fingerprint had to be kept aside as a victim.
+func (cf *ConcurrentCuckooFilter) Add(data []byte) error {
+	// Hash outside of the lock, only the table access needs to be guarded
+	fp, i1 := cf.base.fingerprint(cf.base.hash(data))
+
+	cf.lock.Lock()
+	defer cf.lock.Unlock()
+
+	return cf.base.add(fp, i1)
+}
+
+// Test checks if the given data is likely to be in the filter, by checking both candidate buckets for its fingerprint.
+// This method is thread-safe.
+func (cf *ConcurrentCuckooFilter) Test(data []byte) bool {
+	fp, i1 := cf.base.fingerprint(cf.base.hash(data))
+
+	cf.lock.Lock()
+	defer cf.lock.Unlock()
+
+	return cf.base.test(fp, i1)
+}
+
+// Remove removes one copy of the given data from the filter.
+// This method is thread-safe. It returns [ErrNotInFilter] if the fingerprint of the data is not in the filter.
+func (cf *ConcurrentCuckooFilter) Remove(data []byte) error {
+	fp, i1 := cf.base.fingerprint(cf.base.hash(data))
+
+	cf.lock.Lock()
+	defer cf.lock.Unlock()
+
+	return cf.base.remove(fp, i1)
+}
+
+// Count returns the amount of items in the filter.
+// This method is thread-safe.
+func (cf *ConcurrentCuckooFilter) Count() uint64 {
+	cf.lock.Lock()
+	defer cf.lock.Unlock()
+
+	return cf.base.Count()
+}
+
+// Capacity returns the total amount of fingerprint slots in the filter.
+// This method is thread-safe.
+func (cf *ConcurrentCuckooFilter) Capacity() uint64 {
+	return cf.base.Capacity()
+}
+
+// LoadFactor returns the fraction of fingerprint slots that are in use.
+// This method is thread-safe.
+func (cf *ConcurrentCuckooFilter) LoadFactor() float64 {
+	cf.lock.Lock()
+	defer cf.lock.Unlock()
+
+	return cf.base.LoadFactor()
+}
+
+// FingerprintBits returns the size in bits of a single fingerprint.
+// This method is thread-safe.
+func (cf *ConcurrentCuckooFilter) FingerprintBits() uint8 {
+	return cf.base.FingerprintBits()
+}
+
+// Words returns a copy slice of uint64 words representing the fingerprint table of the filter, like [CuckooFilter.Words].
+// This method is thread-safe.
+func (cf *ConcurrentCuckooFilter) Words() []uint64 {
+	cf.lock.Lock()
+	defer cf.lock.Unlock()
+
+	return cf.base.Words()
+}
@@ -0,0 +1,337 @@
+package bloomfilters
+
+import (
+	"errors"
+	"math/bits"
+	"math/rand/v2"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+const (
+	// CuckooBucketSize is the amount of fingerprints a single bucket of a [CuckooFilter] can hold.
+	CuckooBucketSize = 4
+	// DefaultFingerprintBits is the default size in bits of a fingerprint in a cuckoo filter.
+	DefaultFingerprintBits = 12
+	// DefaultMaxKicks is the default amount of fingerprints a cuckoo filter relocates before it gives up on an insert.
+	DefaultMaxKicks = 500
+)
+
+var (
+	ErrFilterFull             = errors.New("filter is full")
+	ErrInvalidFingerprintBits = errors.New("fingerprint size must be 8, 12 or 16 bits")
+	ErrInvalidMaxKicks        = errors.New("max kicks must be at least 1")
+	ErrSingleHashFunction     = errors.New("exactly one hash function is required")
+)
+
+// CuckooFilter is a probabilistic data structure that tests whether an element is a member of a set, like a bloom filter, based on "Cuckoo Filter: Practically Better Than Bloom" by Fan et al.
+// It stores a small fingerprint of every item in one of two candidate buckets of [CuckooBucketSize] slots, relocating existing fingerprints to their alternate bucket when both are full.
+// Unlike a bloom filter, items can be removed again, and it uses less space than a bloom filter for false positive rates below about 3%.
+type CuckooFilter struct {
+	table   Counters
+	hash    bloomhashes.HashFunction
+	buckets uint64
+	count   uint64
+	victim  cuckooVictim
+
+	hashes   []bloomhashes.HashFunction
+	size     uint64
+	capacity uint64
+	words    []uint64
+	fpBits   uint8
+	maxKicks int
+}
+
+// cuckooVictim holds the last fingerprint that could not be placed after relocating [CuckooFilter.maxKicks] fingerprints.
+// Keeping it around means a failed insert never causes false negatives.
+type cuckooVictim struct {
+	fingerprint uint64
+	bucket      uint64
+	used        bool
+}
+
+// NewCuckooFilter creates a new cuckoo filter with the given options, the size is rounded up to a power of 2 number of buckets.
+// It returns an error if the size of the filter or the fingerprint size is invalid, or if not exactly one hash function is given.
+func NewCuckooFilter(opts ...CuckooFilterOptions) (*CuckooFilter, error) {
+	cf := &CuckooFilter{
+		fpBits:   DefaultFingerprintBits,
+		maxKicks: DefaultMaxKicks,
+	}
+	for _, opt := range opts {
+		opt.applyCuckoo(cf)
+	}
+	if cf.size == 0 && cf.capacity == 0 && len(cf.words) == 0 {
+		return nil, ErrInvalidSize
+	}
+	if cf.fpBits != 8 && cf.fpBits != 12 && cf.fpBits != 16 {
+		return nil, ErrInvalidFingerprintBits
+	}
+	if cf.maxKicks < 1 {
+		return nil, ErrInvalidMaxKicks
+	}
+	if len(cf.hashes) == 0 {
+		return nil, ErrRequiredHashFunction
+	}
+	if cf.hashes[0] == nil {
+		return nil, ErrHashIsNil
+	}
+	if len(cf.hashes) != 1 {
+		return nil, ErrSingleHashFunction
+	}
+	cf.hash = cf.hashes[0]
+
+	switch {
+	case len(cf.words) > 0:
+		if err := cf.restore(cf.words); err != nil {
+			return nil, err
+		}
+	case cf.capacity > 0:
+		cf.buckets = cuckooBuckets(cf.capacity)
+	default:
+		cf.buckets = nextPowerOfTwo((cf.size/uint64(cf.fpBits) + CuckooBucketSize - 1) / CuckooBucketSize)
+	}
+	if len(cf.words) == 0 {
+		cf.table = NewCounters(cf.buckets*CuckooBucketSize, cf.fpBits)
+	}
+	cf.hashes, cf.words = nil, nil
+
+	return cf, nil
+}
+
+// Add adds the given data to the filter, relocating existing fingerprints if both candidate buckets are full.
+// When the relocations run out, the data is still added and the last relocated fingerprint is kept aside as a victim, so nothing is lost.
+// Once there is a victim, later adds return [ErrFilterFull] without adding the data, until a remove makes room for the victim.
+// Adding the same data more than once stores it more than once, so it also needs to be removed more than once.
+func (cf *CuckooFilter) Add(data []byte) error {
+	fp, i1 := cf.fingerprint(cf.hash(data))
+
+	return cf.add(fp, i1)
+}
+
+// Test checks if the given data is likely to be in the filter, by checking both candidate buckets for its fingerprint.
+func (cf *CuckooFilter) Test(data []byte) bool {
+	fp, i1 := cf.fingerprint(cf.hash(data))
+
+	return cf.test(fp, i1)
+}
+
+// Remove removes one copy of the given data from the filter.
+// It returns [ErrNotInFilter] if the fingerprint of the data is not in the filter.
+// Removing data that was never added, but is a false positive, will introduce false negatives for other items.
+func (cf *CuckooFilter) Remove(data []byte) error {
+	fp, i1 := cf.fingerprint(cf.hash(data))
+
+	return cf.remove(fp, i1)
+}
+
+// Count returns the amount of items in the filter.
+func (cf *CuckooFilter) Count() uint64 {
+	return cf.count
+}
+
+// Capacity returns the total amount of fingerprint slots in the filter, in practice inserts start failing at around 95% of this capacity.
+func (cf *CuckooFilter) Capacity() uint64 {
+	return cf.buckets * CuckooBucketSize
+}
+
+// LoadFactor returns the fraction of fingerprint slots that are in use.
+func (cf *CuckooFilter) LoadFactor() float64 {
+	return float64(cf.count) / float64(cf.Capacity())
+}
+
+// FingerprintBits returns the size in bits of a single fingerprint.
+func (cf *CuckooFilter) FingerprintBits() uint8 {
+	return cf.fpBits
+}
+
+// Words returns a copy slice of uint64 words representing the fingerprint table of the filter,
+// followed by the amount of buckets and the fingerprint kept aside as a victim, if any.
+// A filter can be restored from these words with [WithWords] and the same fingerprint size.
+func (cf *CuckooFilter) Words() []uint64 {
+	var victim uint64
+	if cf.victim.used {
+		victim = cf.victim.bucket<<16 | cf.victim.fingerprint
+	}
+
+	return append(cf.table.Words(), cf.buckets, victim)
+}
+
+// restore loads the fingerprint table, the amount of buckets and the victim from words returned by [CuckooFilter.Words].
+func (cf *CuckooFilter) restore(words []uint64) error {
+	if len(words) < 3 {
+		return ErrInvalidSize
+	}
+	table, buckets, victim := words[:len(words)-2], words[len(words)-2], words[len(words)-1]
+	if buckets == 0 || buckets != prevPowerOfTwo(buckets) || buckets > uint64(len(table))*64 {
+		return ErrInvalidSize
+	}
+	if (buckets*CuckooBucketSize*uint64(cf.fpBits)+63)/64 != uint64(len(table)) {
+		return ErrInvalidSize
+	}
+
+	cf.buckets = buckets
+	cf.table = Counters{data: table, width: cf.fpBits, size: uint64(len(table)) * 64 / uint64(cf.fpBits)}
+	cf.count = cf.table.Count()
+
+	// A fingerprint is never 0, so a victim of 0 means there is none
+	if fp := victim & 0xffff; fp != 0 {
+		if fp > cf.table.Max() || victim>>16 >= buckets {
+			return ErrInvalidSize
+		}
+		cf.victim = cuckooVictim{fingerprint: fp, bucket: victim >> 16, used: true}
+		cf.count++
+	}
+
+	return nil
+}
+
+func (cf *CuckooFilter) add(fp, i1 uint64) error {
+	if cf.victim.used {
+		// There is already a fingerprint we couldn't place, the table is as good as full
+		return ErrFilterFull
+	}
+
+	i2 := cf.alternate(i1, fp)
+	if cf.insert(i1, fp) || cf.insert(i2, fp) {
+		cf.count++
+
+		return nil
+	}
+
+	// Both buckets are full, kick out a random fingerprint and move it to its alternate bucket
+	i := i1
+	if rand.IntN(2) == 0 { // nolint:gosec // This is not used for cryptographic purposes.
+		i = i2
+	}
+	for range cf.maxKicks {
+		slot := i*CuckooBucketSize + rand.Uint64N(CuckooBucketSize) // nolint:gosec // This is not used for cryptographic purposes.
+		kicked := cf.table.Get(slot)
+		cf.table.Set(slot, fp)
+		fp = kicked
+
+		i = cf.alternate(i, fp)
+		if cf.insert(i, fp) {
+			cf.count++
+
+			return nil
+		}
+	}
+
+	cf.victim = cuckooVictim{fingerprint: fp, bucket: i, used: true}
+	cf.count++
+
+	return nil
+}
+
+func (cf *CuckooFilter) test(fp, i1 uint64) bool {
+	i2 := cf.alternate(i1, fp)
+	if cf.victim.used && cf.victim.fingerprint == fp && (cf.victim.bucket == i1 || cf.victim.bucket == i2) {
+		return true
+	}
+
+	return cf.contains(i1, fp) || cf.contains(i2, fp)
+}
+
+func (cf *CuckooFilter) remove(fp, i1 uint64) error {
+	i2 := cf.alternate(i1, fp)
+	switch {
+	case cf.delete(i1, fp), cf.delete(i2, fp):
+		cf.count--
+		if cf.victim.used {
+			// There is space again, so try to place the victim
+			victim := cf.victim
+			cf.victim = cuckooVictim{}
+			cf.count--
+			_ = cf.add(victim.fingerprint, victim.bucket)
+		}
+
+		return nil
+	case cf.victim.used && cf.victim.fingerprint == fp && (cf.victim.bucket == i1 || cf.victim.bucket == i2):
+		cf.victim = cuckooVictim{}
+		cf.count--
+
+		return nil
+	}
+
+	return ErrNotInFilter
+}
+
+// insert places the fingerprint in an empty slot of the given bucket, and returns false if the bucket is full.
+func (cf *CuckooFilter) insert(bucket, fp uint64) bool {
+	for slot := bucket * CuckooBucketSize; slot < (bucket+1)*CuckooBucketSize; slot++ {
+		if cf.table.Get(slot) == 0 {
+			cf.table.Set(slot, fp)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// delete clears one slot of the given bucket holding the fingerprint, and returns false if the bucket doesn't hold the fingerprint.
+func (cf *CuckooFilter) delete(bucket, fp uint64) bool {
+	for slot := bucket * CuckooBucketSize; slot < (bucket+1)*CuckooBucketSize; slot++ {
+		if cf.table.Get(slot) == fp {
+			cf.table.Set(slot, 0)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cf *CuckooFilter) contains(bucket, fp uint64) bool {
+	for slot := bucket * CuckooBucketSize; slot < (bucket+1)*CuckooBucketSize; slot++ {
+		if cf.table.Get(slot) == fp {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fingerprint splits the hash into a fingerprint from the upper 32 bits, and the first candidate bucket from the lower bits.
+// A fingerprint of 0 marks an empty slot, so it is never returned.
+func (cf *CuckooFilter) fingerprint(hash uint64) (fp, bucket uint64) {
+	fp = (hash >> 32) & cf.table.Max()
+	if fp == 0 {
+		fp = 1
+	}
+
+	return fp, hash & (cf.buckets - 1)
+}
+
+// alternate returns the other candidate bucket of a fingerprint, given one of its candidate buckets.
+// Since it only relies on XOR, applying it twice returns the original bucket, so a relocated fingerprint can always find its way back.
+func (cf *CuckooFilter) alternate(bucket, fp uint64) uint64 {
+	// Mix the fingerprint with the MurmurHash2 constant, so similar fingerprints end up in different buckets
+	return (bucket ^ (fp * 0x5bd1e995)) & (cf.buckets - 1)
+}
+
+// cuckooBuckets returns the power of 2 amount of buckets needed to hold the given capacity, staying below a load factor of 96%.
+func cuckooBuckets(capacity uint64) uint64 {
+	buckets := nextPowerOfTwo(max(capacity/CuckooBucketSize, 1))
+	if float64(capacity)/float64(buckets*CuckooBucketSize) > 0.96 {
+		buckets <<= 1
+	}
+
+	return buckets
+}
+
+func nextPowerOfTwo(n uint64) uint64 {
+	if n <= 1 {
+		return 1
+	}
+
+	return 1 << bits.Len64(n-1)
+}
+
+func prevPowerOfTwo(n uint64) uint64 {
+	if n == 0 {
+		return 0
+	}
+
+	return 1 << (bits.Len64(n) - 1)
+}
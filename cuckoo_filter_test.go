@@ -0,0 +1,364 @@
+package bloomfilters_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cuckooFilter is the surface shared by the cuckoo filter and its concurrent variant.
+type cuckooFilter interface {
+	Add(data []byte) error
+	Test(data []byte) bool
+	Remove(data []byte) error
+	Count() uint64
+	Capacity() uint64
+}
+
+// cuckooFilters returns a list of cuckoo filter factories to test against.
+func cuckooFilters() map[string]func(opts ...bloomfilters.CuckooFilterOptions) (cuckooFilter, error) {
+	return map[string]func(opts ...bloomfilters.CuckooFilterOptions) (cuckooFilter, error){
+		"CuckooFilter": func(opts ...bloomfilters.CuckooFilterOptions) (cuckooFilter, error) {
+			return bloomfilters.NewCuckooFilter(opts...)
+		},
+		"ConcurrentCuckooFilter": func(opts ...bloomfilters.CuckooFilterOptions) (cuckooFilter, error) {
+			return bloomfilters.NewConcurrentCuckooFilter(opts...)
+		},
+	}
+}
+
+func Test_CuckooFilter_AddTestRemove(t *testing.T) {
+	for name, factory := range cuckooFilters() {
+		t.Run(name, func(t *testing.T) {
+			cf, err := factory(
+				bloomfilters.WithCapacity(1000),
+				bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64}),
+			)
+			require.NoError(t, err)
+
+			require.NoError(t, cf.Add([]byte("hello")))
+			require.NoError(t, cf.Add([]byte("world")))
+			assert.True(t, cf.Test([]byte("hello")))
+			assert.True(t, cf.Test([]byte("world")))
+			assert.Equal(t, uint64(2), cf.Count())
+
+			require.NoError(t, cf.Remove([]byte("hello")))
+			assert.False(t, cf.Test([]byte("hello")), "Expected 'hello' to be removed from the filter")
+			assert.True(t, cf.Test([]byte("world")), "Expected 'world' to still be in the filter")
+
+			err = cf.Remove([]byte("hello"))
+			require.ErrorIs(t, err, bloomfilters.ErrNotInFilter)
+			assert.Equal(t, uint64(1), cf.Count())
+		})
+	}
+}
+
+func Test_CuckooFilter_AddTwiceRemoveOnce(t *testing.T) {
+	cf, err := bloomfilters.NewCuckooFilter(
+		bloomfilters.WithCapacity(100),
+		bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64}),
+	)
+	require.NoError(t, err)
+
+	data := []byte("duplicate")
+	require.NoError(t, cf.Add(data))
+	require.NoError(t, cf.Add(data))
+
+	require.NoError(t, cf.Remove(data))
+	assert.True(t, cf.Test(data), "Expected data added twice to survive a single remove")
+	require.NoError(t, cf.Remove(data))
+	assert.False(t, cf.Test(data))
+}
+
+func Test_CuckooFilter_NoFalseNegatives(t *testing.T) {
+	data := testutil.MoreBytes(10000, 32)
+
+	for _, fpBits := range []uint8{8, 12, 16} {
+		t.Run(fmt.Sprintf("%d bits", fpBits), func(t *testing.T) {
+			cf, err := bloomfilters.NewCuckooFilter(
+				bloomfilters.WithCapacity(uint64(len(data))),
+				bloomfilters.WithFingerprintBits(fpBits),
+				bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64}),
+			)
+			require.NoError(t, err)
+
+			for _, d := range data {
+				require.NoError(t, cf.Add(d))
+			}
+			for _, d := range data[:len(data)/2] {
+				require.NoError(t, cf.Remove(d))
+			}
+			for _, d := range data[len(data)/2:] {
+				require.True(t, cf.Test(d), "Expected remaining data to still be found in the filter")
+			}
+		})
+	}
+}
+
+func Test_CuckooFilter_FalsePositiveRate(t *testing.T) {
+	data := testutil.MoreBytes(20000, 32)
+	added, others := data[:10000], data[10000:]
+
+	for _, fpBits := range []uint8{8, 12, 16} {
+		t.Run(fmt.Sprintf("%d bits", fpBits), func(t *testing.T) {
+			cf, err := bloomfilters.NewCuckooFilter(
+				bloomfilters.WithCapacity(uint64(len(added))),
+				bloomfilters.WithFingerprintBits(fpBits),
+				bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64}),
+			)
+			require.NoError(t, err)
+
+			for _, d := range added {
+				require.NoError(t, cf.Add(d))
+			}
+
+			var positives int
+			for _, d := range others {
+				if cf.Test(d) {
+					positives++
+				}
+			}
+			rate := float64(positives) / float64(len(others))
+			expected := bloomsettings.CuckooFalsePositiveRate(uint64(fpBits), bloomfilters.CuckooBucketSize)
+			assert.LessOrEqual(t, rate, expected*1.5, "Expected the false positive rate to stay close to the theoretical bound")
+		})
+	}
+}
+
+func Test_CuckooFilter_Full(t *testing.T) {
+	cf, err := bloomfilters.NewCuckooFilter(
+		bloomfilters.WithCapacity(64),
+		bloomfilters.WithMaxKicks(50),
+		bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64}),
+	)
+	require.NoError(t, err)
+
+	data := testutil.MoreBytes(int(cf.Capacity())*2, 32)
+
+	var added [][]byte
+	for _, d := range data {
+		err = cf.Add(d)
+		if err != nil {
+			require.ErrorIs(t, err, bloomfilters.ErrFilterFull)
+
+			break
+		}
+		added = append(added, d)
+	}
+	require.ErrorIs(t, err, bloomfilters.ErrFilterFull, "Expected the filter to fill up")
+	assert.Greater(t, cf.LoadFactor(), 0.8, "Expected the filter to fill up most slots before giving up")
+
+	// Items that were accepted, including the last one that was kicked around, are never lost
+	for _, d := range added {
+		require.True(t, cf.Test(d), "Expected added data to be found in a full filter")
+	}
+
+	// Removing an item makes room again
+	require.NoError(t, cf.Remove(added[0]))
+	for _, d := range added[1:] {
+		require.True(t, cf.Test(d), "Expected added data to be found after a remove")
+	}
+}
+
+func Test_CuckooFilter_Sizes(t *testing.T) {
+	hashes := bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64})
+
+	cf, err := bloomfilters.NewCuckooFilter(bloomfilters.WithSize(8192), bloomfilters.WithFingerprintBits(16), hashes)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(512), cf.Capacity(), "Expected 8192 bits to hold 512 fingerprints of 16 bits")
+
+	cf, err = bloomfilters.NewCuckooFilter(bloomfilters.WithCapacity(900), hashes)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1024), cf.Capacity(), "Expected 900 items to fit in 256 buckets")
+
+	cf, err = bloomfilters.NewCuckooFilter(bloomfilters.WithCapacity(900), bloomfilters.WithFingerprintBits(8), hashes)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(8), cf.FingerprintBits())
+	assert.Len(t, cf.Words(), 128+2, "Expected the table words, followed by the amount of buckets and the victim")
+}
+
+func Test_CuckooFilter_Invalid(t *testing.T) {
+	hashes := bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64})
+
+	tests := []struct {
+		name string
+		opts []bloomfilters.CuckooFilterOptions
+		err  error
+	}{
+		{"no size", []bloomfilters.CuckooFilterOptions{hashes}, bloomfilters.ErrInvalidSize},
+		{"fingerprint bits", []bloomfilters.CuckooFilterOptions{bloomfilters.WithCapacity(100), bloomfilters.WithFingerprintBits(10), hashes}, bloomfilters.ErrInvalidFingerprintBits},
+		{"max kicks", []bloomfilters.CuckooFilterOptions{bloomfilters.WithCapacity(100), bloomfilters.WithMaxKicks(0), hashes}, bloomfilters.ErrInvalidMaxKicks},
+		{"no hash", []bloomfilters.CuckooFilterOptions{bloomfilters.WithCapacity(100)}, bloomfilters.ErrRequiredHashFunction},
+		{"nil hash", []bloomfilters.CuckooFilterOptions{bloomfilters.WithCapacity(100), bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{nil})}, bloomfilters.ErrHashIsNil},
+		{"many hashes", []bloomfilters.CuckooFilterOptions{bloomfilters.WithCapacity(100), bloomfilters.WithDefaultHashFunctions()}, bloomfilters.ErrSingleHashFunction},
+		{"words without buckets", []bloomfilters.CuckooFilterOptions{bloomfilters.WithWords(make([]uint64, 2)), hashes}, bloomfilters.ErrInvalidSize},
+		{"words of another size", []bloomfilters.CuckooFilterOptions{bloomfilters.WithWords([]uint64{0, 0, 0, 8, 0}), hashes}, bloomfilters.ErrInvalidSize},
+		{"words with a stray victim", []bloomfilters.CuckooFilterOptions{bloomfilters.WithWords([]uint64{0, 0, 0, 4, 4<<16 | 1}), hashes}, bloomfilters.ErrInvalidSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cf, err := bloomfilters.NewCuckooFilter(tt.opts...)
+			require.ErrorIs(t, err, tt.err)
+			require.Nil(t, cf)
+		})
+	}
+}
+
+func Test_CuckooFilter_WithWords(t *testing.T) {
+	hashes := bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64})
+	data := testutil.MoreBytes(500, 32)
+
+	cf, err := bloomfilters.NewCuckooFilter(bloomfilters.WithCapacity(500), hashes)
+	require.NoError(t, err)
+	for _, d := range data {
+		require.NoError(t, cf.Add(d))
+	}
+
+	restored, err := bloomfilters.NewCuckooFilter(bloomfilters.WithWords(cf.Words()), hashes)
+	require.NoError(t, err)
+	assert.Equal(t, cf.Capacity(), restored.Capacity())
+	assert.Equal(t, cf.Count(), restored.Count())
+	for _, d := range data {
+		require.True(t, restored.Test(d), "Expected the restored filter to contain the added data")
+	}
+}
+
+func Test_CuckooFilter_WithWords_Victim(t *testing.T) {
+	hashes := bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64})
+	data := testutil.MoreBytes(1000, 32)
+
+	cf, err := bloomfilters.NewCuckooFilter(bloomfilters.WithCapacity(128), bloomfilters.WithMaxKicks(10), hashes)
+	require.NoError(t, err)
+	added := 0
+	for _, d := range data {
+		if errors.Is(cf.Add(d), bloomfilters.ErrFilterFull) {
+			break
+		}
+		added++
+	}
+	require.Less(t, added, len(data), "Expected the filter to keep a victim aside")
+
+	restored, err := bloomfilters.NewCuckooFilter(bloomfilters.WithWords(cf.Words()), hashes)
+	require.NoError(t, err)
+	assert.Equal(t, cf.Capacity(), restored.Capacity())
+	assert.Equal(t, uint64(added), restored.Count())
+	assert.Equal(t, cf.Words(), restored.Words())
+	for _, d := range data[:added] {
+		require.True(t, restored.Test(d), "Expected the restored filter to contain the added data")
+	}
+	require.ErrorIs(t, restored.Add(data[added]), bloomfilters.ErrFilterFull, "Expected the restored filter to still hold the victim")
+
+	require.NoError(t, restored.Remove(data[0]))
+	for _, d := range data[1:added] {
+		require.True(t, restored.Test(d), "Expected the victim to be placed after a remove")
+	}
+}
+
+func Test_CuckooFilter_WithWords_SingleBucket(t *testing.T) {
+	hashes := bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64})
+	data := testutil.MoreBytes(3, 32)
+
+	cf, err := bloomfilters.NewCuckooFilter(bloomfilters.WithCapacity(3), bloomfilters.WithFingerprintBits(8), hashes)
+	require.NoError(t, err)
+	for _, d := range data {
+		require.NoError(t, cf.Add(d))
+	}
+
+	restored, err := bloomfilters.NewCuckooFilter(bloomfilters.WithWords(cf.Words()), bloomfilters.WithFingerprintBits(8), hashes)
+	require.NoError(t, err)
+	assert.Equal(t, cf.Capacity(), restored.Capacity())
+	assert.Equal(t, cf.Words(), restored.Words())
+	for _, d := range data {
+		require.True(t, restored.Test(d), "Expected the restored filter to contain the added data")
+	}
+}
+
+func Test_ConcurrentCuckooFilter_Concurrent(t *testing.T) {
+	data := testutil.MoreBytes(800, 32)
+
+	cf, err := bloomfilters.NewConcurrentCuckooFilter(
+		bloomfilters.WithCapacity(1000),
+		bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64}),
+	)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	for i := range 8 {
+		chunk := data[i*100 : (i+1)*100]
+		wg.Go(func() {
+			for _, d := range chunk {
+				assert.NoError(t, cf.Add(d))
+			}
+			for _, d := range chunk[:50] {
+				assert.NoError(t, cf.Remove(d))
+			}
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint64(400), cf.Count())
+	for i := range 8 {
+		for _, d := range data[i*100+50 : (i+1)*100] {
+			require.True(t, cf.Test(d), "Expected remaining data to still be found in the filter")
+		}
+	}
+}
+
+// Fuzz test for CuckooFilter Add, Test and Remove
+func Fuzz_CuckooFilter_AddRemove(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cf, err := bloomfilters.NewCuckooFilter(
+			bloomfilters.WithCapacity(100),
+			bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64}),
+		)
+		require.NoError(t, err)
+
+		require.NoError(t, cf.Add(data))
+		require.True(t, cf.Test(data), "Expected added data to be found in the filter")
+
+		require.NoError(t, cf.Remove(data))
+		require.False(t, cf.Test(data), "Expected removed data to not be found in the filter")
+	})
+}
+
+// Example of basic CuckooFilter usage
+func ExampleCuckooFilter() {
+	// Create a cuckoo filter for 1000 items with 12 bit fingerprints
+	cf, _ := bloomfilters.NewCuckooFilter(
+		bloomfilters.WithCapacity(1000),
+		bloomfilters.WithFingerprintBits(12),
+		bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64}),
+	)
+
+	_ = cf.Add([]byte("apple"))
+	_ = cf.Add([]byte("banana"))
+
+	// Remove an item again
+	err := cf.Remove([]byte("apple"))
+
+	fmt.Println(err)
+	fmt.Println(cf.Test([]byte("apple")))
+	fmt.Println(cf.Test([]byte("banana")))
+	fmt.Println(cf.Count())
+
+	// Output:
+	// <nil>
+	// false
+	// true
+	// 1
+}
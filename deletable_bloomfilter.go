@@ -0,0 +1,168 @@
+package bloomfilters
+
+import (
+	"errors"
+	"math"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+// DefaultRegionSize is the default amount of bits per region of a deletable bloom filter, the collision bitmap costs one bit per region.
+const DefaultRegionSize = 16
+
+var ErrInvalidRegions = errors.New("regions must be between 1 and the size of the bloom filter")
+
+var _ IBloomFilter = &DeletableBloomFilter{}
+
+// DeletableBloomFilter is a [BloomFilter] that supports removing most items, based on "The Deletable Bloom filter: A new member of the Bloom family" by Rothenberg, Macapuna, Verdi and Magalhães.
+// The bits are split into regions, and a collision bitmap of one bit per region remembers which regions had a bit set by more than one item.
+// Bits in a region without collisions belong to a single item, so they can be cleared without creating false negatives.
+// An item can be removed if at least one of its bits is in a collision-free region, see [DeletableBloomFilter.DeleteProbability].
+// Adding an item that is already in the filter marks all of its regions as collided, so it can't be removed anymore.
+type DeletableBloomFilter struct {
+	filter     *BloomFilter
+	collisions Bits
+	regionSize uint64
+	placements uint64
+
+	bits    Bits
+	hashes  []bloomhashes.HashFunction
+	derived derivedHashes
+	regions uint64
+}
+
+// NewDeletableBloomFilter creates a new deletable bloom filter with the given options.
+// The bits are split into one region per [DefaultRegionSize] bits, unless set with [WithRegions].
+// Regions of bits set with [WithBits] or [WithWords] are marked as collided, since it is unknown which items set them.
+// It returns an error if the size of the bloom filter or the amount of regions is invalid, or if any of the hash functions are nil.
+func NewDeletableBloomFilter(opts ...DeletableBloomFilterOptions) (*DeletableBloomFilter, error) {
+	bf := &DeletableBloomFilter{}
+	for _, opt := range opts {
+		opt.applyDeletable(bf)
+	}
+	size := bf.bits.Size()
+	if size == 0 {
+		return nil, ErrInvalidSize
+	}
+	if bf.regions == 0 {
+		bf.regions = (size + DefaultRegionSize - 1) / DefaultRegionSize
+	}
+	if bf.regions > size {
+		return nil, ErrInvalidRegions
+	}
+	if len(bf.hashes) == 0 {
+		return nil, ErrRequiredHashFunction
+	}
+	for _, hashFunc := range bf.hashes {
+		if hashFunc == nil {
+			return nil, ErrHashIsNil
+		}
+	}
+
+	bf.filter = &BloomFilter{bits: bf.bits, hashes: bf.hashes, derived: bf.derived}
+	bf.regionSize = (size + bf.regions - 1) / bf.regions
+	// Rounding up the region size can leave fewer regions than asked for
+	bf.regions = (size + bf.regionSize - 1) / bf.regionSize
+	bf.collisions = NewBits(bf.regions)
+	for i := range size {
+		if bf.bits.Getbit(i) {
+			bf.collisions.Setbit(bf.region(i))
+			bf.placements++
+		}
+	}
+
+	return bf, nil
+}
+
+// Add adds the given data to the bloom filter, see [DeletableBloomFilter.SetHash].
+func (bf *DeletableBloomFilter) Add(data []byte) {
+	for _, hash := range bf.derived.all(bf.hashes, data) {
+		bf.SetHash(hash)
+	}
+}
+
+// Test checks if the given data is likely to be in the bloom filter, see [BloomFilter.Test].
+func (bf *DeletableBloomFilter) Test(data []byte) bool {
+	return bf.filter.Test(data)
+}
+
+// SetHash sets the bit corresponding to the given hash value to 1, if the bit was already set its region is marked as collided.
+func (bf *DeletableBloomFilter) SetHash(hash uint64) {
+	index := bf.filter.index(hash)
+	bf.placements++
+	if bf.filter.bits.Getbit(index) {
+		bf.collisions.Setbit(bf.region(index))
+
+		return
+	}
+	bf.filter.bits.Setbit(index)
+}
+
+// GetHash checks if the bit corresponding to the given hash value is set to 1.
+func (bf *DeletableBloomFilter) GetHash(hash uint64) bool {
+	return bf.filter.GetHash(hash)
+}
+
+// BitsCount returns the total number of bits that are set to 1 in the bloom filter.
+func (bf *DeletableBloomFilter) BitsCount() uint64 {
+	return bf.filter.BitsCount()
+}
+
+// Bits returns a copy of the Bits struct representing the bits of the bloom filter.
+// Modifying the returned Bits will not affect the internal state of the bloom filter.
+func (bf *DeletableBloomFilter) Bits() Bits {
+	return bf.filter.Bits()
+}
+
+// Remove removes the given data from the bloom filter, by clearing its bits that are in collision-free regions.
+// It returns false if the data wasn't found, or if all of its bits are in collided regions, the filter is then left unchanged.
+// Removing data that was never added clears the bits of the items it is a false positive for, which makes them false negatives.
+func (bf *DeletableBloomFilter) Remove(data []byte) (removed bool) {
+	if !bf.Test(data) {
+		return false
+	}
+
+	for _, hash := range bf.derived.all(bf.hashes, data) {
+		index := bf.filter.index(hash)
+		if !bf.collisions.Getbit(bf.region(index)) {
+			bf.filter.bits.Clearbit(index)
+			bf.placements--
+			removed = true
+		}
+	}
+
+	return removed
+}
+
+// DeleteProbability returns the probability an item in the filter can be removed, from the fraction of bits set by items that are in collision-free regions.
+// A bit in a collided region can be set by several items, so the fraction is taken of every time a bit was set, not of the set bits.
+// Use bloomsettings.DeletableProbability to estimate it before items are added.
+func (bf *DeletableBloomFilter) DeleteProbability() float64 {
+	if bf.placements == 0 {
+		return 1
+	}
+
+	var free uint64
+	for i := range bf.filter.bits.Size() {
+		if bf.filter.bits.Getbit(i) && !bf.collisions.Getbit(bf.region(i)) {
+			free++
+		}
+	}
+	clearable := float64(free) / float64(bf.placements)
+
+	return 1 - math.Pow(1-clearable, float64(len(bf.hashes)))
+}
+
+// Regions returns the amount of regions the bits are split into.
+func (bf *DeletableBloomFilter) Regions() uint64 {
+	return bf.regions
+}
+
+// CollisionFreeRegions returns the amount of regions where every set bit was set by a single item, their bits can be cleared.
+func (bf *DeletableBloomFilter) CollisionFreeRegions() uint64 {
+	return bf.regions - bf.collisions.BitsCount()
+}
+
+func (bf *DeletableBloomFilter) region(index uint64) uint64 {
+	return index / bf.regionSize
+}
@@ -0,0 +1,206 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDeletable(t *testing.T, opts ...bloomfilters.DeletableBloomFilterOptions) *bloomfilters.DeletableBloomFilter {
+	t.Helper()
+
+	bf, err := bloomfilters.NewDeletableBloomFilter(append([]bloomfilters.DeletableBloomFilterOptions{
+		bloomfilters.WithSize(4096),
+		bloomfilters.WithDefaultHashFunctions(),
+	}, opts...)...)
+	require.NoError(t, err)
+
+	return bf
+}
+
+func Test_DeletableBloomFilter_Remove(t *testing.T) {
+	data := testutil.MoreBytes(200, 16)
+	bf := newDeletable(t)
+	for _, d := range data {
+		bf.Add(d)
+	}
+
+	var removed []int
+	for i, d := range data[:100] {
+		if bf.Remove(d) {
+			require.False(t, bf.Test(d), "Expected removed data to no longer be found")
+			removed = append(removed, i)
+		}
+	}
+	assert.NotEmpty(t, removed, "Expected some items to be removable")
+	// Only bits of collision-free regions are cleared, so no other item is lost
+	for _, d := range data[100:] {
+		require.True(t, bf.Test(d), "Expected removing items to not create false negatives")
+	}
+	for i, d := range data[:100] {
+		if len(removed) == 0 || removed[0] != i {
+			require.True(t, bf.Test(d), "Expected items that couldn't be removed to still be found")
+
+			continue
+		}
+		removed = removed[1:]
+	}
+}
+
+func Test_DeletableBloomFilter_NotFound(t *testing.T) {
+	bf := newDeletable(t)
+	bf.Add([]byte("item"))
+	bits := bf.Bits()
+
+	assert.False(t, bf.Remove([]byte("missing")))
+	assert.Equal(t, bits, bf.Bits(), "Expected nothing to be cleared for data that isn't found")
+}
+
+func Test_DeletableBloomFilter_Duplicate(t *testing.T) {
+	bf := newDeletable(t)
+	bf.Add([]byte("item"))
+	bf.Add([]byte("item"))
+
+	// Adding an item twice collides with itself, so none of its bits can be cleared
+	assert.False(t, bf.Remove([]byte("item")))
+	assert.True(t, bf.Test([]byte("item")))
+}
+
+func Test_DeletableBloomFilter_Regions(t *testing.T) {
+	bf := newDeletable(t, bloomfilters.WithRegions(100))
+	// 4096 bits in regions of 41 bits fit in 100 regions
+	assert.Equal(t, uint64(100), bf.Regions())
+	assert.Equal(t, uint64(100), bf.CollisionFreeRegions())
+	assert.InDelta(t, 1.0, bf.DeleteProbability(), 1e-12)
+
+	bf = newDeletable(t, bloomfilters.WithRegions(3000))
+	// Regions of 2 bits only need 2048 regions
+	assert.Equal(t, uint64(2048), bf.Regions())
+}
+
+func Test_DeletableBloomFilter_Seeded(t *testing.T) {
+	bits := bloomfilters.NewBits(64)
+	bits.Setbit(3)
+	bf := newDeletable(t, bloomfilters.WithBits(bits), bloomfilters.WithRegions(4), bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{
+		func([]byte) uint64 { return 3 },
+	}))
+
+	// It is unknown which items set the seeded bits, so their regions can't be cleared
+	assert.Equal(t, uint64(3), bf.CollisionFreeRegions())
+	assert.False(t, bf.Remove([]byte("anything")))
+}
+
+func Test_DeletableBloomFilter_DeleteProbability(t *testing.T) {
+	const (
+		m = 4096
+		n = 300
+		r = 256
+	)
+	hashes := bloomhashes.DefaultHashFunctions()
+	data := testutil.MoreBytes(n, 16)
+	bf := newDeletable(t, bloomfilters.WithRegions(r))
+	for _, d := range data {
+		bf.Add(d)
+	}
+
+	// Removing an item changes which other items can be removed, so every item is removed from its own filter
+	var removable int
+	for _, d := range data {
+		other := newDeletable(t, bloomfilters.WithRegions(r))
+		for _, d := range data {
+			other.Add(d)
+		}
+		if other.Remove(d) {
+			removable++
+		}
+	}
+	expected := bloomsettings.DeletableProbability(m, n, uint64(len(hashes)), r)
+	assert.InDelta(t, expected, bf.DeleteProbability(), 0.05)
+	assert.InDelta(t, expected, float64(removable)/n, 0.05)
+}
+
+func Test_DeletableProbability(t *testing.T) {
+	assert.InDelta(t, 1.0, bloomsettings.DeletableProbability(1024, 1, 4, 64), 1e-6, "Expected a single element to always be removable")
+	assert.Less(t,
+		bloomsettings.DeletableProbability(1024, 200, 4, 16),
+		bloomsettings.DeletableProbability(1024, 200, 4, 256),
+		"Expected more regions to make more elements removable",
+	)
+	assert.Less(t,
+		bloomsettings.DeletableProbability(1024, 400, 4, 64),
+		bloomsettings.DeletableProbability(1024, 100, 4, 64),
+		"Expected more elements to make fewer elements removable",
+	)
+}
+
+func Test_DeletableBloomFilter_Invalid(t *testing.T) {
+	hashes := bloomfilters.WithDefaultHashFunctions()
+	size := bloomfilters.WithSize(1024)
+
+	tests := []struct {
+		name string
+		opts []bloomfilters.DeletableBloomFilterOptions
+		err  error
+	}{
+		{"size", []bloomfilters.DeletableBloomFilterOptions{hashes}, bloomfilters.ErrInvalidSize},
+		{"regions", []bloomfilters.DeletableBloomFilterOptions{size, hashes, bloomfilters.WithRegions(1025)}, bloomfilters.ErrInvalidRegions},
+		{"no hashes", []bloomfilters.DeletableBloomFilterOptions{size}, bloomfilters.ErrRequiredHashFunction},
+		{"nil hash", []bloomfilters.DeletableBloomFilterOptions{size, bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{nil})}, bloomfilters.ErrHashIsNil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bf, err := bloomfilters.NewDeletableBloomFilter(tt.opts...)
+			require.ErrorIs(t, err, tt.err)
+			require.Nil(t, bf)
+		})
+	}
+}
+
+// Fuzz test for DeletableBloomFilter Add and Remove
+func Fuzz_DeletableBloomFilter_AddRemove(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		bf := newDeletable(t, bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.MD5}))
+		bf.Add(data)
+		require.True(t, bf.Test(data))
+
+		// A single item never collides with another, only with itself if both hashes pick the same bit
+		if bf.Remove(data) {
+			require.False(t, bf.Test(data), "Expected removed data to no longer be found")
+		} else {
+			require.Equal(t, uint64(1), bf.BitsCount(), "Expected only a self collision to prevent removing the data")
+		}
+	})
+}
+
+// Example of removing items from a DeletableBloomFilter
+func ExampleDeletableBloomFilter() {
+	bf, _ := bloomfilters.NewDeletableBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithDefaultHashFunctions(),
+	)
+
+	bf.Add([]byte("session-a"))
+	bf.Add([]byte("session-b"))
+
+	fmt.Println(bf.Remove([]byte("session-a")))
+	fmt.Println(bf.Test([]byte("session-a")))
+	fmt.Println(bf.Test([]byte("session-b")))
+
+	// Output:
+	// true
+	// false
+	// true
+}
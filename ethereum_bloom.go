@@ -0,0 +1,88 @@
+package bloomfilters
+
+import (
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+// EthereumBloomBytes is the size in bytes of the logsBloom field of an Ethereum block header or receipt.
+const EthereumBloomBytes = 256
+
+var _ IBloomFilter = &EthereumBloom{}
+
+// EthereumBloom is the 2048 bit logsBloom of Ethereum block headers and receipts, see the Ethereum yellow paper, section 4.3.1.
+// An item sets three bits, each picked by the low 11 bits of one of the first three big-endian 16 bit words of its Keccak-256 hash.
+// The bloom is a big-endian 2048 bit number, so bit i is bit i % 8 of byte 255 - i / 8, and its binary form is the exact 256 bytes of the header.
+// A log adds its address and each of its topics, and the bloom of a block is the union of the blooms of its receipts, see [EthereumBloom.Merge].
+type EthereumBloom struct {
+	bits Bits
+}
+
+// NewEthereumBloom creates a new empty Ethereum bloom.
+func NewEthereumBloom() *EthereumBloom {
+	return &EthereumBloom{
+		bits: NewBits(EthereumBloomBytes * 8),
+	}
+}
+
+// Add adds the given data to the bloom, such as the address or a topic of a log.
+func (eb *EthereumBloom) Add(data []byte) {
+	eb.SetHash(bloomhashes.Keccak256(data))
+}
+
+// AddLog adds the address and every topic of a log to the bloom, like a receipt does for each of its logs.
+func (eb *EthereumBloom) AddLog(address []byte, topics ...[]byte) {
+	eb.Add(address)
+	for _, topic := range topics {
+		eb.Add(topic)
+	}
+}
+
+// Test checks if the given data is likely to be in the bloom, by checking its three bits.
+func (eb *EthereumBloom) Test(data []byte) bool {
+	return eb.GetHash(bloomhashes.Keccak256(data))
+}
+
+// SetHash sets the three bits picked by the given hash value, which must be the first 8 bytes of a Keccak-256 hash as returned by bloomhashes.Keccak256.
+func (eb *EthereumBloom) SetHash(hash uint64) {
+	for i := range 3 {
+		eb.bits.Setbit(ethereumBloomIndex(hash, i))
+	}
+}
+
+// GetHash checks if the three bits picked by the given hash value are set, see [EthereumBloom.SetHash].
+func (eb *EthereumBloom) GetHash(hash uint64) bool {
+	for i := range 3 {
+		if !eb.bits.Getbit(ethereumBloomIndex(hash, i)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Merge adds every item of the other bloom to this bloom, like the bloom of a block combines the blooms of its receipts.
+func (eb *EthereumBloom) Merge(other *EthereumBloom) {
+	for i, word := range other.bits.data {
+		eb.bits.data[i] |= word
+	}
+}
+
+// BitsCount returns the total number of bits that are set to 1 in the bloom.
+func (eb *EthereumBloom) BitsCount() uint64 {
+	return eb.bits.BitsCount()
+}
+
+// Bits returns a copy of the Bits struct representing the bits of the bloom, in the byte order of the header.
+// Modifying the returned Bits will not affect the internal state of the bloom.
+func (eb *EthereumBloom) Bits() Bits {
+	return eb.bits.Copy()
+}
+
+// ethereumBloomIndex returns the index in bits of the i-th bit of a Keccak-256 hash, whose first 8 bytes are the little-endian hash value.
+// The bit is the low 11 bits of big-endian word i of the hash, stored in byte 255 - bit / 8 of the header.
+func ethereumBloomIndex(hash uint64, i int) uint64 {
+	high, low := hash>>(16*i)&0xff, hash>>(16*i+8)&0xff
+	bit := (high<<8 | low) & 0x7ff
+
+	return (EthereumBloomBytes-1-bit/8)*8 + bit%8
+}
@@ -0,0 +1,30 @@
+package bloomfilters
+
+import (
+	"encoding"
+	"errors"
+)
+
+var ErrInvalidEthereumBloomData = errors.New("ethereum bloom data must be exactly 256 bytes")
+
+var (
+	_ encoding.BinaryMarshaler   = (*EthereumBloom)(nil)
+	_ encoding.BinaryUnmarshaler = (*EthereumBloom)(nil)
+)
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler].
+// The data is the 256 byte logsBloom field of a block header or receipt.
+func (eb *EthereumBloom) UnmarshalBinary(data []byte) error {
+	if len(data) != EthereumBloomBytes {
+		return ErrInvalidEthereumBloomData
+	}
+
+	return eb.bits.UnmarshalBinary(data)
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler].
+// The data is the 256 byte logsBloom field of a block header or receipt.
+func (eb *EthereumBloom) MarshalBinary() (data []byte, err error) {
+	// Little-endian words are the same bytes as the byte array of the header
+	return eb.bits.MarshalBinary()
+}
@@ -0,0 +1,123 @@
+package bloomfilters_test
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the TestBloom vectors of go-ethereum
+func Test_EthereumBloom_Test(t *testing.T) {
+	positive := []string{"testtest", "test", "hallo", "other"}
+	negative := []string{"tes", "lo"}
+
+	eb := bloomfilters.NewEthereumBloom()
+	for _, data := range positive {
+		eb.Add([]byte(data))
+	}
+	for _, data := range positive {
+		assert.True(t, eb.Test([]byte(data)), "Expected %q to be found", data)
+	}
+	for _, data := range negative {
+		assert.False(t, eb.Test([]byte(data)), "Expected %q to not be found", data)
+	}
+}
+
+// Test the TestBloomExtensively vector of go-ethereum, the Keccak-256 hash of the header bytes after adding 100 items
+func Test_EthereumBloom_Extensively(t *testing.T) {
+	eb := bloomfilters.NewEthereumBloom()
+	for i := range 100 {
+		eb.Add(fmt.Appendf(nil, "xxxxxxxxxx data %d yyyyyyyyyyyyyy", i))
+	}
+
+	data, err := eb.MarshalBinary()
+	require.NoError(t, err)
+	require.Len(t, data, bloomfilters.EthereumBloomBytes)
+	sum := bloomhashes.Keccak256Sum(data)
+	assert.Equal(t, "c8d3ca65cdb4874300a9e39475508f23ed6da09fdbc487f89a2dcf50b09eb263", hex.EncodeToString(sum[:]))
+
+	var parsed bloomfilters.EthereumBloom
+	require.NoError(t, parsed.UnmarshalBinary(data))
+	for i := range 100 {
+		require.True(t, parsed.Test(fmt.Appendf(nil, "xxxxxxxxxx data %d yyyyyyyyyyyyyy", i)))
+	}
+}
+
+func Test_EthereumBloom_Layout(t *testing.T) {
+	eb := bloomfilters.NewEthereumBloom()
+	eb.Add(nil)
+	data, err := eb.MarshalBinary()
+	require.NoError(t, err)
+
+	// Keccak-256 of nothing starts with c5d2 4601 86f7, the low 11 bits of those words are bits 1490, 1537 and 1783
+	expected := make([]byte, bloomfilters.EthereumBloomBytes)
+	for _, bit := range []int{0x5d2, 0x601, 0x6f7} {
+		expected[bloomfilters.EthereumBloomBytes-1-bit/8] |= 1 << (bit % 8)
+	}
+	assert.Equal(t, expected, data)
+}
+
+func Test_EthereumBloom_Merge(t *testing.T) {
+	address := []byte("address")
+	topics := [][]byte{[]byte("Transfer(address,address,uint256)"), []byte("from"), []byte("to")}
+
+	receipt := bloomfilters.NewEthereumBloom()
+	receipt.AddLog(address, topics...)
+	other := bloomfilters.NewEthereumBloom()
+	other.Add([]byte("other"))
+
+	block := bloomfilters.NewEthereumBloom()
+	block.Merge(receipt)
+	block.Merge(other)
+	for _, data := range append(topics, address, []byte("other")) {
+		assert.True(t, block.Test(data))
+	}
+	assert.LessOrEqual(t, block.BitsCount(), receipt.BitsCount()+other.BitsCount())
+}
+
+func Test_EthereumBloom_UnmarshalInvalid(t *testing.T) {
+	var eb bloomfilters.EthereumBloom
+	require.ErrorIs(t, eb.UnmarshalBinary(make([]byte, 255)), bloomfilters.ErrInvalidEthereumBloomData)
+	require.ErrorIs(t, eb.UnmarshalBinary(make([]byte, 257)), bloomfilters.ErrInvalidEthereumBloomData)
+}
+
+// Fuzz test for EthereumBloom Add and Test
+func Fuzz_EthereumBloom_AddTest(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		eb := bloomfilters.NewEthereumBloom()
+		eb.Add(data)
+		require.True(t, eb.Test(data))
+		require.LessOrEqual(t, eb.BitsCount(), uint64(3))
+		require.Positive(t, eb.BitsCount())
+	})
+}
+
+// Example of checking a block logsBloom before fetching its receipts
+func ExampleEthereumBloom() {
+	receipt := bloomfilters.NewEthereumBloom()
+	receipt.AddLog([]byte("contract address"), []byte("event signature hash"))
+
+	header := bloomfilters.NewEthereumBloom()
+	header.Merge(receipt)
+	logsBloom, _ := header.MarshalBinary()
+
+	var received bloomfilters.EthereumBloom
+	_ = received.UnmarshalBinary(logsBloom)
+	fmt.Println(len(logsBloom))
+	fmt.Println(received.Test([]byte("contract address")))
+
+	// Output:
+	// 256
+	// true
+}
@@ -0,0 +1,247 @@
+package bloomfilters
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/extensions/xtime"
+)
+
+const (
+	// DefaultExpiryCounterWidth is the default width in bits of a single cell of an expiring bloom filter, enough for 136 years of seconds.
+	DefaultExpiryCounterWidth = 32
+	// DefaultTTL is the default time to live of items added to an expiring bloom filter with Add.
+	DefaultTTL = time.Hour
+	// DefaultResolution is the default duration of a single tick of the expiry timestamps of an expiring bloom filter.
+	DefaultResolution = time.Second
+)
+
+var (
+	ErrInvalidTTL        = errors.New("time to live must be positive")
+	ErrInvalidResolution = errors.New("resolution must be positive")
+)
+
+var _ IBloomFilter = &ExpiringBloomFilter{}
+
+// ExpiringBloomFilter is a bloom filter where every item has its own time to live.
+// Like a counting bloom filter every slot is a small counter, but the counter holds the latest expiry of the items in that slot, in ticks of the resolution since the filter was created.
+// Test only finds an item while all of its slots have not expired, so an item expires at its own expiry or later, when other items keep some of its slots alive,
+// but never earlier. Expiry timestamps are rounded up to the resolution, and expiries beyond the largest tick the cells can hold are capped at that tick, see [ExpiringBloomFilter.Horizon].
+// Once half of those ticks have passed, the cells are rebased on the current tick, so the horizon moves along with the clock and the filter never runs out of ticks.
+// Expired slots are ignored by Test, [ExpiringBloomFilter.Sweep] clears them so BitsCount and Bits only report live slots without checking the clock.
+type ExpiringBloomFilter struct {
+	counters Counters
+	hashes   []bloomhashes.HashFunction
+	derived  derivedHashes
+	epoch    time.Time
+
+	size       uint64
+	width      uint8
+	ttl        time.Duration
+	resolution time.Duration
+	clock      xtime.Clock
+	seed       *Bits
+}
+
+// NewExpiringBloomFilter creates a new expiring bloom filter with the given options, the size is the amount of cells.
+// It returns an error if the size, the counter width, the time to live or the resolution are invalid, or if any of the hash functions are nil.
+func NewExpiringBloomFilter(opts ...ExpiringBloomFilterOptions) (*ExpiringBloomFilter, error) {
+	bf := &ExpiringBloomFilter{
+		width:      DefaultExpiryCounterWidth,
+		ttl:        DefaultTTL,
+		resolution: DefaultResolution,
+		clock:      xtime.SystemClock{},
+	}
+	for _, opt := range opts {
+		opt.applyExpiring(bf)
+	}
+	if bf.size == 0 {
+		return nil, ErrInvalidSize
+	}
+	if bf.width == 0 || bf.width > 64 {
+		return nil, ErrInvalidCounterWidth
+	}
+	if bf.ttl <= 0 {
+		return nil, ErrInvalidTTL
+	}
+	if bf.resolution <= 0 {
+		return nil, ErrInvalidResolution
+	}
+	if len(bf.hashes) == 0 {
+		return nil, ErrRequiredHashFunction
+	}
+	for _, hashFunc := range bf.hashes {
+		if hashFunc == nil {
+			return nil, ErrHashIsNil
+		}
+	}
+
+	bf.counters = NewCounters(bf.size, bf.width)
+	bf.epoch = bf.clock.Now()
+	if bf.seed != nil {
+		// Every bit that was set, belongs to an item that has just been added
+		expiry := bf.tick(bf.epoch.Add(bf.ttl))
+		for i := range bf.seed.Size() {
+			if bf.seed.Getbit(i) {
+				bf.counters.Set(i, expiry)
+			}
+		}
+		bf.seed = nil
+	}
+
+	return bf, nil
+}
+
+// Add adds the given data to the bloom filter with the default time to live, see [WithTTL].
+func (bf *ExpiringBloomFilter) Add(data []byte) {
+	bf.AddWithTTL(data, bf.ttl)
+}
+
+// AddWithTTL adds the given data to the bloom filter, so it is found for at least the given time to live.
+// Adding data that is already in the filter extends its lifetime, but never shortens it.
+func (bf *ExpiringBloomFilter) AddWithTTL(data []byte, ttl time.Duration) {
+	bf.AddUntil(data, bf.clock.Now().Add(ttl))
+}
+
+// AddUntil adds the given data to the bloom filter, so it is found until at least the given expiry.
+// Adding data that is already in the filter extends its lifetime, but never shortens it. An expiry in the past does nothing.
+func (bf *ExpiringBloomFilter) AddUntil(data []byte, expiry time.Time) {
+	now := bf.now()
+	tick := bf.tick(expiry)
+	if tick <= now {
+		return
+	}
+
+	for _, hash := range bf.derived.all(bf.hashes, data) {
+		bf.extend(bf.index(hash), tick)
+	}
+}
+
+// Test checks if the given data is likely to be in the bloom filter and has not expired, by checking if the cells of each hash function have not expired.
+func (bf *ExpiringBloomFilter) Test(data []byte) bool {
+	now := bf.now()
+	for _, hash := range bf.derived.all(bf.hashes, data) {
+		if bf.counters.Get(bf.index(hash)) <= now {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetHash sets the cell corresponding to the given hash value to expire after the default time to live, unless it already expires later.
+func (bf *ExpiringBloomFilter) SetHash(hash uint64) {
+	now := bf.now()
+	if tick := bf.tick(bf.clock.Now().Add(bf.ttl)); tick > now {
+		bf.extend(bf.index(hash), tick)
+	}
+}
+
+// GetHash checks if the cell corresponding to the given hash value has not expired.
+func (bf *ExpiringBloomFilter) GetHash(hash uint64) bool {
+	return bf.counters.Get(bf.index(hash)) > bf.now()
+}
+
+// BitsCount returns the total number of cells that are not zero, this includes expired cells that have not been swept yet.
+func (bf *ExpiringBloomFilter) BitsCount() uint64 {
+	return bf.counters.Count()
+}
+
+// Bits returns a Bits struct where each bit is set if the corresponding cell is not zero, this includes expired cells that have not been swept yet.
+// Modifying the returned Bits will not affect the internal state of the bloom filter.
+func (bf *ExpiringBloomFilter) Bits() Bits {
+	bits := NewBits(bf.counters.Size())
+	for i := range bf.counters.Size() {
+		if bf.counters.Get(i) != 0 {
+			bits.Setbit(i)
+		}
+	}
+
+	return bits
+}
+
+// Sweep clears every expired cell and returns the amount of cells it cleared.
+func (bf *ExpiringBloomFilter) Sweep() uint64 {
+	now := bf.now()
+
+	var cleared uint64
+	for i := range bf.counters.Size() {
+		if v := bf.counters.Get(i); v != 0 && v <= now {
+			bf.counters.Set(i, 0)
+			cleared++
+		}
+	}
+
+	return cleared
+}
+
+// Horizon returns the latest expiry the cells can hold, later expiries are capped to it.
+// The horizon moves forward whenever the cells are rebased, it is always at least half of the ticks the cells can hold ahead of the clock.
+func (bf *ExpiringBloomFilter) Horizon() time.Time {
+	return bf.epoch.Add(time.Duration(bf.maxTick()) * bf.resolution)
+}
+
+// maxTick returns the largest tick a cell can hold, capped so the ticks never span more than the longest [time.Duration].
+func (bf *ExpiringBloomFilter) maxTick() uint64 {
+	return min(bf.counters.Max(), uint64(math.MaxInt64/bf.resolution))
+}
+
+// extend sets the cell to the given tick, unless it already expires later.
+func (bf *ExpiringBloomFilter) extend(index, tick uint64) {
+	if bf.counters.Get(index) < tick {
+		bf.counters.Set(index, tick)
+	}
+}
+
+// now returns the current tick, a cell with this tick or lower has expired.
+// Once half of the ticks the cells can hold have passed, it rebases the cells on the current tick, so it is always below the largest tick.
+func (bf *ExpiringBloomFilter) now() uint64 {
+	elapsed := bf.clock.Now().Sub(bf.epoch)
+	if elapsed < 0 {
+		return 0
+	}
+
+	now := uint64(elapsed / bf.resolution)
+	if maxTick := bf.maxTick(); now > 0 && now >= maxTick-maxTick/2 {
+		bf.rebase(now)
+
+		return 0
+	}
+
+	return now
+}
+
+// rebase moves the epoch forward by the given amount of ticks, and every cell back by the same amount.
+// Cells that expire at or before the new epoch are cleared.
+func (bf *ExpiringBloomFilter) rebase(ticks uint64) {
+	for i := range bf.counters.Size() {
+		if v := bf.counters.Get(i); v > ticks {
+			bf.counters.Set(i, v-ticks)
+		} else if v != 0 {
+			bf.counters.Set(i, 0)
+		}
+	}
+	bf.epoch = bf.epoch.Add(time.Duration(ticks) * bf.resolution)
+}
+
+// tick returns the tick of the given expiry, rounded up so items never expire early, and capped to the largest tick a cell can hold.
+func (bf *ExpiringBloomFilter) tick(expiry time.Time) uint64 {
+	elapsed := expiry.Sub(bf.epoch)
+	if elapsed <= 0 {
+		return 0
+	}
+
+	// Round up without adding to elapsed, which may already be the longest duration
+	ticks := uint64(elapsed / bf.resolution)
+	if elapsed%bf.resolution != 0 {
+		ticks++
+	}
+
+	return min(ticks, bf.maxTick())
+}
+
+func (bf *ExpiringBloomFilter) index(hash uint64) uint64 {
+	return hash % bf.counters.Size()
+}
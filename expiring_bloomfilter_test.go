@@ -0,0 +1,237 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/extensions/xtime"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newExpiring(t *testing.T, clock xtime.Clock, opts ...bloomfilters.ExpiringBloomFilterOptions) *bloomfilters.ExpiringBloomFilter {
+	t.Helper()
+
+	bf, err := bloomfilters.NewExpiringBloomFilter(append([]bloomfilters.ExpiringBloomFilterOptions{
+		bloomfilters.WithSize(4096),
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithClock(clock),
+	}, opts...)...)
+	require.NoError(t, err)
+
+	return bf
+}
+
+func Test_ExpiringBloomFilter_TTL(t *testing.T) {
+	clock := xtime.NewManualClock(time.Unix(1700000000, 0))
+	bf := newExpiring(t, clock, bloomfilters.WithTTL(10*time.Minute))
+
+	bf.Add([]byte("default"))
+	bf.AddWithTTL([]byte("short"), time.Minute)
+	bf.AddUntil([]byte("until"), clock.Now().Add(30*time.Minute))
+	bf.AddUntil([]byte("past"), clock.Now().Add(-time.Second))
+	assert.False(t, bf.Test([]byte("past")), "Expected an expiry in the past to do nothing")
+
+	clock.Advance(time.Minute - time.Nanosecond)
+	assert.True(t, bf.Test([]byte("short")))
+	clock.Advance(time.Nanosecond)
+	assert.False(t, bf.Test([]byte("short")), "Expected 'short' to expire after its own time to live")
+	assert.True(t, bf.Test([]byte("default")))
+
+	clock.Advance(9 * time.Minute)
+	assert.False(t, bf.Test([]byte("default")))
+	assert.True(t, bf.Test([]byte("until")))
+
+	clock.Advance(20 * time.Minute)
+	assert.False(t, bf.Test([]byte("until")))
+}
+
+func Test_ExpiringBloomFilter_Extend(t *testing.T) {
+	clock := xtime.NewManualClock(time.Unix(0, 0))
+	bf := newExpiring(t, clock)
+
+	bf.AddWithTTL([]byte("session"), 5*time.Minute)
+	// A shorter lifetime never shortens the existing one
+	bf.AddWithTTL([]byte("session"), time.Minute)
+	clock.Advance(2 * time.Minute)
+	assert.True(t, bf.Test([]byte("session")))
+
+	bf.AddWithTTL([]byte("session"), 10*time.Minute)
+	clock.Advance(10*time.Minute - time.Second)
+	assert.True(t, bf.Test([]byte("session")), "Expected the lifetime to be extended")
+	clock.Advance(time.Second)
+	assert.False(t, bf.Test([]byte("session")))
+}
+
+func Test_ExpiringBloomFilter_Resolution(t *testing.T) {
+	clock := xtime.NewManualClock(time.Unix(0, 0))
+	bf := newExpiring(t, clock, bloomfilters.WithResolution(time.Minute), bloomfilters.WithCounterWidth(8))
+	assert.Equal(t, time.Unix(0, 0).Add(255*time.Minute), bf.Horizon())
+
+	// Expiries are rounded up to the next tick, never down
+	bf.AddWithTTL([]byte("rounded"), 90*time.Second)
+	clock.Advance(119 * time.Second)
+	assert.True(t, bf.Test([]byte("rounded")))
+	clock.Advance(time.Second)
+	assert.False(t, bf.Test([]byte("rounded")))
+
+	// Expiries beyond the horizon are capped to it
+	horizon := bf.Horizon()
+	bf.AddWithTTL([]byte("capped"), 24*time.Hour)
+	clock.Set(horizon.Add(-time.Second))
+	assert.True(t, bf.Test([]byte("capped")))
+	clock.Set(horizon)
+	assert.False(t, bf.Test([]byte("capped")))
+}
+
+func Test_ExpiringBloomFilter_WideCounters(t *testing.T) {
+	clock := xtime.NewManualClock(time.Unix(0, 0))
+	for _, resolution := range []time.Duration{time.Nanosecond, time.Second, time.Hour} {
+		bf := newExpiring(t, clock, bloomfilters.WithResolution(resolution), bloomfilters.WithCounterWidth(64))
+		require.True(t, bf.Horizon().After(clock.Now().Add(100*365*24*time.Hour)), "Expected a 64 bit horizon not to overflow at a resolution of %s", resolution)
+
+		bf.AddWithTTL([]byte("forever"), math.MaxInt64)
+		clock.Advance(100 * 365 * 24 * time.Hour)
+		assert.True(t, bf.Test([]byte("forever")))
+		clock.Set(time.Unix(0, 0))
+	}
+}
+
+func Test_ExpiringBloomFilter_PastHorizon(t *testing.T) {
+	clock := xtime.NewManualClock(time.Unix(0, 0))
+	bf := newExpiring(t, clock, bloomfilters.WithCounterWidth(8))
+	horizon := bf.Horizon()
+	assert.Equal(t, time.Unix(0, 0).Add(255*time.Second), horizon)
+
+	bf.AddWithTTL([]byte("before"), 3*time.Minute)
+	clock.Advance(130 * time.Second)
+	assert.True(t, bf.Test([]byte("before")))
+	assert.True(t, bf.Horizon().After(horizon), "Expected the horizon to move along with the clock")
+
+	// Well past the initial horizon, the filter keeps working
+	for range 20 {
+		clock.Advance(time.Minute)
+		bf.AddWithTTL([]byte("after"), time.Minute)
+		require.True(t, bf.Test([]byte("after")))
+		require.False(t, bf.Test([]byte("missing")))
+		require.True(t, bf.Horizon().After(clock.Now().Add(127*time.Second)))
+	}
+	assert.False(t, bf.Test([]byte("before")), "Expected 'before' to have expired")
+
+	clock.Advance(time.Minute)
+	assert.False(t, bf.Test([]byte("after")))
+	bf.Sweep()
+	assert.Equal(t, uint64(0), bf.BitsCount())
+
+	// A clock jump far beyond the horizon clears every cell
+	bf.Add([]byte("jump"))
+	clock.Advance(24 * time.Hour)
+	assert.False(t, bf.Test([]byte("jump")))
+	assert.Equal(t, uint64(0), bf.BitsCount())
+}
+
+func Test_ExpiringBloomFilter_Sweep(t *testing.T) {
+	data := testutil.MoreBytes(200, 16)
+	clock := xtime.NewManualClock(time.Unix(0, 0))
+	bf := newExpiring(t, clock)
+
+	for i, d := range data {
+		bf.AddWithTTL(d, time.Duration(i%2+1)*time.Hour)
+	}
+	before := bf.BitsCount()
+	assert.Equal(t, uint64(0), bf.Sweep(), "Expected nothing to be swept before anything expired")
+
+	clock.Advance(time.Hour)
+	cleared := bf.Sweep()
+	assert.Positive(t, cleared)
+	assert.Equal(t, before-cleared, bf.BitsCount())
+	for i, d := range data {
+		if i%2 == 1 {
+			require.True(t, bf.Test(d), "Expected sweeping to keep items that have not expired")
+		}
+	}
+
+	clock.Advance(time.Hour)
+	bf.Sweep()
+	assert.Equal(t, uint64(0), bf.BitsCount())
+}
+
+func Test_ExpiringBloomFilter_Invalid(t *testing.T) {
+	hashes := bloomfilters.WithDefaultHashFunctions()
+	size := bloomfilters.WithSize(1024)
+
+	tests := []struct {
+		name string
+		opts []bloomfilters.ExpiringBloomFilterOptions
+		err  error
+	}{
+		{"size", []bloomfilters.ExpiringBloomFilterOptions{hashes}, bloomfilters.ErrInvalidSize},
+		{"width", []bloomfilters.ExpiringBloomFilterOptions{size, hashes, bloomfilters.WithCounterWidth(65)}, bloomfilters.ErrInvalidCounterWidth},
+		{"ttl", []bloomfilters.ExpiringBloomFilterOptions{size, hashes, bloomfilters.WithTTL(0)}, bloomfilters.ErrInvalidTTL},
+		{"resolution", []bloomfilters.ExpiringBloomFilterOptions{size, hashes, bloomfilters.WithResolution(-time.Second)}, bloomfilters.ErrInvalidResolution},
+		{"no hashes", []bloomfilters.ExpiringBloomFilterOptions{size}, bloomfilters.ErrRequiredHashFunction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bf, err := bloomfilters.NewExpiringBloomFilter(tt.opts...)
+			require.ErrorIs(t, err, tt.err)
+			require.Nil(t, bf)
+		})
+	}
+}
+
+// Fuzz test for ExpiringBloomFilter AddWithTTL and Test
+func Fuzz_ExpiringBloomFilter_AddTest(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"), int64(time.Minute))
+	f.Add([]byte("hello world"), int64(time.Hour))
+	f.Add([]byte(""), int64(time.Second))
+	f.Add([]byte("a"), int64(-time.Second))
+
+	f.Fuzz(func(t *testing.T, data []byte, ttl int64) {
+		clock := xtime.NewManualClock(time.Unix(0, 0))
+		bf := newExpiring(t, clock, bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.MD5}))
+
+		// Keep the time to live within a few years, so advancing the clock past it can't overflow
+		ttl %= int64(5 * 365 * 24 * time.Hour)
+		bf.AddWithTTL(data, time.Duration(ttl))
+		if ttl <= 0 {
+			require.False(t, bf.Test(data), "Expected data with an expiry in the past to not be added")
+
+			return
+		}
+		require.True(t, bf.Test(data), "Expected data to be found before it expires")
+		clock.Advance(time.Duration(ttl) + time.Second)
+		require.False(t, bf.Test(data), "Expected data to be expired after its time to live")
+	})
+}
+
+// Example of keys with their own lifetime in an ExpiringBloomFilter
+func ExampleExpiringBloomFilter() {
+	clock := xtime.NewManualClock(time.Unix(0, 0))
+
+	bf, _ := bloomfilters.NewExpiringBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithClock(clock),
+	)
+
+	bf.AddWithTTL([]byte("token-a"), 5*time.Minute)
+	bf.AddWithTTL([]byte("token-b"), time.Hour)
+
+	clock.Advance(10 * time.Minute)
+	fmt.Println(bf.Test([]byte("token-a")))
+	fmt.Println(bf.Test([]byte("token-b")))
+	fmt.Println(bf.Sweep() > 0)
+
+	// Output:
+	// false
+	// true
+	// true
+}
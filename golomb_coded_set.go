@@ -0,0 +1,233 @@
+package bloomfilters
+
+import (
+	"errors"
+	"iter"
+	"math"
+	"math/bits"
+	"slices"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+const (
+	// BIP158P is the Golomb-Rice parameter of BIP158 basic block filters, the amount of low bits of every delta that are stored as is.
+	BIP158P = 19
+	// BIP158M is the inverse false positive rate of BIP158 basic block filters.
+	BIP158M = 784931
+)
+
+var (
+	ErrInvalidGolombP = errors.New("golomb-rice parameter must be at most 32")
+	ErrInvalidGolombM = errors.New("inverse false positive rate must be positive")
+	ErrTooManyItems   = errors.New("too many items, the amount of items times the inverse false positive rate must fit in 64 bits")
+)
+
+// GolombCodedSet is a static set of items in its most compact form, a sorted list of hashes stored as Golomb-Rice coded deltas.
+// It is byte-compatible with the compact block filters of Bitcoin BIP158 when built with [BIP158P], [BIP158M] and the first 16 bytes of the block hash as key.
+// Items are hashed with SipHash-2-4 keyed with the key, and mapped to the range [0, N * M), so a lookup is a false positive with a probability of 1/M.
+// A lookup decodes the list up to the hash of the item, [GolombCodedSet.MatchAny] tests many items in a single pass over the list.
+type GolombCodedSet struct {
+	hash bloomhashes.HashFunction
+	data []byte
+	n    uint64
+	p    uint8
+	m    uint64
+}
+
+// BuildGolombCodedSet builds a Golomb-coded set from the given items, an item given more than once is only added once.
+// - key is the SipHash-2-4 key, for BIP158 the first 16 bytes of the block hash
+// - p is the Golomb-Rice parameter, see [BIP158P]
+// - m is the inverse false positive rate, see [BIP158M]
+// It returns an error if p or m are invalid, or if there are too many items for m.
+func BuildGolombCodedSet(key [16]byte, p uint8, m uint64, items iter.Seq[[]byte]) (*GolombCodedSet, error) {
+	unique := map[string]struct{}{}
+	for item := range items {
+		unique[string(item)] = struct{}{}
+	}
+
+	gcs, err := newGolombCodedSet(key, p, m, uint64(len(unique)))
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]uint64, 0, len(unique))
+	for item := range unique {
+		values = append(values, gcs.value([]byte(item)))
+	}
+	slices.Sort(values)
+
+	w := golombWriter{}
+	var last uint64
+	for _, value := range values {
+		w.writeRice(value-last, p)
+		last = value
+	}
+	gcs.data = w.data
+
+	return gcs, nil
+}
+
+func newGolombCodedSet(key [16]byte, p uint8, m, n uint64) (*GolombCodedSet, error) {
+	if p > 32 {
+		return nil, ErrInvalidGolombP
+	}
+	if m == 0 {
+		return nil, ErrInvalidGolombM
+	}
+	if n > math.MaxUint32 || (n != 0 && m > math.MaxUint64/n) {
+		return nil, ErrTooManyItems
+	}
+
+	return &GolombCodedSet{
+		hash: bloomhashes.SipHash24(key),
+		n:    n,
+		p:    p,
+		m:    m,
+	}, nil
+}
+
+// Test checks if the given item is likely to be in the set, it is a false positive with a probability of 1/M.
+func (gcs *GolombCodedSet) Test(data []byte) bool {
+	if gcs.n == 0 {
+		return false
+	}
+
+	target := gcs.value(data)
+	r := golombReader{data: gcs.data}
+	var value uint64
+	for range gcs.n {
+		value += r.readRice(gcs.p)
+		if value >= target {
+			return value == target
+		}
+	}
+
+	return false
+}
+
+// MatchAny checks if any of the given items is likely to be in the set.
+// The items are hashed and sorted first, then compared against the list while decoding it once, instead of once per item.
+func (gcs *GolombCodedSet) MatchAny(items iter.Seq[[]byte]) bool {
+	if gcs.n == 0 {
+		return false
+	}
+
+	var targets []uint64
+	for item := range items {
+		targets = append(targets, gcs.value(item))
+	}
+	if len(targets) == 0 {
+		return false
+	}
+	slices.Sort(targets)
+
+	r := golombReader{data: gcs.data}
+	var value uint64
+	for range gcs.n {
+		value += r.readRice(gcs.p)
+		for targets[0] < value {
+			targets = targets[1:]
+			if len(targets) == 0 {
+				return false
+			}
+		}
+		if targets[0] == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Keys returns the amount of unique items the set was built from.
+func (gcs *GolombCodedSet) Keys() uint64 {
+	return gcs.n
+}
+
+// P returns the Golomb-Rice parameter of the set.
+func (gcs *GolombCodedSet) P() uint8 {
+	return gcs.p
+}
+
+// M returns the inverse false positive rate of the set.
+func (gcs *GolombCodedSet) M() uint64 {
+	return gcs.m
+}
+
+// SizeInBits returns the size of the coded list in bits, rounded up to whole bytes, without the amount of items.
+func (gcs *GolombCodedSet) SizeInBits() uint64 {
+	return uint64(len(gcs.data)) * 8
+}
+
+// FalsePositiveRate returns the probability an item that is not in the set is found, 1/M.
+func (gcs *GolombCodedSet) FalsePositiveRate() float64 {
+	return 1 / float64(gcs.m)
+}
+
+// value returns the hash of the item, mapped to the range [0, N * M) without a modulo.
+func (gcs *GolombCodedSet) value(data []byte) uint64 {
+	hi, _ := bits.Mul64(gcs.hash(data), gcs.n*gcs.m)
+
+	return hi
+}
+
+// golombWriter writes a bit stream, most significant bit first.
+type golombWriter struct {
+	data []byte
+	pos  uint64
+}
+
+// writeRice writes the quotient of the value in unary, and its low p bits as is.
+func (w *golombWriter) writeRice(value uint64, p uint8) {
+	for range value >> p {
+		w.writeBit(true)
+	}
+	w.writeBit(false)
+	for i := int(p) - 1; i >= 0; i-- {
+		w.writeBit(value>>i&1 == 1)
+	}
+}
+
+func (w *golombWriter) writeBit(bit bool) {
+	if w.pos%8 == 0 {
+		w.data = append(w.data, 0)
+	}
+	if bit {
+		w.data[len(w.data)-1] |= 0x80 >> (w.pos % 8)
+	}
+	w.pos++
+}
+
+// golombReader reads a bit stream written by golombWriter, reading past the end returns zeros but still moves the position.
+type golombReader struct {
+	data []byte
+	pos  uint64
+}
+
+// readRice reads a value written by golombWriter.writeRice.
+func (r *golombReader) readRice(p uint8) uint64 {
+	var quotient uint64
+	for r.readBit() {
+		quotient++
+	}
+	value := quotient << p
+	for i := int(p) - 1; i >= 0; i-- {
+		if r.readBit() {
+			value |= 1 << i
+		}
+	}
+
+	return value
+}
+
+func (r *golombReader) readBit() bool {
+	index := r.pos / 8
+	pos := r.pos % 8
+	r.pos++
+	if index >= uint64(len(r.data)) {
+		return false
+	}
+
+	return r.data[index]&(0x80>>pos) != 0
+}
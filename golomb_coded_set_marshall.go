@@ -0,0 +1,109 @@
+package bloomfilters
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var ErrInvalidGolombData = errors.New("golomb-coded set data is truncated or malformed")
+
+var (
+	_ encoding.BinaryMarshaler   = (*GolombCodedSet)(nil)
+	_ encoding.BinaryUnmarshaler = (*GolombCodedSet)(nil)
+)
+
+// ParseGolombCodedSet parses a Golomb-coded set marshalled by [GolombCodedSet.MarshalBinary], like a BIP158 filter received from a peer.
+// The key, p and m are not part of the data, and must be the ones the set was built with, see [BuildGolombCodedSet].
+func ParseGolombCodedSet(key [16]byte, p uint8, m uint64, data []byte) (*GolombCodedSet, error) {
+	gcs, err := newGolombCodedSet(key, p, m, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := gcs.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+
+	return gcs, nil
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler].
+// The key, P and M are not part of the data, they are kept from the set. The whole list is decoded once to check it is complete.
+func (gcs *GolombCodedSet) UnmarshalBinary(data []byte) error {
+	n, size := readCompactSize(data)
+	if size == 0 {
+		return ErrInvalidGolombData
+	}
+	if n > math.MaxUint32 || (n != 0 && gcs.m > math.MaxUint64/n) {
+		return ErrTooManyItems
+	}
+	data = data[size:]
+
+	r := golombReader{data: data}
+	for range n {
+		r.readRice(gcs.p)
+		if r.pos > uint64(len(data))*8 {
+			return ErrInvalidGolombData
+		}
+	}
+
+	gcs.n, gcs.data = n, append([]byte(nil), data...)
+
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler].
+// The data is the amount of items as a Bitcoin CompactSize, followed by the Golomb-Rice coded list padded with zeros to whole bytes, the serialized filter of BIP158.
+func (gcs *GolombCodedSet) MarshalBinary() (data []byte, err error) {
+	data = appendCompactSize(make([]byte, 0, 9+len(gcs.data)), gcs.n)
+
+	return append(data, gcs.data...), nil
+}
+
+// appendCompactSize appends the value as a Bitcoin CompactSize, a single byte below 0xfd, otherwise a marker byte followed by a little-endian uint16, uint32 or uint64.
+func appendCompactSize(data []byte, value uint64) []byte {
+	switch {
+	case value < 0xfd:
+		return append(data, byte(value))
+	case value <= math.MaxUint16:
+		return binary.LittleEndian.AppendUint16(append(data, 0xfd), uint16(value))
+	case value <= math.MaxUint32:
+		return binary.LittleEndian.AppendUint32(append(data, 0xfe), uint32(value))
+	default:
+		return binary.LittleEndian.AppendUint64(append(data, 0xff), value)
+	}
+}
+
+// readCompactSize reads a Bitcoin CompactSize, it returns the value and its size in bytes, or a size of 0 if the data is too short or not canonical.
+func readCompactSize(data []byte) (value uint64, size int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+
+	var minimum uint64
+	switch data[0] {
+	case 0xfd:
+		if len(data) < 3 {
+			return 0, 0
+		}
+		value, size, minimum = uint64(binary.LittleEndian.Uint16(data[1:])), 3, 0xfd
+	case 0xfe:
+		if len(data) < 5 {
+			return 0, 0
+		}
+		value, size, minimum = uint64(binary.LittleEndian.Uint32(data[1:])), 5, math.MaxUint16+1
+	case 0xff:
+		if len(data) < 9 {
+			return 0, 0
+		}
+		value, size, minimum = binary.LittleEndian.Uint64(data[1:]), 9, math.MaxUint32+1
+	default:
+		return uint64(data[0]), 1
+	}
+	if value < minimum {
+		return 0, 0
+	}
+
+	return value, size
+}
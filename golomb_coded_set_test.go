@@ -0,0 +1,185 @@
+package bloomfilters_test
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var gcsKey = [16]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// Test the basic block filter of the testnet genesis block, from the BIP158 test vectors
+func Test_GolombCodedSet_BIP158(t *testing.T) {
+	blockHash, err := hex.DecodeString("000000000933ea01ad0ee984209779baaec3ced90fa3f408719526f8d77f4943")
+	require.NoError(t, err)
+	// The key is the start of the block hash in its internal byte order, the reverse of how it is displayed
+	slices.Reverse(blockHash)
+	var key [16]byte
+	copy(key[:], blockHash)
+	script, err := hex.DecodeString("4104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac")
+	require.NoError(t, err)
+
+	gcs, err := bloomfilters.BuildGolombCodedSet(key, bloomfilters.BIP158P, bloomfilters.BIP158M, slices.Values([][]byte{script}))
+	require.NoError(t, err)
+	data, err := gcs.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, "019dfca8", hex.EncodeToString(data))
+	assert.True(t, gcs.Test(script))
+
+	parsed, err := bloomfilters.ParseGolombCodedSet(key, bloomfilters.BIP158P, bloomfilters.BIP158M, data)
+	require.NoError(t, err)
+	assert.True(t, parsed.Test(script))
+	assert.Equal(t, uint64(1), parsed.Keys())
+}
+
+func Test_GolombCodedSet_Test(t *testing.T) {
+	data := testutil.MoreBytes(11000, 16)
+	items, others := data[:1000], data[1000:]
+	gcs, err := bloomfilters.BuildGolombCodedSet(gcsKey, 6, 64, slices.Values(items))
+	require.NoError(t, err)
+
+	for _, item := range items {
+		require.True(t, gcs.Test(item), "Expected every item of the set to be found")
+	}
+
+	var falsePositives int
+	for _, other := range others {
+		if gcs.Test(other) {
+			falsePositives++
+		}
+	}
+	assert.InDelta(t, gcs.FalsePositiveRate(), float64(falsePositives)/float64(len(others)), 0.005)
+	assert.InDelta(t, bloomsettings.GolombBitsPerKey(6, 64), float64(gcs.SizeInBits())/float64(gcs.Keys()), 0.2)
+}
+
+func Test_GolombCodedSet_MatchAny(t *testing.T) {
+	data := testutil.MoreBytes(3000, 16)
+	items, others := data[:1000], data[1000:]
+	gcs, err := bloomfilters.BuildGolombCodedSet(gcsKey, bloomfilters.BIP158P, bloomfilters.BIP158M, slices.Values(items))
+	require.NoError(t, err)
+
+	assert.False(t, gcs.MatchAny(slices.Values(others)))
+	assert.False(t, gcs.MatchAny(slices.Values([][]byte{})))
+	for i := 0; i < len(items); i += 97 {
+		queries := append(slices.Clone(others[:50]), items[i])
+		require.True(t, gcs.MatchAny(slices.Values(queries)), "Expected a match when one of the queries is in the set")
+	}
+	// The largest and smallest values of the set can be matched as well
+	assert.True(t, gcs.MatchAny(slices.Values(items)))
+}
+
+func Test_GolombCodedSet_Empty(t *testing.T) {
+	gcs, err := bloomfilters.BuildGolombCodedSet(gcsKey, bloomfilters.BIP158P, bloomfilters.BIP158M, slices.Values([][]byte{}))
+	require.NoError(t, err)
+
+	assert.False(t, gcs.Test([]byte("anything")))
+	assert.False(t, gcs.MatchAny(slices.Values([][]byte{[]byte("anything")})))
+	data, err := gcs.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0}, data)
+}
+
+func Test_GolombCodedSet_Duplicates(t *testing.T) {
+	items := [][]byte{[]byte("a"), []byte("b"), []byte("a"), []byte("c"), []byte("b")}
+	gcs, err := bloomfilters.BuildGolombCodedSet(gcsKey, bloomfilters.BIP158P, bloomfilters.BIP158M, slices.Values(items))
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(3), gcs.Keys())
+}
+
+func Test_GolombCodedSet_Marshal(t *testing.T) {
+	items := testutil.MoreBytes(300, 16)
+	gcs, err := bloomfilters.BuildGolombCodedSet(gcsKey, 10, 1000, slices.Values(items))
+	require.NoError(t, err)
+	data, err := gcs.MarshalBinary()
+	require.NoError(t, err)
+	// 300 items take a 3 byte CompactSize
+	assert.Equal(t, []byte{0xfd, 0x2c, 0x01}, data[:3])
+
+	parsed, err := bloomfilters.ParseGolombCodedSet(gcsKey, 10, 1000, data)
+	require.NoError(t, err)
+	assert.Equal(t, gcs.Keys(), parsed.Keys())
+	remarshalled, err := parsed.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, remarshalled)
+	for _, item := range items {
+		require.True(t, parsed.Test(item))
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"short size", []byte{0xfd, 0x2c}},
+		{"non canonical size", []byte{0xfd, 0x01, 0x00}},
+		{"truncated", data[:len(data)-10]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := bloomfilters.ParseGolombCodedSet(gcsKey, 10, 1000, tt.data)
+			require.ErrorIs(t, err, bloomfilters.ErrInvalidGolombData)
+		})
+	}
+}
+
+func Test_GolombCodedSet_Invalid(t *testing.T) {
+	items := slices.Values([][]byte{[]byte("a")})
+
+	_, err := bloomfilters.BuildGolombCodedSet(gcsKey, 33, bloomfilters.BIP158M, items)
+	require.ErrorIs(t, err, bloomfilters.ErrInvalidGolombP)
+	_, err = bloomfilters.BuildGolombCodedSet(gcsKey, bloomfilters.BIP158P, 0, items)
+	require.ErrorIs(t, err, bloomfilters.ErrInvalidGolombM)
+	_, err = bloomfilters.BuildGolombCodedSet(gcsKey, bloomfilters.BIP158P, 1<<63, slices.Values([][]byte{[]byte("a"), []byte("b")}))
+	require.ErrorIs(t, err, bloomfilters.ErrTooManyItems)
+	_, err = bloomfilters.ParseGolombCodedSet(gcsKey, bloomfilters.BIP158P, 1<<63, []byte{2, 0})
+	require.ErrorIs(t, err, bloomfilters.ErrTooManyItems)
+}
+
+// Fuzz test for GolombCodedSet Build, Test and MarshalBinary
+func Fuzz_GolombCodedSet_BuildTest(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"), []byte("other"))
+	f.Add([]byte("hello world"), []byte("hello"))
+	f.Add([]byte(""), []byte("a"))
+	f.Add([]byte("a"), []byte(""))
+
+	f.Fuzz(func(t *testing.T, data, other []byte) {
+		gcs, err := bloomfilters.BuildGolombCodedSet(gcsKey, bloomfilters.BIP158P, bloomfilters.BIP158M, slices.Values([][]byte{data, other}))
+		require.NoError(t, err)
+		require.True(t, gcs.Test(data))
+		require.True(t, gcs.Test(other))
+		require.True(t, gcs.MatchAny(slices.Values([][]byte{other})))
+
+		marshalled, err := gcs.MarshalBinary()
+		require.NoError(t, err)
+		parsed, err := bloomfilters.ParseGolombCodedSet(gcsKey, bloomfilters.BIP158P, bloomfilters.BIP158M, marshalled)
+		require.NoError(t, err)
+		require.True(t, parsed.Test(data))
+	})
+}
+
+// Example of building a BIP158 style filter and matching a wallet against it
+func ExampleGolombCodedSet() {
+	var key [16]byte // the first 16 bytes of the block hash
+	scripts := [][]byte{[]byte("script-a"), []byte("script-b"), []byte("script-c")}
+
+	gcs, _ := bloomfilters.BuildGolombCodedSet(key, bloomfilters.BIP158P, bloomfilters.BIP158M, slices.Values(scripts))
+	data, _ := gcs.MarshalBinary()
+	received, _ := bloomfilters.ParseGolombCodedSet(key, bloomfilters.BIP158P, bloomfilters.BIP158M, data)
+
+	wallet := [][]byte{[]byte("script-x"), []byte("script-b")}
+	fmt.Println(received.MatchAny(slices.Values(wallet)))
+	fmt.Println(received.Test([]byte("script-x")))
+
+	// Output:
+	// true
+	// false
+}
@@ -0,0 +1,259 @@
+package bloomfilters
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+const (
+	// DefaultKeySize is the default largest key in bytes an invertible bloom lookup table can store.
+	DefaultKeySize = 32
+
+	// ibltChecksumSalt separates the checksum of a key from the hash functions that pick its cells.
+	ibltChecksumSalt uint64 = 0x9e3779b97f4a7c15
+)
+
+var (
+	ErrInvalidKeySize     = errors.New("key size must be between 1 and 65535 bytes")
+	ErrKeyTooLong         = errors.New("key is longer than the key size of the table")
+	ErrTooFewCells        = errors.New("table needs at least one cell per hash function")
+	ErrIncompatibleTables = errors.New("tables do not have the same amount of cells, key size or hash functions")
+	ErrListEntriesFailed  = errors.New("could not list all entries, the difference is too large for the table")
+	ErrNoBitsToRestore    = errors.New("filter has no bits to restore from words or bits")
+)
+
+// InvertibleBloomLookupTable is a set of keys that can list its own contents, based on "Invertible Bloom Lookup Tables" by Goodrich and Mitzenmacher.
+// Every cell holds a count, the xor of the keys, the xor of their lengths and the xor of their checksums. A key is added to one cell per hash function,
+// each hash function has its own range of cells so a key never lands in the same cell twice.
+// The main use is set reconciliation: subtract the table of another set and list the keys that are only on one side.
+// Listing succeeds when the difference fits the table, see bloomsettings.IBLTCells, no matter how large the sets themselves are.
+type InvertibleBloomLookupTable struct {
+	counts     []int64
+	checksums  []uint64
+	lengthSums []uint16
+	keySums    []byte
+
+	cells    uint64
+	keySize  int
+	hashes   []bloomhashes.HashFunction
+	derived  derivedHashes
+	restored bool
+}
+
+// NewInvertibleBloomLookupTable creates a new invertible bloom lookup table with the given options, the size is the amount of cells.
+// Keys can be at most [DefaultKeySize] bytes long, unless set with [WithKeySize].
+// It returns an error if the size or the key size is invalid, if any of the hash functions are nil, or if [WithWords] or [WithBits] is given.
+func NewInvertibleBloomLookupTable(opts ...InvertibleBloomLookupTableOptions) (*InvertibleBloomLookupTable, error) {
+	t := &InvertibleBloomLookupTable{
+		keySize: DefaultKeySize,
+	}
+	for _, opt := range opts {
+		opt.applyIBLT(t)
+	}
+	if t.restored {
+		return nil, ErrNoBitsToRestore
+	}
+	if t.cells == 0 {
+		return nil, ErrInvalidSize
+	}
+	if t.keySize < 1 || t.keySize > 0xffff {
+		return nil, ErrInvalidKeySize
+	}
+	if len(t.hashes) == 0 {
+		return nil, ErrRequiredHashFunction
+	}
+	for _, hashFunc := range t.hashes {
+		if hashFunc == nil {
+			return nil, ErrHashIsNil
+		}
+	}
+	if t.cells < uint64(len(t.hashes)) {
+		return nil, ErrTooFewCells
+	}
+
+	t.counts = make([]int64, t.cells)
+	t.checksums = make([]uint64, t.cells)
+	t.lengthSums = make([]uint16, t.cells)
+	t.keySums = make([]byte, t.cells*uint64(t.keySize))
+
+	return t, nil
+}
+
+// Insert adds the given key to the table. It returns [ErrKeyTooLong] if the key is longer than the key size of the table.
+func (t *InvertibleBloomLookupTable) Insert(key []byte) error {
+	if len(key) > t.keySize {
+		return ErrKeyTooLong
+	}
+	t.update(key, 1)
+
+	return nil
+}
+
+// Delete removes the given key from the table. It returns [ErrKeyTooLong] if the key is longer than the key size of the table.
+// Deleting a key that was never inserted is allowed, it is then listed as a deleted entry by [InvertibleBloomLookupTable.ListEntries].
+func (t *InvertibleBloomLookupTable) Delete(key []byte) error {
+	if len(key) > t.keySize {
+		return ErrKeyTooLong
+	}
+	t.update(key, -1)
+
+	return nil
+}
+
+// Subtract removes every key of the other table from this table, so it only holds the difference between both sets.
+// Both tables must have the same amount of cells, key size and hash functions, or it returns [ErrIncompatibleTables].
+func (t *InvertibleBloomLookupTable) Subtract(other *InvertibleBloomLookupTable) error {
+	if t.cells != other.cells || t.keySize != other.keySize || len(t.hashes) != len(other.hashes) {
+		return ErrIncompatibleTables
+	}
+
+	for i := range t.counts {
+		t.counts[i] -= other.counts[i]
+		t.checksums[i] ^= other.checksums[i]
+		t.lengthSums[i] ^= other.lengthSums[i]
+	}
+	for i := range t.keySums {
+		t.keySums[i] ^= other.keySums[i]
+	}
+
+	return nil
+}
+
+// ListEntries lists the keys in the table, without modifying it. After [InvertibleBloomLookupTable.Subtract],
+// inserted are the keys only in this table and deleted are the keys only in the other table.
+// If not every key could be listed, it returns the keys it did find and [ErrListEntriesFailed].
+func (t *InvertibleBloomLookupTable) ListEntries() (inserted, deleted [][]byte, err error) {
+	peel := t.Copy()
+
+	// Every cell holding a single key is pure, removing that key can make other cells pure
+	queue := make([]uint64, 0, t.cells)
+	for i := range peel.cells {
+		if peel.pure(i) {
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		cell := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if !peel.pure(cell) {
+			continue
+		}
+
+		count := peel.counts[cell]
+		key := bytes.Clone(peel.key(cell)[:peel.lengthSums[cell]])
+		if count > 0 {
+			inserted = append(inserted, key)
+		} else {
+			deleted = append(deleted, key)
+		}
+
+		peel.update(key, -count)
+		for i, hash := range peel.derived.all(peel.hashes, key) {
+			if index := peel.index(hash, i); peel.pure(index) {
+				queue = append(queue, index)
+			}
+		}
+	}
+
+	for i := range peel.cells {
+		if !peel.empty(i) {
+			return inserted, deleted, ErrListEntriesFailed
+		}
+	}
+
+	return inserted, deleted, nil
+}
+
+// Copy returns a deep copy of the table, modifying the copy will not affect the original.
+func (t *InvertibleBloomLookupTable) Copy() *InvertibleBloomLookupTable {
+	return &InvertibleBloomLookupTable{
+		counts:     append([]int64(nil), t.counts...),
+		checksums:  append([]uint64(nil), t.checksums...),
+		lengthSums: append([]uint16(nil), t.lengthSums...),
+		keySums:    bytes.Clone(t.keySums),
+		cells:      t.cells,
+		keySize:    t.keySize,
+		hashes:     t.hashes,
+		derived:    t.derived,
+	}
+}
+
+// Cells returns the amount of cells of the table.
+func (t *InvertibleBloomLookupTable) Cells() uint64 {
+	return t.cells
+}
+
+// KeySize returns the largest key in bytes the table can store.
+func (t *InvertibleBloomLookupTable) KeySize() int {
+	return t.keySize
+}
+
+// update adds the key count times to every cell of the key, a negative count removes it.
+func (t *InvertibleBloomLookupTable) update(key []byte, count int64) {
+	checksum := t.checksum(key)
+	for i, hash := range t.derived.all(t.hashes, key) {
+		cell := t.index(hash, i)
+		t.counts[cell] += count
+		t.checksums[cell] ^= checksum
+		t.lengthSums[cell] ^= uint16(len(key))
+		sum := t.key(cell)
+		for j, b := range key {
+			sum[j] ^= b
+		}
+	}
+}
+
+// pure checks if the cell holds a single inserted or deleted key, verified by its checksum.
+func (t *InvertibleBloomLookupTable) pure(cell uint64) bool {
+	if count := t.counts[cell]; count != 1 && count != -1 {
+		return false
+	}
+	length := int(t.lengthSums[cell])
+	if length > t.keySize {
+		return false
+	}
+	key := t.key(cell)
+	// A single key is padded with zeros up to the key size
+	for _, b := range key[length:] {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return t.checksum(key[:length]) == t.checksums[cell]
+}
+
+// empty checks if nothing is left in the cell.
+func (t *InvertibleBloomLookupTable) empty(cell uint64) bool {
+	if t.counts[cell] != 0 || t.checksums[cell] != 0 || t.lengthSums[cell] != 0 {
+		return false
+	}
+	for _, b := range t.key(cell) {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// key returns the xor of the keys in the cell, it is part of the table so it can be modified in place.
+func (t *InvertibleBloomLookupTable) key(cell uint64) []byte {
+	start := cell * uint64(t.keySize)
+
+	return t.keySums[start : start+uint64(t.keySize)]
+}
+
+// index returns the cell of hash function i, within the range of cells of that hash function.
+func (t *InvertibleBloomLookupTable) index(hash uint64, i int) uint64 {
+	rangeSize := t.cells / uint64(len(t.hashes))
+
+	return uint64(i)*rangeSize + hash%rangeSize
+}
+
+// checksum returns the checksum of a key, used to tell a cell with a single key apart from a cell with several keys.
+func (t *InvertibleBloomLookupTable) checksum(key []byte) uint64 {
+	return xorMix(bloomhashes.XXHash64(key), ibltChecksumSalt)
+}
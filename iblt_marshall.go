@@ -0,0 +1,87 @@
+package bloomfilters
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrInvalidIBLTData = errors.New("invertible bloom lookup table data is invalid")
+
+const (
+	// ibltHeaderSize is the size of the amount of cells, the key size and the amount of hash functions at the start of a marshalled table.
+	ibltHeaderSize = 8 + 2 + 1
+	// ibltCellHeaderSize is the size of the count, the checksum and the length sum at the start of every marshalled cell, followed by the key sum.
+	ibltCellHeaderSize = 8 + 8 + 2
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*InvertibleBloomLookupTable)(nil)
+	_ encoding.BinaryUnmarshaler = (*InvertibleBloomLookupTable)(nil)
+)
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler].
+// The table must be created with the same hash functions as the marshalled table, the amount of cells and the key size are taken from the data.
+// It returns [ErrIncompatibleTables] if the amount of hash functions differs.
+func (t *InvertibleBloomLookupTable) UnmarshalBinary(data []byte) error {
+	if len(data) < ibltHeaderSize {
+		return ErrInvalidIBLTData
+	}
+
+	cells := binary.LittleEndian.Uint64(data)
+	keySize := int(binary.LittleEndian.Uint16(data[8:]))
+	if int(data[10]) != len(t.hashes) {
+		return ErrIncompatibleTables
+	}
+	data = data[ibltHeaderSize:]
+
+	// Check the amount of cells against the data before it is used to allocate
+	cellSize := uint64(ibltCellHeaderSize + keySize)
+	if keySize == 0 || cells < uint64(len(t.hashes)) || cells > uint64(len(data))/cellSize || uint64(len(data)) != cells*cellSize {
+		return ErrInvalidIBLTData
+	}
+
+	restored := InvertibleBloomLookupTable{
+		counts:     make([]int64, cells),
+		checksums:  make([]uint64, cells),
+		lengthSums: make([]uint16, cells),
+		keySums:    make([]byte, cells*uint64(keySize)),
+		cells:      cells,
+		keySize:    keySize,
+		hashes:     t.hashes,
+		derived:    t.derived,
+	}
+	for i := range cells {
+		cell := data[i*cellSize:]
+		restored.counts[i] = int64(binary.LittleEndian.Uint64(cell))
+		restored.checksums[i] = binary.LittleEndian.Uint64(cell[8:])
+		restored.lengthSums[i] = binary.LittleEndian.Uint16(cell[16:])
+		copy(restored.key(i), cell[ibltCellHeaderSize:cellSize])
+	}
+
+	*t = restored
+
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler].
+// The data is the amount of cells as little-endian uint64, the key size as little-endian uint16 and the amount of hash functions as a byte,
+// followed by every cell: the count as little-endian int64, the checksum as little-endian uint64, the length sum as little-endian uint16 and the key sum.
+// The hash functions themselves are not part of the data.
+func (t *InvertibleBloomLookupTable) MarshalBinary() (data []byte, err error) {
+	cellSize := uint64(ibltCellHeaderSize + t.keySize)
+
+	data = make([]byte, ibltHeaderSize+t.cells*cellSize)
+	binary.LittleEndian.PutUint64(data, t.cells)
+	binary.LittleEndian.PutUint16(data[8:], uint16(t.keySize))
+	data[10] = byte(len(t.hashes))
+	for i := range t.cells {
+		cell := data[ibltHeaderSize+i*cellSize:]
+		binary.LittleEndian.PutUint64(cell, uint64(t.counts[i]))
+		binary.LittleEndian.PutUint64(cell[8:], t.checksums[i])
+		binary.LittleEndian.PutUint16(cell[16:], t.lengthSums[i])
+		copy(cell[ibltCellHeaderSize:], t.key(i))
+	}
+
+	return data, nil
+}
@@ -0,0 +1,243 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ibltHashes = []bloomhashes.HashFunction{bloomhashes.Fnv1_64a, bloomhashes.Crc64_ECMA, bloomhashes.XXHash64}
+
+func newIBLT(t *testing.T, cells uint64, opts ...bloomfilters.InvertibleBloomLookupTableOptions) *bloomfilters.InvertibleBloomLookupTable {
+	t.Helper()
+
+	table, err := bloomfilters.NewInvertibleBloomLookupTable(append([]bloomfilters.InvertibleBloomLookupTableOptions{
+		bloomfilters.WithSize(cells),
+		bloomfilters.WithHashFunctions(ibltHashes),
+	}, opts...)...)
+	require.NoError(t, err)
+
+	return table
+}
+
+func Test_InvertibleBloomLookupTable_Reconcile(t *testing.T) {
+	data := testutil.MoreBytes(1100, 20)
+	shared, onlyA, onlyB := data[:1000], data[1000:1060], data[1060:]
+
+	cells := bloomsettings.IBLTCells(100, uint64(len(ibltHashes)))
+	a, b := newIBLT(t, cells), newIBLT(t, cells)
+	for _, d := range shared {
+		require.NoError(t, a.Insert(d))
+		require.NoError(t, b.Insert(d))
+	}
+	for _, d := range onlyA {
+		require.NoError(t, a.Insert(d))
+	}
+	for _, d := range onlyB {
+		require.NoError(t, b.Insert(d))
+	}
+
+	require.NoError(t, a.Subtract(b))
+	inserted, deleted, err := a.ListEntries()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, onlyA, inserted)
+	assert.ElementsMatch(t, onlyB, deleted)
+
+	// Listing does not modify the table
+	again, _, err := a.ListEntries()
+	require.NoError(t, err)
+	assert.Len(t, again, len(onlyA))
+}
+
+func Test_InvertibleBloomLookupTable_DerivedHashes(t *testing.T) {
+	data := testutil.MoreBytes(120, 20)
+	calls := 0
+	counted := func(data []byte) (uint64, uint64) {
+		calls++
+
+		return bloomhashes.XXH3_128(data)
+	}
+	derived := bloomfilters.WithDerivedHashes(counted, bloomhashes.EnhancedDoubleHashing, 4)
+
+	a := newIBLT(t, bloomsettings.IBLTCells(30, 4), derived)
+	b := newIBLT(t, bloomsettings.IBLTCells(30, 4), derived)
+	require.NoError(t, a.Insert(data[0]))
+	assert.Equal(t, 1, calls, "Expected the key to be hashed once per insert, not once per hash function")
+
+	for _, d := range data[1:100] {
+		require.NoError(t, a.Insert(d))
+		require.NoError(t, b.Insert(d))
+	}
+	for _, d := range data[100:] {
+		require.NoError(t, b.Insert(d))
+	}
+
+	require.NoError(t, a.Subtract(b))
+	inserted, deleted, err := a.ListEntries()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, data[:1], inserted)
+	assert.ElementsMatch(t, data[100:], deleted)
+}
+
+func Test_InvertibleBloomLookupTable_KeyLengths(t *testing.T) {
+	table := newIBLT(t, 30, bloomfilters.WithKeySize(8))
+	keys := [][]byte{{}, {0}, {0, 0}, {1, 0, 0}, []byte("12345678")}
+	for _, key := range keys {
+		require.NoError(t, table.Insert(key))
+	}
+	require.ErrorIs(t, table.Insert([]byte("123456789")), bloomfilters.ErrKeyTooLong)
+	require.ErrorIs(t, table.Delete([]byte("123456789")), bloomfilters.ErrKeyTooLong)
+
+	inserted, deleted, err := table.ListEntries()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, keys, inserted)
+	assert.Empty(t, deleted)
+}
+
+func Test_InvertibleBloomLookupTable_InsertDelete(t *testing.T) {
+	data := testutil.MoreBytes(500, 16)
+	table := newIBLT(t, 60)
+	for _, d := range data {
+		require.NoError(t, table.Insert(d))
+	}
+	// Far too many keys to list
+	_, _, err := table.ListEntries()
+	require.ErrorIs(t, err, bloomfilters.ErrListEntriesFailed)
+
+	for _, d := range data[10:] {
+		require.NoError(t, table.Delete(d))
+	}
+	inserted, deleted, err := table.ListEntries()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, data[:10], inserted)
+	assert.Empty(t, deleted)
+}
+
+func Test_InvertibleBloomLookupTable_Marshal(t *testing.T) {
+	data := testutil.MoreBytes(300, 16)
+	a, b := newIBLT(t, 90), newIBLT(t, 90)
+	for _, d := range data[:250] {
+		require.NoError(t, a.Insert(d))
+	}
+	for _, d := range data[5:] {
+		require.NoError(t, b.Insert(d))
+	}
+
+	// Exchange the table of b over the wire
+	wire, err := b.MarshalBinary()
+	require.NoError(t, err)
+	received, err := bloomfilters.NewInvertibleBloomLookupTable(bloomfilters.WithSize(3), bloomfilters.WithHashFunctions(ibltHashes))
+	require.NoError(t, err)
+	require.NoError(t, received.UnmarshalBinary(wire))
+	assert.Equal(t, b.Cells(), received.Cells())
+	assert.Equal(t, b.KeySize(), received.KeySize())
+
+	require.NoError(t, a.Subtract(received))
+	inserted, deleted, err := a.ListEntries()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, data[:5], inserted)
+	assert.ElementsMatch(t, data[250:], deleted)
+
+	require.ErrorIs(t, received.UnmarshalBinary(wire[:len(wire)-1]), bloomfilters.ErrInvalidIBLTData)
+	require.ErrorIs(t, received.UnmarshalBinary(wire[:5]), bloomfilters.ErrInvalidIBLTData)
+	other, err := bloomfilters.NewInvertibleBloomLookupTable(bloomfilters.WithSize(90), bloomfilters.WithDefaultHashFunctions())
+	require.NoError(t, err)
+	require.ErrorIs(t, other.UnmarshalBinary(wire), bloomfilters.ErrIncompatibleTables)
+}
+
+func Test_InvertibleBloomLookupTable_Invalid(t *testing.T) {
+	hashes := bloomfilters.WithHashFunctions(ibltHashes)
+
+	tests := []struct {
+		name string
+		opts []bloomfilters.InvertibleBloomLookupTableOptions
+		err  error
+	}{
+		{"size", []bloomfilters.InvertibleBloomLookupTableOptions{hashes}, bloomfilters.ErrInvalidSize},
+		{"key size", []bloomfilters.InvertibleBloomLookupTableOptions{bloomfilters.WithSize(30), bloomfilters.WithKeySize(0), hashes}, bloomfilters.ErrInvalidKeySize},
+		{"too few cells", []bloomfilters.InvertibleBloomLookupTableOptions{bloomfilters.WithSize(2), hashes}, bloomfilters.ErrTooFewCells},
+		{"no hashes", []bloomfilters.InvertibleBloomLookupTableOptions{bloomfilters.WithSize(30)}, bloomfilters.ErrRequiredHashFunction},
+		{"nil hash", []bloomfilters.InvertibleBloomLookupTableOptions{bloomfilters.WithSize(30), bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{nil})}, bloomfilters.ErrHashIsNil},
+		{"words", []bloomfilters.InvertibleBloomLookupTableOptions{bloomfilters.WithWords(make([]uint64, 1)), hashes}, bloomfilters.ErrNoBitsToRestore},
+		{"bits", []bloomfilters.InvertibleBloomLookupTableOptions{bloomfilters.WithSize(64), bloomfilters.WithBits(bloomfilters.NewBits(64)), hashes}, bloomfilters.ErrNoBitsToRestore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := bloomfilters.NewInvertibleBloomLookupTable(tt.opts...)
+			require.ErrorIs(t, err, tt.err)
+			require.Nil(t, table)
+		})
+	}
+
+	require.ErrorIs(t, newIBLT(t, 30).Subtract(newIBLT(t, 33)), bloomfilters.ErrIncompatibleTables)
+	require.ErrorIs(t, newIBLT(t, 30).Subtract(newIBLT(t, 30, bloomfilters.WithKeySize(8))), bloomfilters.ErrIncompatibleTables)
+}
+
+func Test_IBLTLoadThreshold(t *testing.T) {
+	// Known thresholds from "Invertible Bloom Lookup Tables" by Goodrich and Mitzenmacher
+	assert.InDelta(t, 1/1.222, bloomsettings.IBLTLoadThreshold(3), 0.001)
+	assert.InDelta(t, 1/1.295, bloomsettings.IBLTLoadThreshold(4), 0.001)
+	assert.InDelta(t, 1/1.425, bloomsettings.IBLTLoadThreshold(5), 0.001)
+	assert.Equal(t, uint64(0), bloomsettings.IBLTCells(100, 3)%3)
+}
+
+// Fuzz test for InvertibleBloomLookupTable Insert, Delete and ListEntries
+func Fuzz_InvertibleBloomLookupTable_ListEntries(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		table := newIBLT(t, 30, bloomfilters.WithKeySize(64))
+		if len(data) > table.KeySize() {
+			require.ErrorIs(t, table.Insert(data), bloomfilters.ErrKeyTooLong)
+
+			return
+		}
+
+		require.NoError(t, table.Insert(data))
+		require.NoError(t, table.Insert([]byte("shared")))
+		require.NoError(t, table.Delete([]byte("shared")))
+
+		inserted, deleted, err := table.ListEntries()
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{data}, inserted)
+		require.Empty(t, deleted)
+	})
+}
+
+// Example of finding the difference between two sets with an InvertibleBloomLookupTable
+func ExampleInvertibleBloomLookupTable() {
+	// Size the tables for a difference of at most 10 keys, no matter how large the sets are
+	opts := []bloomfilters.InvertibleBloomLookupTableOptions{
+		bloomfilters.WithSize(bloomsettings.IBLTCells(10, 3)),
+		bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64a, bloomhashes.Crc64_ECMA, bloomhashes.XXHash64}),
+	}
+	local, _ := bloomfilters.NewInvertibleBloomLookupTable(opts...)
+	remote, _ := bloomfilters.NewInvertibleBloomLookupTable(opts...)
+
+	for _, key := range []string{"apple", "banana", "cherry"} {
+		_ = local.Insert([]byte(key))
+	}
+	for _, key := range []string{"apple", "banana", "date"} {
+		_ = remote.Insert([]byte(key))
+	}
+
+	_ = local.Subtract(remote)
+	inserted, deleted, _ := local.ListEntries()
+	fmt.Printf("only local: %s\n", inserted)
+	fmt.Printf("only remote: %s\n", deleted)
+
+	// Output:
+	// only local: [cherry]
+	// only remote: [date]
+}
@@ -1,20 +1,185 @@
 package bloomfilters
 
 import (
+	"math/rand/v2"
+	"time"
+
 	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/extensions/xtime"
 )
 
 type BloomFilterOptions interface {
+	CountingBloomFilterOptions
+	ScalableBloomFilterOptions
+	PartitionedBloomFilterOptions
+	BlockedBloomFilterOptions
+	CuckooFilterOptions
+	QuotientFilterOptions
+	StableBloomFilterOptions
+	SlidingWindowBloomFilterOptions
+	AgePartitionedBloomFilterOptions
+	InvertibleBloomLookupTableOptions
+	CountMinSketchOptions
+	ExpiringBloomFilterOptions
+	RetouchedBloomFilterOptions
+	DeletableBloomFilterOptions
 	applyBF(*BloomFilter)
 	applyCBF(*ConcurrentBloomFilter)
 }
 
+// CountingBloomFilterOptions are the options accepted by [NewCountingBloomFilter] and [NewConcurrentCountingBloomFilter].
+// All [BloomFilterOptions] are also CountingBloomFilterOptions.
+type CountingBloomFilterOptions interface {
+	applyCounting(*CountingBloomFilter)
+}
+
+// ScalableBloomFilterOptions are the options accepted by [NewScalableBloomFilter].
+// All [BloomFilterOptions] are also ScalableBloomFilterOptions.
+type ScalableBloomFilterOptions interface {
+	applyScalable(*ScalableBloomFilter)
+}
+
+// PartitionedBloomFilterOptions are the options accepted by [NewPartitionedBloomFilter].
+// All [BloomFilterOptions] are also PartitionedBloomFilterOptions.
+type PartitionedBloomFilterOptions interface {
+	applyPartitioned(*PartitionedBloomFilter)
+}
+
+// BlockedBloomFilterOptions are the options accepted by [NewBlockedBloomFilter].
+// All [BloomFilterOptions] are also BlockedBloomFilterOptions.
+type BlockedBloomFilterOptions interface {
+	applyBlocked(*BlockedBloomFilter)
+}
+
+// CuckooFilterOptions are the options accepted by [NewCuckooFilter] and [NewConcurrentCuckooFilter].
+// All [BloomFilterOptions] are also CuckooFilterOptions, a cuckoo filter requires exactly one hash function.
+type CuckooFilterOptions interface {
+	applyCuckoo(*CuckooFilter)
+}
+
+// QuotientFilterOptions are the options accepted by [NewQuotientFilter].
+// All [BloomFilterOptions] are also QuotientFilterOptions, a quotient filter requires exactly one hash function.
+type QuotientFilterOptions interface {
+	applyQuotient(*QuotientFilter)
+}
+
+// StableBloomFilterOptions are the options accepted by [NewStableBloomFilter].
+// All [BloomFilterOptions] are also StableBloomFilterOptions.
+type StableBloomFilterOptions interface {
+	applyStable(*StableBloomFilter)
+}
+
+// SlidingWindowBloomFilterOptions are the options accepted by [NewSlidingWindowBloomFilter] and [NewConcurrentSlidingWindowBloomFilter].
+// All [BloomFilterOptions] are also SlidingWindowBloomFilterOptions.
+type SlidingWindowBloomFilterOptions interface {
+	applySliding(*SlidingWindowBloomFilter)
+}
+
+// AgePartitionedBloomFilterOptions are the options accepted by [NewAgePartitionedBloomFilter].
+// All [BloomFilterOptions] are also AgePartitionedBloomFilterOptions.
+type AgePartitionedBloomFilterOptions interface {
+	applyAgePartitioned(*AgePartitionedBloomFilter)
+}
+
+// InvertibleBloomLookupTableOptions are the options accepted by [NewInvertibleBloomLookupTable].
+// All [BloomFilterOptions] are also InvertibleBloomLookupTableOptions, but a table has no bits to restore, so [WithWords] and [WithBits] make it return [ErrNoBitsToRestore].
+type InvertibleBloomLookupTableOptions interface {
+	applyIBLT(*InvertibleBloomLookupTable)
+}
+
+// CountMinSketchOptions are the options accepted by [NewCountMinSketch].
+// All [BloomFilterOptions] are also CountMinSketchOptions, but a sketch has no bits to restore, so [WithWords] and [WithBits] make it return [ErrNoBitsToRestore].
+type CountMinSketchOptions interface {
+	applyCountMin(*CountMinSketch)
+}
+
+// ExpiringBloomFilterOptions are the options accepted by [NewExpiringBloomFilter].
+// All [BloomFilterOptions] are also ExpiringBloomFilterOptions.
+type ExpiringBloomFilterOptions interface {
+	applyExpiring(*ExpiringBloomFilter)
+}
+
+// RetouchedBloomFilterOptions are the options accepted by [NewRetouchedBloomFilter].
+// All [BloomFilterOptions] are also RetouchedBloomFilterOptions.
+type RetouchedBloomFilterOptions interface {
+	applyRetouched(*RetouchedBloomFilter)
+}
+
+// DeletableBloomFilterOptions are the options accepted by [NewDeletableBloomFilter].
+// All [BloomFilterOptions] are also DeletableBloomFilterOptions.
+type DeletableBloomFilterOptions interface {
+	applyDeletable(*DeletableBloomFilter)
+}
+
+// CounterWidthOptions are the options that set the width of a counter, accepted by filters with counters.
+type CounterWidthOptions interface {
+	CountingBloomFilterOptions
+	StableBloomFilterOptions
+	ExpiringBloomFilterOptions
+	RetouchedBloomFilterOptions
+}
+
+// FalsePositiveRateOptions are the options that set a target false positive rate, accepted by filters that derive their configuration from it.
+type FalsePositiveRateOptions interface {
+	ScalableBloomFilterOptions
+	StableBloomFilterOptions
+}
+
+// TimeWindowOptions are the options that configure the time window of a filter, accepted by filters that forget items over time.
+type TimeWindowOptions interface {
+	SlidingWindowBloomFilterOptions
+	AgePartitionedBloomFilterOptions
+}
+
+// ClockOptions are the options that set the clock of a filter, accepted by every filter that depends on time.
+type ClockOptions interface {
+	TimeWindowOptions
+	ExpiringBloomFilterOptions
+}
+
+// RandomSeedOptions are the options that seed the random choices of a filter, accepted by filters that make random choices.
+type RandomSeedOptions interface {
+	StableBloomFilterOptions
+	RetouchedBloomFilterOptions
+}
+
 type withSize struct {
 	size uint64
 }
 
 func (w withSize) applyBF(bf *BloomFilter)            { bf.bits = NewBits(w.size) }
 func (w withSize) applyCBF(bf *ConcurrentBloomFilter) { bf.bits = NewBits(w.size) }
+func (w withSize) applyCounting(bf *CountingBloomFilter) {
+	bf.size = max(w.size, 1) // Like NewBits, always have at least one word of storage
+	bf.seed = nil
+}
+func (w withSize) applyScalable(sbf *ScalableBloomFilter)      { sbf.initial = NewBits(w.size) }
+func (w withSize) applyPartitioned(bf *PartitionedBloomFilter) { bf.bits = NewBits(w.size) }
+func (w withSize) applyBlocked(bf *BlockedBloomFilter) {
+	// Round up to a whole number of blocks, with at least one block
+	blocks := max((w.size+BlockSize-1)/BlockSize, 1)
+	bf.bits = NewBits(blocks * BlockSize)
+}
+func (w withSize) applyCuckoo(cf *CuckooFilter) {
+	cf.size, cf.capacity, cf.words = max(w.size, 1), 0, nil
+}
+func (w withSize) applyQuotient(qf *QuotientFilter) {
+	qf.size, qf.qBits, qf.words = max(w.size, 1), 0, nil
+}
+func (w withSize) applySliding(bf *SlidingWindowBloomFilter)         { bf.bits = NewBits(w.size) }
+func (w withSize) applyAgePartitioned(bf *AgePartitionedBloomFilter) { bf.bits = NewBits(w.size) }
+func (w withSize) applyIBLT(t *InvertibleBloomLookupTable)           { t.cells = w.size }
+func (w withSize) applyCountMin(cms *CountMinSketch)                 { cms.width = w.size }
+func (w withSize) applyExpiring(bf *ExpiringBloomFilter) {
+	bf.size = max(w.size, 1)
+	bf.seed = nil
+}
+func (w withSize) applyRetouched(bf *RetouchedBloomFilter) { bf.bits = NewBits(w.size) }
+func (w withSize) applyDeletable(bf *DeletableBloomFilter) { bf.bits = NewBits(w.size) }
+func (w withSize) applyStable(bf *StableBloomFilter) {
+	bf.size = max(w.size, 1)
+	bf.seed = nil
+}
 
 // WithSize sets the size of the bloom filter in bits.
 // It calculates the necessary number of uint64 words to accommodate the specified size and initializes the Bits structure accordingly.
@@ -26,8 +191,54 @@ type withHashFunctions struct {
 	hashFunctions []bloomhashes.HashFunction
 }
 
-func (w withHashFunctions) applyBF(bf *BloomFilter)            { bf.hashes = w.hashFunctions }
-func (w withHashFunctions) applyCBF(bf *ConcurrentBloomFilter) { bf.hashes = w.hashFunctions }
+func (w withHashFunctions) applyBF(bf *BloomFilter) {
+	bf.hashes, bf.derived = w.hashFunctions, derivedHashes{}
+}
+func (w withHashFunctions) applyCBF(bf *ConcurrentBloomFilter) {
+	bf.hashes, bf.derived = w.hashFunctions, derivedHashes{}
+}
+func (w withHashFunctions) applyCounting(bf *CountingBloomFilter) {
+	bf.hashes, bf.derived = w.hashFunctions, derivedHashes{}
+}
+func (w withHashFunctions) applyScalable(sbf *ScalableBloomFilter) {
+	sbf.hashes, sbf.derived = w.hashFunctions, derivedHashes{}
+}
+func (w withHashFunctions) applyPartitioned(bf *PartitionedBloomFilter) {
+	bf.hashes, bf.derived = w.hashFunctions, derivedHashes{}
+}
+func (w withHashFunctions) applyBlocked(bf *BlockedBloomFilter) {
+	bf.hashes, bf.derived = w.hashFunctions, derivedHashes{}
+}
+func (w withHashFunctions) applyCuckoo(cf *CuckooFilter) {
+	cf.hashes = w.hashFunctions
+}
+func (w withHashFunctions) applyQuotient(qf *QuotientFilter) {
+	qf.hashes = w.hashFunctions
+}
+func (w withHashFunctions) applyStable(bf *StableBloomFilter) {
+	bf.hashes, bf.derived = w.hashFunctions, derivedHashes{}
+}
+func (w withHashFunctions) applySliding(bf *SlidingWindowBloomFilter) {
+	bf.hashes, bf.derived = w.hashFunctions, derivedHashes{}
+}
+func (w withHashFunctions) applyAgePartitioned(bf *AgePartitionedBloomFilter) {
+	bf.hashes, bf.derived = w.hashFunctions, derivedHashes{}
+}
+func (w withHashFunctions) applyIBLT(t *InvertibleBloomLookupTable) {
+	t.hashes, t.derived = w.hashFunctions, derivedHashes{}
+}
+func (w withHashFunctions) applyCountMin(cms *CountMinSketch) {
+	cms.hashes, cms.derived = w.hashFunctions, derivedHashes{}
+}
+func (w withHashFunctions) applyExpiring(bf *ExpiringBloomFilter) {
+	bf.hashes, bf.derived = w.hashFunctions, derivedHashes{}
+}
+func (w withHashFunctions) applyRetouched(bf *RetouchedBloomFilter) {
+	bf.hashes, bf.derived = w.hashFunctions, derivedHashes{}
+}
+func (w withHashFunctions) applyDeletable(bf *DeletableBloomFilter) {
+	bf.hashes, bf.derived = w.hashFunctions, derivedHashes{}
+}
 
 // WithDefaultHashFunctions sets the default hash functions to be used by the bloom filter.
 // It retrieves the default hash functions from the bloomhashes package and assigns them to the bloom filter's hashes field.
@@ -53,10 +264,53 @@ type withAppendHashFunctions struct {
 }
 
 func (w withAppendHashFunctions) applyBF(bf *BloomFilter) {
-	bf.hashes = append(bf.hashes, w.hashFunctions...)
+	// The appended hash functions are not derived, so every hash function is called again
+	bf.hashes, bf.derived = append(bf.hashes, w.hashFunctions...), derivedHashes{}
 }
 func (w withAppendHashFunctions) applyCBF(bf *ConcurrentBloomFilter) {
-	bf.hashes = append(bf.hashes, w.hashFunctions...)
+	bf.hashes, bf.derived = append(bf.hashes, w.hashFunctions...), derivedHashes{}
+}
+func (w withAppendHashFunctions) applyCounting(bf *CountingBloomFilter) {
+	bf.hashes, bf.derived = append(bf.hashes, w.hashFunctions...), derivedHashes{}
+}
+func (w withAppendHashFunctions) applyScalable(sbf *ScalableBloomFilter) {
+	sbf.hashes, sbf.derived = append(sbf.hashes, w.hashFunctions...), derivedHashes{}
+}
+func (w withAppendHashFunctions) applyPartitioned(bf *PartitionedBloomFilter) {
+	bf.hashes, bf.derived = append(bf.hashes, w.hashFunctions...), derivedHashes{}
+}
+func (w withAppendHashFunctions) applyBlocked(bf *BlockedBloomFilter) {
+	bf.hashes, bf.derived = append(bf.hashes, w.hashFunctions...), derivedHashes{}
+}
+func (w withAppendHashFunctions) applyCuckoo(cf *CuckooFilter) {
+	cf.hashes = append(cf.hashes, w.hashFunctions...)
+}
+func (w withAppendHashFunctions) applyQuotient(qf *QuotientFilter) {
+	qf.hashes = append(qf.hashes, w.hashFunctions...)
+}
+func (w withAppendHashFunctions) applyStable(bf *StableBloomFilter) {
+	bf.hashes, bf.derived = append(bf.hashes, w.hashFunctions...), derivedHashes{}
+}
+func (w withAppendHashFunctions) applySliding(bf *SlidingWindowBloomFilter) {
+	bf.hashes, bf.derived = append(bf.hashes, w.hashFunctions...), derivedHashes{}
+}
+func (w withAppendHashFunctions) applyAgePartitioned(bf *AgePartitionedBloomFilter) {
+	bf.hashes, bf.derived = append(bf.hashes, w.hashFunctions...), derivedHashes{}
+}
+func (w withAppendHashFunctions) applyIBLT(t *InvertibleBloomLookupTable) {
+	t.hashes, t.derived = append(t.hashes, w.hashFunctions...), derivedHashes{}
+}
+func (w withAppendHashFunctions) applyCountMin(cms *CountMinSketch) {
+	cms.hashes, cms.derived = append(cms.hashes, w.hashFunctions...), derivedHashes{}
+}
+func (w withAppendHashFunctions) applyExpiring(bf *ExpiringBloomFilter) {
+	bf.hashes, bf.derived = append(bf.hashes, w.hashFunctions...), derivedHashes{}
+}
+func (w withAppendHashFunctions) applyRetouched(bf *RetouchedBloomFilter) {
+	bf.hashes, bf.derived = append(bf.hashes, w.hashFunctions...), derivedHashes{}
+}
+func (w withAppendHashFunctions) applyDeletable(bf *DeletableBloomFilter) {
+	bf.hashes, bf.derived = append(bf.hashes, w.hashFunctions...), derivedHashes{}
 }
 
 // WithAppendHashFunctions appends additional hash functions to the existing list of hash functions used by the bloom filter. It takes a slice of HashFunction and appends it to the current list of hash functions.
@@ -66,12 +320,112 @@ func WithAppendHashFunctions(hashFunctions []bloomhashes.HashFunction) BloomFilt
 	}
 }
 
+type withDerivedHashes struct {
+	derived derivedHashes
+	k       uint64
+}
+
+func (w withDerivedHashes) hashes() []bloomhashes.HashFunction {
+	if w.derived.hash == nil {
+		// Leave the hash functions nil, so the filter reports it
+		return make([]bloomhashes.HashFunction, w.k)
+	}
+
+	return bloomhashes.DerivedHashFunctions(w.derived.hash, w.derived.derivation, w.k)
+}
+
+func (w withDerivedHashes) applyBF(bf *BloomFilter) {
+	bf.hashes, bf.derived = w.hashes(), w.derived
+}
+func (w withDerivedHashes) applyCBF(bf *ConcurrentBloomFilter) {
+	bf.hashes, bf.derived = w.hashes(), w.derived
+}
+func (w withDerivedHashes) applyCounting(bf *CountingBloomFilter) {
+	bf.hashes, bf.derived = w.hashes(), w.derived
+}
+func (w withDerivedHashes) applyScalable(sbf *ScalableBloomFilter) {
+	sbf.hashes, sbf.derived = w.hashes(), w.derived
+}
+func (w withDerivedHashes) applyPartitioned(bf *PartitionedBloomFilter) {
+	bf.hashes, bf.derived = w.hashes(), w.derived
+}
+func (w withDerivedHashes) applyBlocked(bf *BlockedBloomFilter) {
+	bf.hashes, bf.derived = w.hashes(), w.derived
+}
+func (w withDerivedHashes) applyCuckoo(cf *CuckooFilter) {
+	cf.hashes = w.hashes()
+}
+func (w withDerivedHashes) applyQuotient(qf *QuotientFilter) {
+	qf.hashes = w.hashes()
+}
+func (w withDerivedHashes) applyStable(bf *StableBloomFilter) {
+	bf.hashes, bf.derived = w.hashes(), w.derived
+}
+func (w withDerivedHashes) applySliding(bf *SlidingWindowBloomFilter) {
+	bf.hashes, bf.derived = w.hashes(), w.derived
+}
+func (w withDerivedHashes) applyAgePartitioned(bf *AgePartitionedBloomFilter) {
+	bf.hashes, bf.derived = w.hashes(), w.derived
+}
+func (w withDerivedHashes) applyIBLT(t *InvertibleBloomLookupTable) {
+	t.hashes, t.derived = w.hashes(), w.derived
+}
+func (w withDerivedHashes) applyCountMin(cms *CountMinSketch) {
+	cms.hashes, cms.derived = w.hashes(), w.derived
+}
+func (w withDerivedHashes) applyExpiring(bf *ExpiringBloomFilter) {
+	bf.hashes, bf.derived = w.hashes(), w.derived
+}
+func (w withDerivedHashes) applyRetouched(bf *RetouchedBloomFilter) {
+	bf.hashes, bf.derived = w.hashes(), w.derived
+}
+func (w withDerivedHashes) applyDeletable(bf *DeletableBloomFilter) {
+	bf.hashes, bf.derived = w.hashes(), w.derived
+}
+
+// WithDerivedHashes sets k hash functions derived from a single 128-bit hash, see bloomhashes.Derivation.
+// It replaces any existing hash functions, so k is set independently of the amount of hash functions available.
+// Filters compute the 128-bit hash once per item, so the cost of hashing doesn't grow with k. Filters with a single hash function,
+// like [CuckooFilter] and [QuotientFilter], call it through bloomhashes.DerivedHashFunctions, which computes it once as well.
+func WithDerivedHashes(hash bloomhashes.HashFunction128, derivation bloomhashes.Derivation, k uint64) BloomFilterOptions {
+	return withDerivedHashes{
+		derived: derivedHashes{hash: hash, derivation: derivation},
+		k:       k,
+	}
+}
+
 type withWords struct {
 	words []uint64
 }
 
 func (w withWords) applyBF(bf *BloomFilter)            { bf.bits = Bits{data: w.words} }
 func (w withWords) applyCBF(bf *ConcurrentBloomFilter) { bf.bits = Bits{data: w.words} }
+func (w withWords) applyCounting(bf *CountingBloomFilter) {
+	withBits{bits: Bits{data: w.words}}.applyCounting(bf)
+}
+func (w withWords) applyScalable(sbf *ScalableBloomFilter)      { sbf.initial = Bits{data: w.words} }
+func (w withWords) applyPartitioned(bf *PartitionedBloomFilter) { bf.bits = Bits{data: w.words} }
+func (w withWords) applyBlocked(bf *BlockedBloomFilter)         { bf.bits = Bits{data: w.words} }
+func (w withWords) applyCuckoo(cf *CuckooFilter) {
+	// The words hold the fingerprint table, the amount of buckets and the victim, as returned by CuckooFilter.Words
+	cf.size, cf.capacity, cf.words = 0, 0, w.words
+}
+func (w withWords) applyQuotient(qf *QuotientFilter) {
+	// The words hold the slots, as returned by QuotientFilter.Words, the amount of slots comes from WithQuotientBits
+	qf.size, qf.words = 0, w.words
+}
+func (w withWords) applySliding(bf *SlidingWindowBloomFilter)         { bf.bits = Bits{data: w.words} }
+func (w withWords) applyAgePartitioned(bf *AgePartitionedBloomFilter) { bf.bits = Bits{data: w.words} }
+func (w withWords) applyIBLT(t *InvertibleBloomLookupTable)           { t.restored = true }
+func (w withWords) applyCountMin(cms *CountMinSketch)                 { cms.restored = true }
+func (w withWords) applyExpiring(bf *ExpiringBloomFilter) {
+	withBits{bits: Bits{data: w.words}}.applyExpiring(bf)
+}
+func (w withWords) applyRetouched(bf *RetouchedBloomFilter) { bf.bits = Bits{data: w.words} }
+func (w withWords) applyDeletable(bf *DeletableBloomFilter) { bf.bits = Bits{data: w.words} }
+func (w withWords) applyStable(bf *StableBloomFilter) {
+	withBits{bits: Bits{data: w.words}}.applyStable(bf)
+}
 
 // WithWords sets the bits of the bloom filter using a slice of uint64 words. It initializes the Bits structure with the provided words, allowing for direct manipulation of the bloom filter's bit array.
 func WithWords(words []uint64) BloomFilterOptions {
@@ -86,6 +440,32 @@ type withBits struct {
 
 func (w withBits) applyBF(bf *BloomFilter)            { bf.bits = w.bits }
 func (w withBits) applyCBF(bf *ConcurrentBloomFilter) { bf.bits = w.bits }
+func (w withBits) applyCounting(bf *CountingBloomFilter) {
+	// Counting filters start with a counter of 1 for every bit that is set
+	bf.size = w.bits.Size()
+	bf.seed = &w.bits
+}
+func (w withBits) applyScalable(sbf *ScalableBloomFilter)            { sbf.initial = w.bits }
+func (w withBits) applyPartitioned(bf *PartitionedBloomFilter)       { bf.bits = w.bits }
+func (w withBits) applyBlocked(bf *BlockedBloomFilter)               { bf.bits = w.bits }
+func (w withBits) applyCuckoo(cf *CuckooFilter)                      { withWords{words: w.bits.data}.applyCuckoo(cf) }
+func (w withBits) applyQuotient(qf *QuotientFilter)                  { withWords{words: w.bits.data}.applyQuotient(qf) }
+func (w withBits) applySliding(bf *SlidingWindowBloomFilter)         { bf.bits = w.bits }
+func (w withBits) applyAgePartitioned(bf *AgePartitionedBloomFilter) { bf.bits = w.bits }
+func (w withBits) applyIBLT(t *InvertibleBloomLookupTable)           { t.restored = true }
+func (w withBits) applyCountMin(cms *CountMinSketch)                 { cms.restored = true }
+func (w withBits) applyExpiring(bf *ExpiringBloomFilter) {
+	// Expiring filters start with the default time to live for every bit that is set
+	bf.size = w.bits.Size()
+	bf.seed = &w.bits
+}
+func (w withBits) applyRetouched(bf *RetouchedBloomFilter) { bf.bits = w.bits }
+func (w withBits) applyDeletable(bf *DeletableBloomFilter) { bf.bits = w.bits }
+func (w withBits) applyStable(bf *StableBloomFilter) {
+	// Stable filters start with a full cell for every bit that is set
+	bf.size = w.bits.Size()
+	bf.seed = &w.bits
+}
 
 // WithBits sets the bits of the bloom filter using a Bits structure. It directly assigns the provided Bits to the bloom filter, allowing for more flexible manipulation of the bit array.
 func WithBits(bits Bits) BloomFilterOptions {
@@ -93,3 +473,362 @@ func WithBits(bits Bits) BloomFilterOptions {
 		bits: bits,
 	}
 }
+
+type withCounterWidth struct {
+	width uint8
+}
+
+func (w withCounterWidth) applyCounting(bf *CountingBloomFilter)   { bf.width = w.width }
+func (w withCounterWidth) applyStable(bf *StableBloomFilter)       { bf.width = w.width }
+func (w withCounterWidth) applyExpiring(bf *ExpiringBloomFilter)   { bf.width = w.width }
+func (w withCounterWidth) applyRetouched(bf *RetouchedBloomFilter) { bf.width = w.width }
+
+// WithCounterWidth sets the width in bits of a single counter of a counting bloom filter, defaults to [DefaultCounterWidth].
+// Wider counters are less likely to saturate, but use proportionally more memory. The width must be between 1 and 64 bits.
+// For a stable bloom filter it sets the width of a cell, defaults to [DefaultStableCounterWidth], cells are set to the maximum value of the width.
+// For an expiring bloom filter it sets the width of the expiry timestamp of a cell, defaults to [DefaultExpiryCounterWidth].
+// For a retouched bloom filter it sets the width of the counters of items and known false positives per bit.
+func WithCounterWidth(width uint8) CounterWidthOptions {
+	return withCounterWidth{
+		width: width,
+	}
+}
+
+type withGrowthFactor struct {
+	factor float64
+}
+
+func (w withGrowthFactor) applyScalable(sbf *ScalableBloomFilter) { sbf.growth = w.factor }
+
+// WithGrowthFactor sets the factor by which each new stage of a scalable bloom filter is larger than the previous one, defaults to [DefaultGrowthFactor].
+// Larger factors need fewer stages for the same amount of items, at the cost of more unused memory. The factor must be at least 1.
+func WithGrowthFactor(factor float64) ScalableBloomFilterOptions {
+	return withGrowthFactor{
+		factor: factor,
+	}
+}
+
+type withTighteningRatio struct {
+	ratio float64
+}
+
+func (w withTighteningRatio) applyScalable(sbf *ScalableBloomFilter) { sbf.tightening = w.ratio }
+
+// WithTighteningRatio sets the ratio by which the false positive rate of each new stage of a scalable bloom filter is reduced, defaults to [DefaultTighteningRatio].
+// The ratio must be between 0 and 1, values between 0.8 and 0.9 are recommended.
+func WithTighteningRatio(ratio float64) ScalableBloomFilterOptions {
+	return withTighteningRatio{
+		ratio: ratio,
+	}
+}
+
+type withFalsePositiveRate struct {
+	rate float64
+}
+
+func (w withFalsePositiveRate) applyScalable(sbf *ScalableBloomFilter) { sbf.fpr = w.rate }
+func (w withFalsePositiveRate) applyStable(bf *StableBloomFilter)      { bf.fpr = w.rate }
+
+// WithFalsePositiveRate sets the false positive rate the filter should stay below, defaults to [DefaultFalsePositiveRate].
+// A stable bloom filter converges to this rate instead. The rate must be between 0 and 1.
+func WithFalsePositiveRate(rate float64) FalsePositiveRateOptions {
+	return withFalsePositiveRate{
+		rate: rate,
+	}
+}
+
+type withCapacity struct {
+	capacity uint64
+}
+
+func (w withCapacity) applyCuckoo(cf *CuckooFilter) {
+	cf.size, cf.capacity, cf.words = 0, w.capacity, nil
+}
+
+// WithCapacity sizes a cuckoo filter to hold the given amount of items, instead of setting its size in bits with [WithSize].
+// The amount of buckets is rounded up to a power of 2, with some headroom so inserts don't start failing before the capacity is reached.
+func WithCapacity(capacity uint64) CuckooFilterOptions {
+	return withCapacity{
+		capacity: capacity,
+	}
+}
+
+type withFingerprintBits struct {
+	bits uint8
+}
+
+func (w withFingerprintBits) applyCuckoo(cf *CuckooFilter) { cf.fpBits = w.bits }
+
+// WithFingerprintBits sets the size in bits of a single fingerprint of a cuckoo filter, defaults to [DefaultFingerprintBits].
+// Larger fingerprints lower the false positive rate, see bloomsettings.CuckooFalsePositiveRate. The size must be 8, 12 or 16 bits.
+func WithFingerprintBits(bits uint8) CuckooFilterOptions {
+	return withFingerprintBits{
+		bits: bits,
+	}
+}
+
+type withMaxKicks struct {
+	kicks int
+}
+
+func (w withMaxKicks) applyCuckoo(cf *CuckooFilter) { cf.maxKicks = w.kicks }
+
+// WithMaxKicks sets the amount of fingerprints a cuckoo filter relocates before an insert gives up, defaults to [DefaultMaxKicks].
+// More kicks allow a higher load factor, at the cost of slower inserts when the filter is almost full. The amount must be at least 1.
+func WithMaxKicks(kicks int) CuckooFilterOptions {
+	return withMaxKicks{
+		kicks: kicks,
+	}
+}
+
+type withQuotientBits struct {
+	bits uint8
+}
+
+func (w withQuotientBits) applyQuotient(qf *QuotientFilter) {
+	qf.size, qf.qBits = 0, w.bits
+}
+
+// WithQuotientBits sizes a quotient filter to 2^bits slots, instead of setting its size in bits with [WithSize].
+// The quotient and remainder bits together must not exceed 64.
+// Restoring a quotient filter with [WithWords] requires this option, since the words alone do not tell how many slots there are.
+func WithQuotientBits(bits uint8) QuotientFilterOptions {
+	return withQuotientBits{
+		bits: bits,
+	}
+}
+
+type withRemainderBits struct {
+	bits uint8
+}
+
+func (w withRemainderBits) applyQuotient(qf *QuotientFilter) { qf.rBits = w.bits }
+
+// WithRemainderBits sets the size in bits of the remainder a quotient filter stores per item, defaults to [DefaultRemainderBits].
+// Every extra bit halves the false positive rate, see bloomsettings.QuotientFilterFalsePositiveRate. The size must be between 1 and 61 bits.
+func WithRemainderBits(bits uint8) QuotientFilterOptions {
+	return withRemainderBits{
+		bits: bits,
+	}
+}
+
+type withDecrements struct {
+	decrements uint64
+}
+
+func (w withDecrements) applyStable(bf *StableBloomFilter) { bf.decrements = w.decrements }
+
+// WithDecrements sets the amount of cells a stable bloom filter decrements per add, instead of deriving it from the false positive rate.
+// More decrements make old items fade out faster, which lowers the false positive rate and raises the false negative rate.
+// A value of 0 derives it from the false positive rate, which is the default.
+func WithDecrements(decrements uint64) StableBloomFilterOptions {
+	return withDecrements{
+		decrements: decrements,
+	}
+}
+
+type withRandomSeed struct {
+	seed uint64
+}
+
+func (w withRandomSeed) applyStable(bf *StableBloomFilter) {
+	bf.rng = rand.New(rand.NewPCG(w.seed, w.seed)) // nolint:gosec // This is not used for cryptographic purposes.
+}
+func (w withRandomSeed) applyRetouched(bf *RetouchedBloomFilter) {
+	bf.rng = rand.New(rand.NewPCG(w.seed, w.seed)) // nolint:gosec // This is not used for cryptographic purposes.
+}
+
+// WithRandomSeed seeds the random cell decrements of a stable bloom filter, or the random bit choice of a retouched bloom filter,
+// so the filter behaves the same on every run. By default a random seed is used.
+func WithRandomSeed(seed uint64) RandomSeedOptions {
+	return withRandomSeed{
+		seed: seed,
+	}
+}
+
+type withInterval struct {
+	interval time.Duration
+}
+
+func (w withInterval) applySliding(bf *SlidingWindowBloomFilter)         { bf.interval = w.interval }
+func (w withInterval) applyAgePartitioned(bf *AgePartitionedBloomFilter) { bf.interval = w.interval }
+
+// WithInterval sets how often a sliding window bloom filter starts a new generation, clearing the oldest one.
+// With n generations an item is found for at least n-1 intervals, so for a window of 10 minutes and 4 generations use an interval of 10/3 minutes.
+// An age-partitioned bloom filter shifts its slices every interval instead of after a fixed amount of items.
+func WithInterval(interval time.Duration) TimeWindowOptions {
+	return withInterval{
+		interval: interval,
+	}
+}
+
+type withGenerations struct {
+	generations int
+}
+
+func (w withGenerations) applySliding(bf *SlidingWindowBloomFilter) { bf.count = w.generations }
+
+// WithGenerations sets the amount of generations of a sliding window bloom filter, defaults to [DefaultGenerations].
+// More generations make the moment an item is forgotten more precise, at the cost of memory and slower tests. At least 2 generations are needed.
+func WithGenerations(generations int) SlidingWindowBloomFilterOptions {
+	return withGenerations{
+		generations: generations,
+	}
+}
+
+type withClock struct {
+	clock xtime.Clock
+}
+
+func (w withClock) applySliding(bf *SlidingWindowBloomFilter)         { bf.clock = w.clock }
+func (w withClock) applyAgePartitioned(bf *AgePartitionedBloomFilter) { bf.clock = w.clock }
+func (w withClock) applyExpiring(bf *ExpiringBloomFilter)             { bf.clock = w.clock }
+
+// WithClock sets the clock a time based filter uses, defaults to the system clock.
+// Tests can use a xtime.ManualClock to move time forward without waiting.
+func WithClock(clock xtime.Clock) ClockOptions {
+	return withClock{
+		clock: clock,
+	}
+}
+
+type withAgePartitions struct {
+	k, l int
+}
+
+func (w withAgePartitions) applyAgePartitioned(bf *AgePartitionedBloomFilter) { bf.k, bf.l = w.k, w.l }
+
+// WithAgePartitions sets the amount of slices an age-partitioned bloom filter adds an item to, and the amount of extra slices that keep older items,
+// defaults to [DefaultAgePartitionsK] and [DefaultAgePartitionsL]. A larger k lowers the false positive rate, a larger l makes the window longer.
+// k must be at least 1 and l at least 1.
+func WithAgePartitions(k, l int) AgePartitionedBloomFilterOptions {
+	return withAgePartitions{
+		k: k,
+		l: l,
+	}
+}
+
+type withGenerationSize struct {
+	size uint64
+}
+
+func (w withGenerationSize) applyAgePartitioned(bf *AgePartitionedBloomFilter) {
+	bf.generation = w.size
+}
+
+// WithGenerationSize sets the amount of items an age-partitioned bloom filter adds before it shifts its slices.
+// With [WithInterval] it is the most items expected per interval, only used for the false positive rate.
+// By default the size is chosen so the slices are half full when they are shifted out, which gives the lowest false positive rate for the memory.
+func WithGenerationSize(size uint64) AgePartitionedBloomFilterOptions {
+	return withGenerationSize{
+		size: size,
+	}
+}
+
+type withKeySize struct {
+	size int
+}
+
+func (w withKeySize) applyIBLT(t *InvertibleBloomLookupTable) { t.keySize = w.size }
+
+// WithKeySize sets the largest key in bytes an invertible bloom lookup table can store, defaults to [DefaultKeySize].
+// Every cell stores the xor of its keys in this many bytes, so it directly sets the size of the table. The size must be between 1 and 65535 bytes.
+func WithKeySize(size int) InvertibleBloomLookupTableOptions {
+	return withKeySize{
+		size: size,
+	}
+}
+
+type withEpsilon struct {
+	epsilon float64
+}
+
+func (w withEpsilon) applyCountMin(cms *CountMinSketch) { cms.epsilon = w.epsilon }
+
+// WithEpsilon sets the error of a Count-Min sketch relative to the total of all counts, defaults to [DefaultEpsilon].
+// A smaller epsilon makes the rows wider, see bloomsettings.CountMinWidth. Epsilon must be between 0 and 1.
+func WithEpsilon(epsilon float64) CountMinSketchOptions {
+	return withEpsilon{
+		epsilon: epsilon,
+	}
+}
+
+type withDelta struct {
+	delta float64
+}
+
+func (w withDelta) applyCountMin(cms *CountMinSketch) { cms.delta = w.delta }
+
+// WithDelta sets the probability an estimate of a Count-Min sketch overshoots by more than its error, defaults to [DefaultDelta].
+// A smaller delta adds rows, see bloomsettings.CountMinDepth. Delta must be between 0 and 1.
+func WithDelta(delta float64) CountMinSketchOptions {
+	return withDelta{
+		delta: delta,
+	}
+}
+
+type withConservativeUpdate struct{}
+
+func (w withConservativeUpdate) applyCountMin(cms *CountMinSketch) { cms.conservative = true }
+
+// WithConservativeUpdate makes a Count-Min sketch only raise the counters of an item that are below its new estimate.
+// This lowers the error of frequent and infrequent items alike, while estimates still never undershoot.
+func WithConservativeUpdate() CountMinSketchOptions {
+	return withConservativeUpdate{}
+}
+
+type withTTL struct {
+	ttl time.Duration
+}
+
+func (w withTTL) applyExpiring(bf *ExpiringBloomFilter) { bf.ttl = w.ttl }
+
+// WithTTL sets the time to live of items added to an expiring bloom filter with Add, defaults to [DefaultTTL].
+// Items added with AddWithTTL or AddUntil have their own lifetime. The time to live must be positive.
+func WithTTL(ttl time.Duration) ExpiringBloomFilterOptions {
+	return withTTL{
+		ttl: ttl,
+	}
+}
+
+type withResolution struct {
+	resolution time.Duration
+}
+
+func (w withResolution) applyExpiring(bf *ExpiringBloomFilter) { bf.resolution = w.resolution }
+
+// WithResolution sets the duration of a single tick of the expiry timestamps of an expiring bloom filter, defaults to [DefaultResolution].
+// Items may be found for up to one tick after they expire. Together with [WithCounterWidth] it sets how far ahead expiries can be,
+// a coarser resolution allows narrower cells. The resolution must be positive.
+func WithResolution(resolution time.Duration) ExpiringBloomFilterOptions {
+	return withResolution{
+		resolution: resolution,
+	}
+}
+
+type withRetouchStrategy struct {
+	strategy RetouchStrategy
+}
+
+func (w withRetouchStrategy) applyRetouched(bf *RetouchedBloomFilter) { bf.strategy = w.strategy }
+
+// WithRetouchStrategy sets how a retouched bloom filter chooses the bit to clear for a false positive, defaults to [RetouchRatio].
+func WithRetouchStrategy(strategy RetouchStrategy) RetouchedBloomFilterOptions {
+	return withRetouchStrategy{
+		strategy: strategy,
+	}
+}
+
+type withRegions struct {
+	regions uint64
+}
+
+func (w withRegions) applyDeletable(bf *DeletableBloomFilter) { bf.regions = w.regions }
+
+// WithRegions sets the amount of regions the bits of a deletable bloom filter are split into, defaults to one region per [DefaultRegionSize] bits.
+// More regions make more items removable, at the cost of one bit of memory per region.
+func WithRegions(regions uint64) DeletableBloomFilterOptions {
+	return withRegions{
+		regions: regions,
+	}
+}
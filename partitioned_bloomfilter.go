@@ -0,0 +1,118 @@
+package bloomfilters
+
+import (
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+var _ IBloomFilter = &PartitionedBloomFilter{}
+
+// PartitionedBloomFilter is a bloom filter where the bit array is split into k equal slices, one for each hash function.
+// Hash function i only addresses slice i, so the hash functions never collide with one another and every slice fills up at the same, predictable, rate.
+// Any bits left over after dividing the bit array into k slices are not used.
+type PartitionedBloomFilter struct {
+	bits      Bits
+	hashes    []bloomhashes.HashFunction
+	derived   derivedHashes
+	sliceSize uint64
+}
+
+// NewPartitionedBloomFilter creates a new partitioned bloom filter with the given options, the size is the total size of all slices together.
+// It returns an error if the size of the bloom filter is too small to give each hash function a slice, or if any of the hash functions are nil.
+func NewPartitionedBloomFilter(opts ...PartitionedBloomFilterOptions) (*PartitionedBloomFilter, error) {
+	bf := &PartitionedBloomFilter{}
+	for _, opt := range opts {
+		opt.applyPartitioned(bf)
+	}
+	if bf.bits.Size() == 0 {
+		return nil, ErrInvalidSize
+	}
+	if len(bf.hashes) == 0 {
+		return nil, ErrRequiredHashFunction
+	}
+	for _, hashFunc := range bf.hashes {
+		if hashFunc == nil {
+			return nil, ErrHashIsNil
+		}
+	}
+
+	bf.sliceSize = bf.bits.Size() / uint64(len(bf.hashes))
+	if bf.sliceSize == 0 {
+		return nil, ErrInvalidSize
+	}
+
+	return bf, nil
+}
+
+// Add adds the given data to the bloom filter by applying each hash function to the data and setting the corresponding bit in the slice of that hash function.
+func (bf *PartitionedBloomFilter) Add(data []byte) {
+	for i, hash := range bf.derived.all(bf.hashes, data) {
+		bf.bits.Setbit(bf.index(i, hash))
+	}
+}
+
+// Test checks if the given data is likely to be in the bloom filter by applying each hash function to the data and checking if the corresponding bit in the slice of that hash function is set.
+// It returns true if all bits are set, indicating that the data is likely to be in the filter, and false otherwise.
+func (bf *PartitionedBloomFilter) Test(data []byte) bool {
+	for i, hash := range bf.derived.all(bf.hashes, data) {
+		if !bf.bits.Getbit(bf.index(i, hash)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetHash sets the bit corresponding to the given hash value to 1 in every slice, as if every hash function produced this hash value.
+func (bf *PartitionedBloomFilter) SetHash(hash uint64) {
+	for i := range bf.hashes {
+		bf.bits.Setbit(bf.index(i, hash))
+	}
+}
+
+// GetHash checks if the bit corresponding to the given hash value is set to 1 in any slice.
+// So it returns true for a hash value produced by any of the hash functions of data that has been added.
+func (bf *PartitionedBloomFilter) GetHash(hash uint64) bool {
+	for i := range bf.hashes {
+		if bf.bits.Getbit(bf.index(i, hash)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BitsCount returns the total number of bits that are set to 1 in the bloom filter.
+func (bf *PartitionedBloomFilter) BitsCount() uint64 {
+	return bf.bits.BitsCount()
+}
+
+// SliceBitsCount returns the number of bits that are set to 1 in each slice, indexed by the hash function that addresses the slice.
+func (bf *PartitionedBloomFilter) SliceBitsCount() []uint64 {
+	counts := make([]uint64, len(bf.hashes))
+	for i := range counts {
+		start := uint64(i) * bf.sliceSize
+		for j := range bf.sliceSize {
+			if bf.bits.Getbit(start + j) {
+				counts[i]++
+			}
+		}
+	}
+
+	return counts
+}
+
+// SliceSize returns the size in bits of a single slice.
+func (bf *PartitionedBloomFilter) SliceSize() uint64 {
+	return bf.sliceSize
+}
+
+// Bits returns a copy of the Bits struct representing the bit array of the bloom filter, slice i starts at bit i * [PartitionedBloomFilter.SliceSize].
+// Modifying the returned Bits will not affect the internal state of the bloom filter.
+func (bf *PartitionedBloomFilter) Bits() Bits {
+	return bf.bits.Copy()
+}
+
+// index returns the bit index of the given hash value, inside the slice of the hash function with the given index.
+func (bf *PartitionedBloomFilter) index(slice int, hash uint64) uint64 {
+	return uint64(slice)*bf.sliceSize + hash%bf.sliceSize
+}
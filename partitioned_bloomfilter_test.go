@@ -0,0 +1,146 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PartitionedBloomFilter_Slices(t *testing.T) {
+	const items = 100
+	data := testutil.MoreBytes(items, 32)
+
+	bf, err := bloomfilters.NewPartitionedBloomFilter(
+		bloomfilters.WithSize(6*1024),
+		bloomfilters.WithDefaultHashFunctions(),
+	)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1024), bf.SliceSize())
+
+	for _, d := range data {
+		bf.Add(d)
+	}
+
+	// Every hash function sets exactly one bit per item in its own slice
+	var total uint64
+	for i, count := range bf.SliceBitsCount() {
+		assert.LessOrEqual(t, count, uint64(items), "Expected slice %d to have at most one bit per item", i)
+		assert.Greater(t, count, uint64(items*9/10), "Expected slice %d to have few collisions", i)
+		total += count
+	}
+	assert.Equal(t, bf.BitsCount(), total)
+}
+
+func Test_PartitionedBloomFilter_FalsePositiveRate(t *testing.T) {
+	const m, n = 8192, 1000
+	data := testutil.MoreBytes(2*n, 32)
+	added, other := data[:n], data[n:]
+	hashes := bloomhashes.DefaultHashFunctions()
+
+	bf, err := bloomfilters.NewPartitionedBloomFilter(
+		bloomfilters.WithSize(m),
+		bloomfilters.WithHashFunctions(hashes),
+	)
+	require.NoError(t, err)
+
+	for _, d := range added {
+		bf.Add(d)
+	}
+	for _, d := range added {
+		require.True(t, bf.Test(d), "Expected added data to be found in the filter")
+	}
+
+	falsePositives := 0
+	for _, d := range other {
+		if bf.Test(d) {
+			falsePositives++
+		}
+	}
+
+	expected := bloomsettings.PartitionedFalsePositiveRate(m, n, uint64(len(hashes)))
+	assert.InDelta(t, expected, float64(falsePositives)/float64(len(other)), 0.01, "Expected the measured false positive rate to match the predicted rate")
+}
+
+func Test_PartitionedBloomFilter_TooSmall(t *testing.T) {
+	// 64 bits can not be divided over 100 hash functions
+	hashes := make([]bloomhashes.HashFunction, 100)
+	for i := range hashes {
+		hashes[i] = bloomhashes.Fnv1_64
+	}
+
+	bf, err := bloomfilters.NewPartitionedBloomFilter(
+		bloomfilters.WithSize(64),
+		bloomfilters.WithHashFunctions(hashes),
+	)
+	require.ErrorIs(t, err, bloomfilters.ErrInvalidSize)
+	require.Nil(t, bf)
+}
+
+func Test_PartitionedBloomFilter_SetGetHash(t *testing.T) {
+	bf, err := bloomfilters.NewPartitionedBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.Fnv1_64a}),
+	)
+	require.NoError(t, err)
+
+	data := []byte("hash")
+	bf.Add(data)
+	assert.True(t, bf.GetHash(bloomhashes.Fnv1_64(data)))
+	assert.True(t, bf.GetHash(bloomhashes.Fnv1_64a(data)))
+
+	bf.SetHash(12345)
+	assert.True(t, bf.GetHash(12345))
+	assert.Equal(t, uint64(4), bf.BitsCount(), "Expected SetHash to set one bit in every slice")
+}
+
+// Fuzz test for PartitionedBloomFilter Add and Test
+func Fuzz_PartitionedBloomFilter_AddTest(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		bf, err := bloomfilters.NewPartitionedBloomFilter(
+			bloomfilters.WithSize(1024),
+			bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{
+				bloomhashes.Fnv1_64,
+				bloomhashes.MD5,
+			}),
+		)
+		require.NoError(t, err)
+
+		bf.Add(data)
+		require.True(t, bf.Test(data), "Expected added data to be found in the filter")
+	})
+}
+
+// Example of basic PartitionedBloomFilter usage
+func ExamplePartitionedBloomFilter() {
+	// 2 hash functions, so 2 slices of 512 bits
+	bf, _ := bloomfilters.NewPartitionedBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.Fnv1_64a}),
+	)
+
+	bf.Add([]byte("apple"))
+	bf.Add([]byte("banana"))
+
+	fmt.Println(bf.SliceSize())
+	fmt.Println(bf.SliceBitsCount())
+	fmt.Println(bf.Test([]byte("apple")))
+	fmt.Println(bf.Test([]byte("grape")))
+
+	// Output:
+	// 512
+	// [2 2]
+	// true
+	// false
+}
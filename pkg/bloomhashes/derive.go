@@ -0,0 +1,63 @@
+package bloomhashes
+
+// HashFunction128 defines the type for 128-bit hash functions, returning the hash as two 64-bit halves.
+// A single call provides the two hash values k hash values are derived from, see [Derivation].
+type HashFunction128 func(data []byte) (uint64, uint64)
+
+// Derivation is how hash value i is derived from the two halves h1 and h2 of a 128-bit hash, as described in
+// "Less Hashing, Same Performance: Building a Better Bloom Filter" by Kirsch and Mitzenmacher,
+// and "Bloom Filters in Probabilistic Verification" by Dillinger and Manolios.
+// The lowest bit of h2 is always set, so the first 64 hash values differ modulo any multiple of 64 bits.
+type Derivation uint8
+
+const (
+	// DoubleHashing derives g_i = h1 + i*h2.
+	DoubleHashing Derivation = iota
+	// EnhancedDoubleHashing derives g_i = h1 + i*h2 + (i^3 - i) / 6, so two items with the same g_0 and g_1 rarely share the other values.
+	EnhancedDoubleHashing
+	// TripleHashing derives g_i = h1 + i*h2 + i*(i - 1) / 2 * h3, with h3 mixed from h1 and h2.
+	TripleHashing
+)
+
+// Derive returns hash value i derived from the halves h1 and h2 of a 128-bit hash.
+func (d Derivation) Derive(h1, h2, i uint64) uint64 {
+	h2 |= 1
+	g := h1 + i*h2
+
+	switch d {
+	case EnhancedDoubleHashing:
+		// One of three consecutive numbers is divisible by 3, and one of the other two by 2
+		return g + (i-1)*i*(i+1)/6
+	case TripleHashing:
+		return g + i*(i-1)/2*mix64(h1^h2)
+	default:
+		return g
+	}
+}
+
+// DerivedHashFunctions returns k hash functions, hash function i returns hash value i derived from the 128-bit hash of the data.
+// Every hash function computes the 128-bit hash, so this is for code that only accepts hash functions.
+// The filters of this module compute it once for all of them when given the hash and derivation instead.
+func DerivedHashFunctions(h HashFunction128, derivation Derivation, k uint64) []HashFunction {
+	hashes := make([]HashFunction, k)
+	for i := range hashes {
+		hashes[i] = func(data []byte) uint64 {
+			h1, h2 := h(data)
+
+			return derivation.Derive(h1, h2, uint64(i))
+		}
+	}
+
+	return hashes
+}
+
+// mix64 is the 64-bit finalizer of MurmurHash3, every bit of the input affects every bit of the output.
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+
+	return x
+}
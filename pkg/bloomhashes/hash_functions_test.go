@@ -3,9 +3,12 @@ package bloomhashes_test
 import (
 	"crypto/sha1" // nolint:gosec // Used for testing, not security
 	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"hash/crc64"
 	"hash/fnv"
+	"strings"
 	"testing"
 
 	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
@@ -161,6 +164,28 @@ func Test_Sha512(t *testing.T) {
 	assert.NotZero(t, result, "Hash should not be zero")
 }
 
+// Test XXHash64 hash function against the reference test vectors
+func Test_XXHash64(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"hello", 0x26c7827d889f6da3},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+		{"0123456789abcdef0123456789abcdef0123456789", 0xa76190c3acf08a1c},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.data, func(t *testing.T) {
+			result := bloomhashes.XXHash64([]byte(tc.data))
+			assert.Equal(t, tc.expected, result, "Hash should match the xxHash64 reference output")
+		})
+	}
+}
+
 // Test that hash functions produce consistent results
 func Test_HashFunctions_Consistency(t *testing.T) {
 	testCases := []struct {
@@ -176,6 +201,8 @@ func Test_HashFunctions_Consistency(t *testing.T) {
 		{"MD5", bloomhashes.MD5},
 		{"SHA1", bloomhashes.Sha1},
 		{"SHA256", bloomhashes.Sha256},
+		{"XXHash64", bloomhashes.XXHash64},
+		{"XXH3_64", bloomhashes.XXH3_64},
 	}
 
 	for _, tc := range testCases {
@@ -309,6 +336,27 @@ func Fuzz_HashFunctions(f *testing.F) {
 	})
 }
 
+// Fuzz test for the seeded xxHash functions, which must not panic on any length and must match the unseeded functions for a seed of 0
+func Fuzz_XXH3(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"), uint64(0))
+	f.Add([]byte("hello world"), uint64(1))
+	f.Add([]byte(""), uint64(0x9e3779b185ebca8d))
+	f.Add([]byte("a"), uint64(0xffffffffffffffff))
+
+	f.Fuzz(func(t *testing.T, data []byte, seed uint64) {
+		require.Equal(t, bloomhashes.XXH3_64Seed(seed)(data), bloomhashes.XXH3_64Seed(seed)(data))
+		low, high := bloomhashes.XXH3_128Seed(seed)(data)
+		low2, high2 := bloomhashes.XXH3_128Seed(seed)(data)
+		require.Equal(t, low, low2)
+		require.Equal(t, high, high2)
+		_ = bloomhashes.XXHash64Seed(seed)(data)
+
+		require.Equal(t, bloomhashes.XXH3_64(data), bloomhashes.XXH3_64Seed(0)(data))
+		require.Equal(t, bloomhashes.XXHash64(data), bloomhashes.XXHash64Seed(0)(data))
+	})
+}
+
 // Example of using FNV hash function
 func Example_fnv1_64() {
 	data := []byte("example data")
@@ -344,3 +392,250 @@ func Example_wrapHasher64() {
 	// Output:
 	// Hash value is non-zero: true
 }
+
+// Test SipHash24 hash function against the reference test vectors, with the key 00 01 02 ... 0f and the message 00 01 02 ... of the given length
+func Test_SipHash24(t *testing.T) {
+	var key [16]byte
+	for i := range key {
+		key[i] = byte(i)
+	}
+	hash := bloomhashes.SipHash24(key)
+
+	testCases := []struct {
+		length   int
+		expected uint64
+	}{
+		{0, 0x726fdb47dd0e0e31},
+		{1, 0x74f839c593dc67fd},
+		{8, 0x93f5f5799a932462},
+		{15, 0xa129ca6149be45e5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprint(tc.length), func(t *testing.T) {
+			data := make([]byte, tc.length)
+			for i := range data {
+				data[i] = byte(i)
+			}
+			assert.Equal(t, tc.expected, hash(data), "Hash should match the SipHash-2-4 reference output")
+		})
+	}
+}
+
+// Test Murmur3_32 hash function against the reference test vectors, including the ones of Bitcoin Core
+func Test_Murmur3_32(t *testing.T) {
+	testCases := []struct {
+		seed     uint32
+		data     string
+		expected uint64
+	}{
+		{0x00000000, "", 0x00000000},
+		{0x00000001, "", 0x514e28b7},
+		{0xfba4c795, "", 0x6a396f08},
+		{0xffffffff, "", 0x81f16f39},
+		{0x00000000, "\x00\x00\x00\x00", 0x2362f9de},
+		{0x9747b28c, "aaaa", 0x5a97808a},
+		{0x9747b28c, "Hello, world!", 0x24884cba},
+		{0x9747b28c, "The quick brown fox jumps over the lazy dog", 0x2fa826cd},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%08x %q", tc.seed, tc.data), func(t *testing.T) {
+			result := bloomhashes.Murmur3_32(tc.seed)([]byte(tc.data))
+			assert.Equal(t, tc.expected, result, "Hash should match the MurmurHash3 reference output")
+		})
+	}
+}
+
+// Test Keccak256Sum against the reference test vectors, these are the legacy Keccak hashes Ethereum uses and not SHA3-256
+func Test_Keccak256(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected string
+	}{
+		{"", "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"},
+		{"abc", "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45"},
+		{strings.Repeat("a", 135), "34367dc248bbd832f4e3e69dfaac2f92638bd0bbd18f2912ba4ef454919cf446"},
+		{strings.Repeat("a", 136), "a6c4d403279fe3e0af03729caada8374b5ca54d8065329a3ebcaeb4b60aa386e"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprint(len(tc.data)), func(t *testing.T) {
+			sum := bloomhashes.Keccak256Sum([]byte(tc.data))
+			assert.Equal(t, tc.expected, hex.EncodeToString(sum[:]), "Hash should match the Keccak-256 reference output")
+			assert.Equal(t, binary.LittleEndian.Uint64(sum[:]), bloomhashes.Keccak256([]byte(tc.data)))
+		})
+	}
+}
+
+func Test_Fnv1_128aSum(t *testing.T) {
+	for _, data := range []string{"", "a", "hello world"} {
+		h1, h2 := bloomhashes.Fnv1_128aSum([]byte(data))
+		assert.Equal(t, bloomhashes.Fnv1_128a([]byte(data)), h1)
+
+		w1, w2 := bloomhashes.WrapHasher128(fnv.New128a)([]byte(data))
+		assert.Equal(t, h1, w1)
+		assert.Equal(t, h2, w2)
+	}
+
+	// FNV-1a 128 of the empty string is its offset basis 6c62272e07bb0142 62b821756295c58d, in big-endian bytes
+	h1, h2 := bloomhashes.Fnv1_128aSum(nil)
+	assert.Equal(t, uint64(0x4201bb072e27626c), h1)
+	assert.Equal(t, uint64(0x8dc595627521b862), h2)
+}
+
+// Test the closed forms of the derivations against the incremental forms of Dillinger and Manolios
+func Test_Derivation(t *testing.T) {
+	h1, h2 := uint64(0x0123456789abcdef), uint64(0xfedcba9876543210)
+	h3 := bloomhashes.TripleHashing.Derive(h1, h2, 2) - 2*bloomhashes.DoubleHashing.Derive(h1, h2, 1) + h1
+
+	double, enhanced, triple := h1, h1, h1
+	doubleStep, enhancedStep, tripleStep := h2|1, h2|1, h2|1
+	for i := range uint64(100) {
+		require.Equal(t, double, bloomhashes.DoubleHashing.Derive(h1, h2, i), "double hashing %d", i)
+		require.Equal(t, enhanced, bloomhashes.EnhancedDoubleHashing.Derive(h1, h2, i), "enhanced double hashing %d", i)
+		require.Equal(t, triple, bloomhashes.TripleHashing.Derive(h1, h2, i), "triple hashing %d", i)
+
+		double += doubleStep
+		enhanced += enhancedStep
+		enhancedStep += i + 1
+		triple += tripleStep
+		tripleStep += h3
+	}
+}
+
+func Test_Derivation_DistinctIndexes(t *testing.T) {
+	// An even h2 is made odd, so 64 hash values never share a bit of a 64-bit filter
+	for _, derivation := range []bloomhashes.Derivation{bloomhashes.DoubleHashing, bloomhashes.EnhancedDoubleHashing, bloomhashes.TripleHashing} {
+		seen := map[uint64]struct{}{}
+		for i := range uint64(64) {
+			seen[derivation.Derive(3, 0, i)%64] = struct{}{}
+		}
+		if derivation == bloomhashes.DoubleHashing {
+			assert.Len(t, seen, 64)
+		} else {
+			assert.Greater(t, len(seen), 32)
+		}
+	}
+}
+
+func Test_DerivedHashFunctions(t *testing.T) {
+	hashes := bloomhashes.DerivedHashFunctions(bloomhashes.Fnv1_128aSum, bloomhashes.EnhancedDoubleHashing, 12)
+	require.Len(t, hashes, 12)
+
+	h1, h2 := bloomhashes.Fnv1_128aSum([]byte("test"))
+	for i, hashFunc := range hashes {
+		assert.Equal(t, bloomhashes.EnhancedDoubleHashing.Derive(h1, h2, uint64(i)), hashFunc([]byte("test")))
+	}
+}
+
+// xxhSanityBuffer returns the input of the sanity checks of the xxHash reference implementation, see xsum_sanity_check.c
+func xxhSanityBuffer(length int) []byte {
+	buffer := make([]byte, length)
+	byteGen := uint64(2654435761)
+	for i := range buffer {
+		buffer[i] = byte(byteGen >> 56)
+		byteGen *= 11400714785074694797
+	}
+
+	return buffer
+}
+
+// Test xxHash64, XXH3-64 and XXH3-128 against the xxHash 0.8.2 reference implementation, for every length class of XXH3
+func Test_XXHash_ReferenceVectors(t *testing.T) {
+	testCases := []struct {
+		length  int
+		seed    uint64
+		xxh64   uint64
+		xxh3    uint64
+		xxh128l uint64
+		xxh128h uint64
+	}{
+		{0, 0x0, 0xef46db3751d8e999, 0x2d06800538d394c2, 0x6001c324468d497f, 0x99aa06d3014798d8},
+		{1, 0x0, 0xe934a84adb052768, 0xc44bdff4074eecdb, 0xc44bdff4074eecdb, 0xa6cd5e9392000f6a},
+		{2, 0x0, 0x5d48cd60a77e23ff, 0x7a9978044cb8a8bb, 0x7a9978044cb8a8bb, 0x76750c3c7bf95668},
+		{3, 0x0, 0xff7e1959cb50794a, 0x54247382a8d6b94d, 0x54247382a8d6b94d, 0x20efc49ff02422ea},
+		{4, 0x0, 0x9136a0dca57457ee, 0xe5dc74bc51848a51, 0x2e7d8d6876a39fe9, 0x970d585ac632bf8e},
+		{5, 0x0, 0x9b046fb1397f09a5, 0xe4243f00720306bb, 0x057c7ed2c01fa1d1, 0x62ed587687606b4e},
+		{8, 0x0, 0xcdbcf538e71d1348, 0x24ccc9acaa9f65e4, 0x64c69cab4bb21dc5, 0x47a7f080d82bb456},
+		{9, 0x0, 0x554b1ae991eda6b6, 0x14d5001c15dd3f2b, 0xed7ccbc501eb7501, 0x564ef6078950d457},
+		{12, 0x0, 0x0723bf50086ead9a, 0xa713daf0dfbb77e7, 0x061a192713f69ad9, 0x6e3efd8fc7802b18},
+		{16, 0x0, 0x98c90b57fdfcb55c, 0x981b17d36c7498c9, 0x562980258a998629, 0xc68c368ecf8a9c05},
+		{17, 0x0, 0x0d39a2d051a30c2c, 0x796f5acd3a60f862, 0xabbc12d11973d7db, 0x955fa78643ed3669},
+		{32, 0x0, 0x18b216492bb44b70, 0x9feaddbdbf57eed3, 0x278410a17595e3f9, 0x98fc6458710dc2e8},
+		{33, 0x0, 0x55c8dc3e578f5b59, 0xabfb2d081b400a10, 0xe593bc4e5914c9d1, 0x3103c192ceaa2ded},
+		{64, 0x0, 0xef558f8acac2b5cd, 0x9cb48487720ec49d, 0xefdb6a44690721a9, 0x6d90e81a9b0fd622},
+		{65, 0x0, 0xde0f20dc2631af7a, 0xfd81aac4bebc3883, 0xfe2f650fa500ec6e, 0x6c074d65e54db85a},
+		{96, 0x0, 0x105064e743edd1d9, 0x935a769a7f94776f, 0xe9324473ea9afebe, 0xd9d0b885f56c93f1},
+		{97, 0x0, 0x097b16e4e9b0a2e3, 0xca4ca268fd3c3a6c, 0x7c87228ae9671ba7, 0x09dff37faa6b284c},
+		{128, 0x0, 0x90ca021457d96dc5, 0xfcff24126754d861, 0xebb15e34a7fb5ab1, 0x39992220e045260a},
+		{129, 0x0, 0x41c280132d697aba, 0x98f1b0a679a2ca29, 0x86c9e3bc8f0a3b5c, 0x03815fc91f1b30b6},
+		{160, 0x0, 0x5b11efe8de350a3b, 0x9d03a319ed4cbd2b, 0x737126c8d7c09cee, 0xba5d218964b622ad},
+		{192, 0x0, 0xb76a434ac1e4768b, 0xaf9f58e78b8d3587, 0x0679e7f625e389d9, 0x064934db40706c3d},
+		{240, 0x0, 0xb81838d483baee53, 0x81c3c2b67f568ccf, 0x5c9aae94c8ebe5a0, 0xaa4202daa2769dc8},
+		{241, 0x0, 0x95d76c8b4d8fc4d6, 0xc5a639ecd2030e5e, 0xc5a639ecd2030e5e, 0x99a80ecf0ecfc647},
+		{255, 0x0, 0xa80f35bb0dc8e3a7, 0xe98f979f4ed8a197, 0xe98f979f4ed8a197, 0x961375c87e09efbc},
+		{256, 0x0, 0x5e3f5bf94d574981, 0x55de574ad89d0ac5, 0x55de574ad89d0ac5, 0x8b1c66091423d288},
+		{512, 0x0, 0x4358d2fdd62b58a7, 0x617e49599013cb6b, 0x617e49599013cb6b, 0x18d2d110dcc9bca1},
+		{1023, 0x0, 0xaac72718b7620924, 0x87a8f7b2f2e22496, 0x87a8f7b2f2e22496, 0xe8083e4d83214c3c},
+		{1024, 0x0, 0x4775bf7cace4d177, 0xdd85c9b5c1109c5c, 0xdd85c9b5c1109c5c, 0x0d30d24071c64c57},
+		{1025, 0x0, 0x847fa6006d7c2ac0, 0xd870c0fa13211c6a, 0xd870c0fa13211c6a, 0xfd3ee4fe7f2954c6},
+		{2048, 0x0, 0x5940f2752bc04387, 0xdd59e2c3a5f038e0, 0xdd59e2c3a5f038e0, 0xf736557fd47073a5},
+		{2240, 0x0, 0xa4edb3c85b99b1d9, 0x6e73a90539cf2948, 0x6e73a90539cf2948, 0xccb134fbfa7ce49d},
+		{2367, 0x0, 0xa82418ddec0ea581, 0xcb37aeb9e5d361ed, 0xcb37aeb9e5d361ed, 0xe89c0f6ff369b427},
+		{4096, 0x0, 0xab77f4af85f4e70b, 0xe91206429d1f48f9, 0xe91206429d1f48f9, 0xb9cfaea2ca5626a4},
+		{0, 0x9e3779b185ebca8d, 0x0b303d920ec349df, 0xa8a6b918b2f0364a, 0xa986dfc5d7605bfe, 0x00feaa732a3ce25e},
+		{1, 0x9e3779b185ebca8d, 0x9c6678669fcd2e6d, 0x032be332dd766ef8, 0x032be332dd766ef8, 0x20e49abcc53b3842},
+		{2, 0x9e3779b185ebca8d, 0x8469cbf08335c09c, 0x764b35c90519ad88, 0x764b35c90519ad88, 0x7b96e6a600dae67d},
+		{3, 0x9e3779b185ebca8d, 0x281b7cbb86cc6a05, 0x634b8990b4976373, 0x634b8990b4976373, 0x1c7ecf6a308cf00e},
+		{4, 0x9e3779b185ebca8d, 0xccfe4ead7e01983c, 0xaa2e7eccb0c8f747, 0xbfaf51f1e67e0b0f, 0x3d53e5dfd837d927},
+		{5, 0x9e3779b185ebca8d, 0x9099058d286ef837, 0x5a67c87e50ed80ed, 0x67a0c170d32090d7, 0xfac738e8fec37715},
+		{8, 0x9e3779b185ebca8d, 0x768161b4e5a58dfa, 0x8f973410999b8f6b, 0x7b29471dc729b5ff, 0xf50cec145bcd5c5a},
+		{9, 0x9e3779b185ebca8d, 0x6a7ef24927b938a0, 0xb3ae7333d9013f60, 0xaef5dfc0ac9f9044, 0x6b380b43ffa61042},
+		{12, 0x9e3779b185ebca8d, 0x0f2c37856fc19ed9, 0xe7303e1b2336de0e, 0x5d92b5d7190b12d1, 0xff0d60acd02ed401},
+		{16, 0x9e3779b185ebca8d, 0x85446bba49cb7df1, 0x663f29333b4db6b1, 0x0346d13a7a5498c7, 0x6ffcb80cd33085c8},
+		{17, 0x9e3779b185ebca8d, 0x1dd902d73122eda0, 0xf3ec5067f4306db3, 0x980a14119985a7df, 0xd77681219e464828},
+		{32, 0x9e3779b185ebca8d, 0x21d817283f4b6283, 0x2199fab1534893d9, 0x0054e82631cef166, 0xcc587e4fcdb86bc5},
+		{33, 0x9e3779b185ebca8d, 0xb09782549294df85, 0xad56348da574bb6d, 0xc361d36cea597c31, 0x21273c8190c645cd},
+		{64, 0x9e3779b185ebca8d, 0xf90d26fed8023d61, 0x4fe8895db9b8c077, 0x9405ba2affa95ceb, 0x37b738968d40bda5},
+		{65, 0x9e3779b185ebca8d, 0x7814174cd6405bee, 0xad80aeec1fc9e0a7, 0x9d60c345e5c297cd, 0x72503a6fa8d07adb},
+		{96, 0x9e3779b185ebca8d, 0x12cdf1480c876275, 0x70cf51937e500540, 0xd61f3ab58705c405, 0x6f9ed3c2008cb388},
+		{97, 0x9e3779b185ebca8d, 0xee752f0a58b68c2e, 0xee461d3add7ee6c9, 0x49ea87f2afe44f66, 0x14e68f850b481ada},
+		{128, 0x9e3779b185ebca8d, 0xfcef9beb2ce440a6, 0x73fde75280646649, 0x8394f5c51f1d8246, 0xa0f7ccb68ee02add},
+		{129, 0x9e3779b185ebca8d, 0xaeb872c374eabf84, 0x21fffdbca099c844, 0xd4aae26fcec7dc03, 0xad559266067c0bf3},
+		{160, 0x9e3779b185ebca8d, 0x0184fea66213ac0a, 0x3825c75ffe70fde0, 0x46a4a3f67ccd556e, 0xc6b7abc26def52ac},
+		{192, 0x9e3779b185ebca8d, 0x1fcaa96d78066ef4, 0x69e006aa2156c999, 0xfd5412027e573a96, 0xc1e549baf8d0d863},
+		{240, 0x9e3779b185ebca8d, 0x7c3c8490fe0c1b94, 0xcc0f58c27ef3d8ee, 0x604e98db085c1864, 0x29d2133d6ea58c5b},
+		{241, 0x9e3779b185ebca8d, 0x6bd0db4ef4123409, 0xdda9b0a161d4829a, 0xdda9b0a161d4829a, 0xec64afae6a137582},
+		{255, 0x9e3779b185ebca8d, 0x03d699d52e8cd292, 0x2aca7901d9538c75, 0x2aca7901d9538c75, 0xe72ec0137d62df44},
+		{256, 0x9e3779b185ebca8d, 0xa1cbbc0da72934fe, 0x4d30234b7a3aa61c, 0x4d30234b7a3aa61c, 0xaaa57235b92d5e7c},
+		{512, 0x9e3779b185ebca8d, 0x6e1a3c5263d2def2, 0x3ce457de14c27708, 0x3ce457de14c27708, 0x925d06b8ec5b8040},
+		{1023, 0x9e3779b185ebca8d, 0xa471caee31ba9f8a, 0x0f0f02de8590e1b5, 0x0f0f02de8590e1b5, 0x96b80fe329ce5e35},
+		{1024, 0x9e3779b185ebca8d, 0xcfbc5e785ff33ccd, 0xef368a8a2ebabaef, 0xef368a8a2ebabaef, 0x17600efe2b493a18},
+		{1025, 0x9e3779b185ebca8d, 0x880172cbae03711f, 0x96792bcf9af88519, 0x96792bcf9af88519, 0x2c383949f57bf7e1},
+		{2048, 0x9e3779b185ebca8d, 0x896b632400e68878, 0x66f81670669ababc, 0x66f81670669ababc, 0x23cc3a2e75ebaaea},
+		{2240, 0x9e3779b185ebca8d, 0x9c6df05f3bb8d2c9, 0x757ba8487d1b5247, 0x757ba8487d1b5247, 0xe40842f585875ba9},
+		{2367, 0x9e3779b185ebca8d, 0x363b532c35e01e25, 0xd2db3415b942b42a, 0xd2db3415b942b42a, 0xccb7a94cca1a6496},
+		{4096, 0x9e3779b185ebca8d, 0x7b950d3ad86dcd2c, 0x2a3bbb20a5439dcd, 0x2a3bbb20a5439dcd, 0x8fbc8fd4d526d1bd},
+	}
+
+	buffer := xxhSanityBuffer(4096)
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%d/%x", tc.length, tc.seed), func(t *testing.T) {
+			data := buffer[:tc.length]
+			assert.Equal(t, tc.xxh64, bloomhashes.XXHash64Seed(tc.seed)(data), "xxHash64")
+			assert.Equal(t, tc.xxh3, bloomhashes.XXH3_64Seed(tc.seed)(data), "XXH3-64")
+			low, high := bloomhashes.XXH3_128Seed(tc.seed)(data)
+			assert.Equal(t, tc.xxh128l, low, "XXH3-128 low")
+			assert.Equal(t, tc.xxh128h, high, "XXH3-128 high")
+
+			if tc.seed == 0 {
+				assert.Equal(t, tc.xxh64, bloomhashes.XXHash64(data))
+				assert.Equal(t, tc.xxh3, bloomhashes.XXH3_64(data))
+				low, high := bloomhashes.XXH3_128(data)
+				assert.Equal(t, tc.xxh128l, low)
+				assert.Equal(t, tc.xxh128h, high)
+			}
+		})
+	}
+}
@@ -45,3 +45,13 @@ func Fnv1_128a(data []byte) uint64 {
 
 	return bytesToUint64(sum)
 }
+
+// Fnv1_128aSum computes a FNV-1a 128-bit hash, for deriving hash values with a [Derivation].
+// It returns the first and the last 8 bytes of the hash as 64-bit values, the first is the same as [Fnv1_128a].
+func Fnv1_128aSum(data []byte) (uint64, uint64) {
+	hasher := fnv.New128a()
+	_, _ = hasher.Write(data)
+	sum := hasher.Sum(nil)
+
+	return bytesToUint64(sum), bytesToUint64(sum[8:])
+}
@@ -0,0 +1,97 @@
+package bloomhashes
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// keccak256Rate is the amount of bytes absorbed per permutation by Keccak-256, 1600 bits minus twice the 256 bit output.
+const keccak256Rate = 136
+
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// keccakRotations and keccakLanes are the rho rotations and the pi lane order, following the lane that moves into lane 1.
+var (
+	keccakRotations = [24]int{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+	keccakLanes     = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+)
+
+// Keccak256 computes a Keccak-256 hash and converts it to a uint64.
+// It returns the first 8 bytes of the hash as a little-endian 64-bit value, so the leading bytes Ethereum blooms are built from are kept as is.
+func Keccak256(data []byte) uint64 {
+	b := Keccak256Sum(data)
+
+	return bytesToUint64(b[:])
+}
+
+// Keccak256Sum computes the Keccak-256 hash of the data, the hash Ethereum uses everywhere.
+// This is the original Keccak submission with its 0x01 padding, it differs from SHA3-256 which pads with 0x06.
+func Keccak256Sum(data []byte) [32]byte {
+	var state [25]uint64
+	for len(data) >= keccak256Rate {
+		keccakAbsorb(&state, data[:keccak256Rate])
+		data = data[keccak256Rate:]
+	}
+
+	var last [keccak256Rate]byte
+	copy(last[:], data)
+	last[len(data)] ^= 0x01
+	last[keccak256Rate-1] ^= 0x80
+	keccakAbsorb(&state, last[:])
+
+	var sum [32]byte
+	for i := range 4 {
+		binary.LittleEndian.PutUint64(sum[8*i:], state[i])
+	}
+
+	return sum
+}
+
+// keccakAbsorb xors a block of rate bytes into the state as little-endian lanes, and permutes the state.
+func keccakAbsorb(state *[25]uint64, block []byte) {
+	for i := range keccak256Rate / 8 {
+		state[i] ^= binary.LittleEndian.Uint64(block[8*i:])
+	}
+	keccakF1600(state)
+}
+
+// keccakF1600 is the Keccak-f[1600] permutation, as specified by https://keccak.team/keccak_specs_summary.html
+func keccakF1600(state *[25]uint64) {
+	var c [5]uint64
+	for _, rc := range keccakRoundConstants {
+		// Theta, xor every lane with the parity of two neighbouring columns
+		for x := range 5 {
+			c[x] = state[x] ^ state[x+5] ^ state[x+10] ^ state[x+15] ^ state[x+20]
+		}
+		for x := range 5 {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				state[y+x] ^= d
+			}
+		}
+
+		// Rho and pi, rotate every lane and move it to its new position
+		lane := state[1]
+		for i, to := range keccakLanes {
+			lane, state[to] = state[to], bits.RotateLeft64(lane, keccakRotations[i])
+		}
+
+		// Chi, the only non-linear step, per row
+		for y := 0; y < 25; y += 5 {
+			copy(c[:], state[y:y+5])
+			for x := range 5 {
+				state[y+x] = c[x] ^ (^c[(x+1)%5] & c[(x+2)%5])
+			}
+		}
+
+		// Iota
+		state[0] ^= rc
+	}
+}
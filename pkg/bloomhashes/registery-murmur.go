@@ -0,0 +1,56 @@
+package bloomhashes
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	murmur3C1 uint32 = 0xcc9e2d51
+	murmur3C2 uint32 = 0x1b873593
+)
+
+// Murmur3_32 returns a HashFunction that computes a 32 bit MurmurHash3 (x86_32) hash with the given seed, in the low 32 bits of the result.
+// This is the hash used by Bitcoin BIP37 bloom filters, with a different seed per hash function.
+func Murmur3_32(seed uint32) HashFunction {
+	return func(data []byte) uint64 {
+		return uint64(murmur3_32(seed, data))
+	}
+}
+
+// murmur3_32 is a pure Go implementation of MurmurHash3_x86_32, as specified by https://github.com/aappleby/smhasher/blob/master/src/MurmurHash3.cpp
+func murmur3_32(seed uint32, data []byte) uint32 {
+	h := seed
+	length := uint32(len(data))
+
+	for len(data) >= 4 {
+		h ^= murmur3Mix(binary.LittleEndian.Uint32(data))
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+		data = data[4:]
+	}
+
+	var k uint32
+	for i, b := range data {
+		k |= uint32(b) << (8 * i)
+	}
+	if len(data) > 0 {
+		h ^= murmur3Mix(k)
+	}
+
+	h ^= length
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+
+	return h
+}
+
+func murmur3Mix(k uint32) uint32 {
+	k *= murmur3C1
+	k = bits.RotateLeft32(k, 15)
+
+	return k * murmur3C2
+}
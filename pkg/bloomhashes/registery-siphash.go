@@ -0,0 +1,72 @@
+package bloomhashes
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// SipHash24 returns a HashFunction that computes a SipHash-2-4 hash keyed with the given 128 bit key.
+// The key is read as two little endian words, like BIP158 reads the first 16 bytes of a block hash.
+// Unlike the other hash functions, an attacker that doesn't know the key can't craft inputs that collide.
+func SipHash24(key [16]byte) HashFunction {
+	k0 := binary.LittleEndian.Uint64(key[0:])
+	k1 := binary.LittleEndian.Uint64(key[8:])
+
+	return func(data []byte) uint64 {
+		return sipHash24(k0, k1, data)
+	}
+}
+
+// sipHash24 is a pure Go implementation of SipHash-2-4, as specified by https://cr.yp.to/siphash/siphash-20120918.pdf
+func sipHash24(k0, k1 uint64, data []byte) uint64 {
+	v0 := k0 ^ 0x736f6d6570736575
+	v1 := k1 ^ 0x646f72616e646f6d
+	v2 := k0 ^ 0x6c7967656e657261
+	v3 := k1 ^ 0x7465646279746573
+
+	length := len(data)
+	for len(data) >= 8 {
+		m := binary.LittleEndian.Uint64(data)
+		v3 ^= m
+		v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+		v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+		v0 ^= m
+		data = data[8:]
+	}
+
+	// The last word holds the remaining bytes, and the length in its top byte
+	m := uint64(length) << 56
+	for i, b := range data {
+		m |= uint64(b) << (8 * i)
+	}
+	v3 ^= m
+	v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+	v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+	v0 ^= m
+
+	v2 ^= 0xff
+	for range 4 {
+		v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+	}
+
+	return v0 ^ v1 ^ v2 ^ v3
+}
+
+func sipRound(v0, v1, v2, v3 uint64) (uint64, uint64, uint64, uint64) {
+	v0 += v1
+	v1 = bits.RotateLeft64(v1, 13)
+	v1 ^= v0
+	v0 = bits.RotateLeft64(v0, 32)
+	v2 += v3
+	v3 = bits.RotateLeft64(v3, 16)
+	v3 ^= v2
+	v0 += v3
+	v3 = bits.RotateLeft64(v3, 21)
+	v3 ^= v0
+	v2 += v1
+	v1 = bits.RotateLeft64(v1, 17)
+	v1 ^= v2
+	v2 = bits.RotateLeft64(v2, 32)
+
+	return v0, v1, v2, v3
+}
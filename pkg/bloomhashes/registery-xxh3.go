@@ -0,0 +1,347 @@
+package bloomhashes
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	xxPrime32_1 uint64 = 0x9e3779b1
+	xxPrime32_2 uint64 = 0x85ebca77
+	xxPrime32_3 uint64 = 0xc2b2ae3d
+	xxPrimeMx1  uint64 = 0x165667919e3779f9
+	xxPrimeMx2  uint64 = 0x9fb21c651e98df25
+
+	// xxh3StripeLen is the amount of input bytes consumed per stripe by the long hash, and xxh3SecretConsumeRate the secret bytes skipped per stripe.
+	xxh3StripeLen         = 64
+	xxh3SecretConsumeRate = 8
+	// xxh3StripesPerBlock is the amount of stripes accumulated before the accumulators are scrambled, for the 192 byte secret.
+	xxh3StripesPerBlock = (len(xxh3Secret) - xxh3StripeLen) / xxh3SecretConsumeRate
+	xxh3BlockLen        = xxh3StripeLen * xxh3StripesPerBlock
+	// Offsets into the secret of the last rounds of the mid-size and long hashes
+	xxh3MidSizeStartOffset = 3
+	xxh3MidSizeLastOffset  = 136 - 17
+	xxh3LastStripeOffset   = len(xxh3Secret) - xxh3StripeLen - 7
+	xxh3MergeAccsStart     = 11
+)
+
+// xxh3Secret is the default secret of XXH3, it is combined with the seed for inputs up to 240 bytes, and replaced by a secret derived from the seed for longer inputs.
+var xxh3Secret = [192]byte{
+	0xb8, 0xfe, 0x6c, 0x39, 0x23, 0xa4, 0x4b, 0xbe, 0x7c, 0x01, 0x81, 0x2c, 0xf7, 0x21, 0xad, 0x1c,
+	0xde, 0xd4, 0x6d, 0xe9, 0x83, 0x90, 0x97, 0xdb, 0x72, 0x40, 0xa4, 0xa4, 0xb7, 0xb3, 0x67, 0x1f,
+	0xcb, 0x79, 0xe6, 0x4e, 0xcc, 0xc0, 0xe5, 0x78, 0x82, 0x5a, 0xd0, 0x7d, 0xcc, 0xff, 0x72, 0x21,
+	0xb8, 0x08, 0x46, 0x74, 0xf7, 0x43, 0x24, 0x8e, 0xe0, 0x35, 0x90, 0xe6, 0x81, 0x3a, 0x26, 0x4c,
+	0x3c, 0x28, 0x52, 0xbb, 0x91, 0xc3, 0x00, 0xcb, 0x88, 0xd0, 0x65, 0x8b, 0x1b, 0x53, 0x2e, 0xa3,
+	0x71, 0x64, 0x48, 0x97, 0xa2, 0x0d, 0xf9, 0x4e, 0x38, 0x19, 0xef, 0x46, 0xa9, 0xde, 0xac, 0xd8,
+	0xa8, 0xfa, 0x76, 0x3f, 0xe3, 0x9c, 0x34, 0x3f, 0xf9, 0xdc, 0xbb, 0xc7, 0xc7, 0x0b, 0x4f, 0x1d,
+	0x8a, 0x51, 0xe0, 0x4b, 0xcd, 0xb4, 0x59, 0x31, 0xc8, 0x9f, 0x7e, 0xc9, 0xd9, 0x78, 0x73, 0x64,
+	0xea, 0xc5, 0xac, 0x83, 0x34, 0xd3, 0xeb, 0xc3, 0xc5, 0x81, 0xa0, 0xff, 0xfa, 0x13, 0x63, 0xeb,
+	0x17, 0x0d, 0xdd, 0x51, 0xb7, 0xf0, 0xda, 0x49, 0xd3, 0x16, 0x55, 0x26, 0x29, 0xd4, 0x68, 0x9e,
+	0x2b, 0x16, 0xbe, 0x58, 0x7d, 0x47, 0xa1, 0xfc, 0x8f, 0xf8, 0xb8, 0xd1, 0x7a, 0xd0, 0x31, 0xce,
+	0x45, 0xcb, 0x3a, 0x8f, 0x95, 0x16, 0x04, 0x28, 0xaf, 0xd7, 0xfb, 0xca, 0xbb, 0x4b, 0x40, 0x7e,
+}
+
+// XXH3_64 computes a 64-bit XXH3 hash with a seed of 0.
+// XXH3 is the fastest hash in this package for short keys, without SIMD xxHash64 is faster for keys longer than 128 bytes.
+func XXH3_64(data []byte) uint64 {
+	return xxh3_64(data, 0, &xxh3Secret)
+}
+
+// XXH3_64Seed returns a HashFunction that computes a 64-bit XXH3 hash with the given seed.
+// The secret for inputs longer than 240 bytes is derived from the seed once, not per call.
+func XXH3_64Seed(seed uint64) HashFunction {
+	secret := xxh3SeedSecret(seed)
+
+	return func(data []byte) uint64 {
+		return xxh3_64(data, seed, secret)
+	}
+}
+
+// XXH3_128 computes a 128-bit XXH3 hash with a seed of 0, it returns the low and the high 64 bits of the hash.
+// It is a HashFunction128, for deriving hash values with a [Derivation].
+func XXH3_128(data []byte) (uint64, uint64) {
+	return xxh3_128(data, 0, &xxh3Secret)
+}
+
+// XXH3_128Seed returns a HashFunction128 that computes a 128-bit XXH3 hash with the given seed, see [XXH3_128].
+func XXH3_128Seed(seed uint64) HashFunction128 {
+	secret := xxh3SeedSecret(seed)
+
+	return func(data []byte) (uint64, uint64) {
+		return xxh3_128(data, seed, secret)
+	}
+}
+
+// xxh3SeedSecret derives the secret of the long hash from the seed, by adding the seed to the even and subtracting it from the odd 64-bit words of the default secret.
+func xxh3SeedSecret(seed uint64) *[192]byte {
+	if seed == 0 {
+		return &xxh3Secret
+	}
+
+	secret := new([192]byte)
+	for i := 0; i < len(secret); i += 16 {
+		binary.LittleEndian.PutUint64(secret[i:], binary.LittleEndian.Uint64(xxh3Secret[i:])+seed)
+		binary.LittleEndian.PutUint64(secret[i+8:], binary.LittleEndian.Uint64(xxh3Secret[i+8:])-seed)
+	}
+
+	return secret
+}
+
+// xxh3_64 is a pure Go implementation of the 64-bit XXH3 algorithm, as specified by https://github.com/Cyan4973/xxHash/blob/dev/doc/xxhash_spec.md
+// The secret is only used for inputs longer than 240 bytes, it must be derived from the seed.
+func xxh3_64(data []byte, seed uint64, secret *[192]byte) uint64 {
+	length := uint64(len(data))
+	s := xxh3Secret[:]
+
+	switch {
+	case length == 0:
+		return xxAvalanche64(seed ^ readLE64(s, 56) ^ readLE64(s, 64))
+	case length <= 3:
+		combined := uint64(data[0])<<16 | uint64(data[length>>1])<<24 | uint64(data[length-1]) | length<<8
+		bitflip := uint64(binary.LittleEndian.Uint32(s)^binary.LittleEndian.Uint32(s[4:])) + seed
+
+		return xxAvalanche64(combined ^ bitflip)
+	case length <= 8:
+		seed ^= uint64(bits.ReverseBytes32(uint32(seed))) << 32
+		input := uint64(binary.LittleEndian.Uint32(data[length-4:])) + uint64(binary.LittleEndian.Uint32(data))<<32
+		bitflip := (readLE64(s, 8) ^ readLE64(s, 16)) - seed
+
+		return xxh3Rrmxmx(input^bitflip, length)
+	case length <= 16:
+		low := readLE64(data, 0) ^ ((readLE64(s, 24) ^ readLE64(s, 32)) + seed)
+		high := readLE64(data, length-8) ^ ((readLE64(s, 40) ^ readLE64(s, 48)) - seed)
+
+		return xxh3Avalanche(length + bits.ReverseBytes64(low) + high + xxh3MulFold64(low, high))
+	case length <= 128:
+		acc := length * xxPrime64_1
+		for i := (length - 1) / 32; ; i-- {
+			acc += xxh3Mix16(data[16*i:], s[32*i:], seed)
+			acc += xxh3Mix16(data[length-16*(i+1):], s[32*i+16:], seed)
+			if i == 0 {
+				break
+			}
+		}
+
+		return xxh3Avalanche(acc)
+	case length <= 240:
+		acc := length * xxPrime64_1
+		for i := range uint64(8) {
+			acc += xxh3Mix16(data[16*i:], s[16*i:], seed)
+		}
+		acc = xxh3Avalanche(acc)
+		for i := uint64(8); i < length/16; i++ {
+			acc += xxh3Mix16(data[16*i:], s[16*(i-8)+xxh3MidSizeStartOffset:], seed)
+		}
+		acc += xxh3Mix16(data[length-16:], s[xxh3MidSizeLastOffset:], seed)
+
+		return xxh3Avalanche(acc)
+	default:
+		acc := xxh3HashLong(data, secret[:])
+
+		return xxh3MergeAccs(&acc, secret[xxh3MergeAccsStart:], length*xxPrime64_1)
+	}
+}
+
+// xxh3_128 is a pure Go implementation of the 128-bit XXH3 algorithm, it returns the low and the high 64 bits of the hash, see [xxh3_64].
+func xxh3_128(data []byte, seed uint64, secret *[192]byte) (uint64, uint64) {
+	length := uint64(len(data))
+	s := xxh3Secret[:]
+
+	switch {
+	case length == 0:
+		return xxAvalanche64(seed ^ readLE64(s, 64) ^ readLE64(s, 72)), xxAvalanche64(seed ^ readLE64(s, 80) ^ readLE64(s, 88))
+	case length <= 3:
+		combinedLow := uint32(data[0])<<16 | uint32(data[length>>1])<<24 | uint32(data[length-1]) | uint32(length)<<8
+		combinedHigh := bits.RotateLeft32(bits.ReverseBytes32(combinedLow), 13)
+		bitflipLow := uint64(binary.LittleEndian.Uint32(s)^binary.LittleEndian.Uint32(s[4:])) + seed
+		bitflipHigh := uint64(binary.LittleEndian.Uint32(s[8:])^binary.LittleEndian.Uint32(s[12:])) - seed
+
+		return xxAvalanche64(uint64(combinedLow) ^ bitflipLow), xxAvalanche64(uint64(combinedHigh) ^ bitflipHigh)
+	case length <= 8:
+		seed ^= uint64(bits.ReverseBytes32(uint32(seed))) << 32
+		input := uint64(binary.LittleEndian.Uint32(data)) + uint64(binary.LittleEndian.Uint32(data[length-4:]))<<32
+		bitflip := (readLE64(s, 16) ^ readLE64(s, 24)) + seed
+
+		high, low := bits.Mul64(input^bitflip, xxPrime64_1+length<<2)
+		high += low << 1
+		low ^= high >> 3
+		low ^= low >> 35
+		low *= xxPrimeMx2
+		low ^= low >> 28
+
+		return low, xxh3Avalanche(high)
+	case length <= 16:
+		bitflipLow := (readLE64(s, 32) ^ readLE64(s, 40)) - seed
+		bitflipHigh := (readLE64(s, 48) ^ readLE64(s, 56)) + seed
+		inputLow := readLE64(data, 0)
+		inputHigh := readLE64(data, length-8)
+
+		high, low := bits.Mul64(inputLow^inputHigh^bitflipLow, xxPrime64_1)
+		low += (length - 1) << 54
+		inputHigh ^= bitflipHigh
+		high += inputHigh + (inputHigh&0xffffffff)*(xxPrime32_2-1)
+		low ^= bits.ReverseBytes64(high)
+
+		hashHigh, hashLow := bits.Mul64(low, xxPrime64_2)
+		hashHigh += high * xxPrime64_2
+
+		return xxh3Avalanche(hashLow), xxh3Avalanche(hashHigh)
+	case length <= 128:
+		var low, high uint64
+		low = length * xxPrime64_1
+		for i := (length - 1) / 32; ; i-- {
+			low, high = xxh3Mix32(low, high, data[16*i:], data[length-16*(i+1):], s[32*i:], seed)
+			if i == 0 {
+				break
+			}
+		}
+
+		return xxh3Finalize128(low, high, length, seed)
+	case length <= 240:
+		var low, high uint64
+		low = length * xxPrime64_1
+		for i := range uint64(4) {
+			low, high = xxh3Mix32(low, high, data[32*i:], data[32*i+16:], s[32*i:], seed)
+		}
+		low, high = xxh3Avalanche(low), xxh3Avalanche(high)
+		for i := uint64(4); i < length/32; i++ {
+			low, high = xxh3Mix32(low, high, data[32*i:], data[32*i+16:], s[32*(i-4)+xxh3MidSizeStartOffset:], seed)
+		}
+		low, high = xxh3Mix32(low, high, data[length-16:], data[length-32:], s[xxh3MidSizeLastOffset-16:], -seed)
+
+		return xxh3Finalize128(low, high, length, seed)
+	default:
+		acc := xxh3HashLong(data, secret[:])
+
+		return xxh3MergeAccs(&acc, secret[xxh3MergeAccsStart:], length*xxPrime64_1),
+			xxh3MergeAccs(&acc, secret[len(secret)-xxh3StripeLen-xxh3MergeAccsStart:], ^(length * xxPrime64_2))
+	}
+}
+
+// xxh3HashLong accumulates the input in blocks of stripes, scrambling the accumulators after every full block.
+func xxh3HashLong(data, secret []byte) [8]uint64 {
+	acc := [8]uint64{xxPrime32_3, xxPrime64_1, xxPrime64_2, xxPrime64_3, xxPrime64_4, xxPrime32_2, xxPrime64_5, xxPrime32_1}
+	length := len(data)
+
+	blocks := (length - 1) / xxh3BlockLen
+	for n := range blocks {
+		xxh3Accumulate(&acc, data[n*xxh3BlockLen:], secret, xxh3StripesPerBlock)
+		xxh3Scramble(&acc, secret[len(secret)-xxh3StripeLen:])
+	}
+
+	// The last partial block, and the last stripe, which may overlap the stripes before it
+	xxh3Accumulate(&acc, data[blocks*xxh3BlockLen:], secret, ((length-1)-blocks*xxh3BlockLen)/xxh3StripeLen)
+	xxh3Accumulate(&acc, data[length-xxh3StripeLen:], secret[xxh3LastStripeOffset:], 1)
+
+	return acc
+}
+
+// xxh3Accumulate adds consecutive stripes to the accumulators, the secret moves by xxh3SecretConsumeRate bytes per stripe.
+// The accumulators are kept in local variables and the fixed size arrays drop the bounds checks, which makes it almost twice as fast.
+func xxh3Accumulate(acc *[8]uint64, data, secret []byte, stripes int) {
+	a0, a1, a2, a3, a4, a5, a6, a7 := acc[0], acc[1], acc[2], acc[3], acc[4], acc[5], acc[6], acc[7]
+	for s := range stripes {
+		in := (*[xxh3StripeLen]byte)(data[s*xxh3StripeLen:])
+		key := (*[xxh3StripeLen]byte)(secret[s*xxh3SecretConsumeRate:])
+		v0 := binary.LittleEndian.Uint64(in[0:])
+		k0 := v0 ^ binary.LittleEndian.Uint64(key[0:])
+		v1 := binary.LittleEndian.Uint64(in[8:])
+		k1 := v1 ^ binary.LittleEndian.Uint64(key[8:])
+		v2 := binary.LittleEndian.Uint64(in[16:])
+		k2 := v2 ^ binary.LittleEndian.Uint64(key[16:])
+		v3 := binary.LittleEndian.Uint64(in[24:])
+		k3 := v3 ^ binary.LittleEndian.Uint64(key[24:])
+		v4 := binary.LittleEndian.Uint64(in[32:])
+		k4 := v4 ^ binary.LittleEndian.Uint64(key[32:])
+		v5 := binary.LittleEndian.Uint64(in[40:])
+		k5 := v5 ^ binary.LittleEndian.Uint64(key[40:])
+		v6 := binary.LittleEndian.Uint64(in[48:])
+		k6 := v6 ^ binary.LittleEndian.Uint64(key[48:])
+		v7 := binary.LittleEndian.Uint64(in[56:])
+		k7 := v7 ^ binary.LittleEndian.Uint64(key[56:])
+
+		a1 += v0
+		a0 += (k0 & 0xffffffff) * (k0 >> 32)
+		a0 += v1
+		a1 += (k1 & 0xffffffff) * (k1 >> 32)
+		a3 += v2
+		a2 += (k2 & 0xffffffff) * (k2 >> 32)
+		a2 += v3
+		a3 += (k3 & 0xffffffff) * (k3 >> 32)
+		a5 += v4
+		a4 += (k4 & 0xffffffff) * (k4 >> 32)
+		a4 += v5
+		a5 += (k5 & 0xffffffff) * (k5 >> 32)
+		a7 += v6
+		a6 += (k6 & 0xffffffff) * (k6 >> 32)
+		a6 += v7
+		a7 += (k7 & 0xffffffff) * (k7 >> 32)
+	}
+	acc[0], acc[1], acc[2], acc[3], acc[4], acc[5], acc[6], acc[7] = a0, a1, a2, a3, a4, a5, a6, a7
+}
+
+func xxh3Scramble(acc *[8]uint64, secret []byte) {
+	key := (*[xxh3StripeLen]byte)(secret)
+	for i := range 8 {
+		a := acc[i]
+		a ^= a >> 47
+		a ^= binary.LittleEndian.Uint64(key[8*i:])
+		acc[i] = a * xxPrime32_1
+	}
+}
+
+func xxh3MergeAccs(acc *[8]uint64, secret []byte, start uint64) uint64 {
+	result := start
+	for i := range 4 {
+		result += xxh3MulFold64(acc[2*i]^readLE64(secret, uint64(16*i)), acc[2*i+1]^readLE64(secret, uint64(16*i+8)))
+	}
+
+	return xxh3Avalanche(result)
+}
+
+func xxh3Mix16(data, secret []byte, seed uint64) uint64 {
+	return xxh3MulFold64(readLE64(data, 0)^(readLE64(secret, 0)+seed), readLE64(data, 8)^(readLE64(secret, 8)-seed))
+}
+
+func xxh3Mix32(low, high uint64, data1, data2, secret []byte, seed uint64) (uint64, uint64) {
+	low += xxh3Mix16(data1, secret, seed)
+	low ^= readLE64(data2, 0) + readLE64(data2, 8)
+	high += xxh3Mix16(data2, secret[16:], seed)
+	high ^= readLE64(data1, 0) + readLE64(data1, 8)
+
+	return low, high
+}
+
+func xxh3Finalize128(low, high, length, seed uint64) (uint64, uint64) {
+	hashLow := low + high
+	hashHigh := low*xxPrime64_1 + high*xxPrime64_4 + (length-seed)*xxPrime64_2
+
+	return xxh3Avalanche(hashLow), -xxh3Avalanche(hashHigh)
+}
+
+// xxh3MulFold64 multiplies two 64-bit values to 128 bits and xors the halves.
+func xxh3MulFold64(a, b uint64) uint64 {
+	high, low := bits.Mul64(a, b)
+
+	return high ^ low
+}
+
+func xxh3Avalanche(h uint64) uint64 {
+	h ^= h >> 37
+	h *= xxPrimeMx1
+
+	return h ^ h>>32
+}
+
+func xxh3Rrmxmx(h, length uint64) uint64 {
+	h ^= bits.RotateLeft64(h, 49) ^ bits.RotateLeft64(h, 24)
+	h *= xxPrimeMx2
+	h ^= (h >> 35) + length
+	h *= xxPrimeMx2
+
+	return h ^ h>>28
+}
+
+func readLE64(data []byte, offset uint64) uint64 {
+	return binary.LittleEndian.Uint64(data[offset:])
+}
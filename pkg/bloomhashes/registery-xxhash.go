@@ -0,0 +1,96 @@
+package bloomhashes
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	xxPrime64_1 uint64 = 11400714785074694791
+	xxPrime64_2 uint64 = 14029467366897019727
+	xxPrime64_3 uint64 = 1609587929392839161
+	xxPrime64_4 uint64 = 9650029242287828579
+	xxPrime64_5 uint64 = 2870177450012600261
+)
+
+// XXHash64 computes a xxHash64 hash with a seed of 0.
+// This is the hash used by Apache Parquet bloom filters, and one of the fastest hashes for longer keys.
+func XXHash64(data []byte) uint64 {
+	return xxHash64(data, 0)
+}
+
+// XXHash64Seed returns a HashFunction that computes a xxHash64 hash with the given seed.
+func XXHash64Seed(seed uint64) HashFunction {
+	return func(data []byte) uint64 {
+		return xxHash64(data, seed)
+	}
+}
+
+// xxHash64 is a pure Go implementation of the xxHash64 algorithm, as specified by https://github.com/Cyan4973/xxHash/blob/dev/doc/xxhash_spec.md
+func xxHash64(data []byte, seed uint64) uint64 {
+	length := uint64(len(data))
+
+	var h uint64
+	if len(data) >= 32 {
+		v1 := seed + xxPrime64_1 + xxPrime64_2
+		v2 := seed + xxPrime64_2
+		v3 := seed
+		v4 := seed - xxPrime64_1
+		for len(data) >= 32 {
+			v1 = xxRound64(v1, binary.LittleEndian.Uint64(data[0:]))
+			v2 = xxRound64(v2, binary.LittleEndian.Uint64(data[8:]))
+			v3 = xxRound64(v3, binary.LittleEndian.Uint64(data[16:]))
+			v4 = xxRound64(v4, binary.LittleEndian.Uint64(data[24:]))
+			data = data[32:]
+		}
+
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMergeRound64(h, v1)
+		h = xxMergeRound64(h, v2)
+		h = xxMergeRound64(h, v3)
+		h = xxMergeRound64(h, v4)
+	} else {
+		h = seed + xxPrime64_5
+	}
+
+	h += length
+	for len(data) >= 8 {
+		h ^= xxRound64(0, binary.LittleEndian.Uint64(data))
+		h = bits.RotateLeft64(h, 27)*xxPrime64_1 + xxPrime64_4
+		data = data[8:]
+	}
+	if len(data) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(data)) * xxPrime64_1
+		h = bits.RotateLeft64(h, 23)*xxPrime64_2 + xxPrime64_3
+		data = data[4:]
+	}
+	for _, b := range data {
+		h ^= uint64(b) * xxPrime64_5
+		h = bits.RotateLeft64(h, 11) * xxPrime64_1
+	}
+
+	return xxAvalanche64(h)
+}
+
+// xxAvalanche64 is the final mix of xxHash64, also used by XXH3 for the shortest inputs.
+func xxAvalanche64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= xxPrime64_2
+	h ^= h >> 29
+	h *= xxPrime64_3
+
+	return h ^ h>>32
+}
+
+func xxRound64(acc, input uint64) uint64 {
+	acc += input * xxPrime64_2
+	acc = bits.RotateLeft64(acc, 31)
+
+	return acc * xxPrime64_1
+}
+
+func xxMergeRound64(acc, val uint64) uint64 {
+	acc ^= xxRound64(0, val)
+
+	return acc*xxPrime64_1 + xxPrime64_4
+}
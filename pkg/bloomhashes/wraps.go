@@ -42,3 +42,18 @@ func WrapHasher(h func() hash.Hash) HashFunction {
 		return bytesToUint64(sum)
 	}
 }
+
+// WrapHasher128 wraps a hash.Hash factory function of at least 128 bits into a HashFunction128.
+// It creates a new hasher, writes the data, and converts the first 16 bytes of the sum to two uint64 values.
+func WrapHasher128(h func() hash.Hash) HashFunction128 {
+	return func(data []byte) (uint64, uint64) {
+		hasher := h()
+		_, _ = hasher.Write(data)
+		sum := hasher.Sum(nil)
+		if len(sum) < 16 {
+			return bytesToUint64(sum), 0
+		}
+
+		return bytesToUint64(sum), bytesToUint64(sum[8:])
+	}
+}
@@ -18,3 +18,283 @@ func FalsePositiveRate(m, n, k uint64) float64 {
 	//https://en.wikipedia.org/wiki/Bloom_filter
 	return math.Pow(1-math.Exp((-float64(k)*float64(n))/float64(m)), float64(k))
 }
+
+// PartitionedFalsePositiveRate calculates the false positive rate of a partitioned Bloom filter, where each hash function has its own slice of m / k bits
+// - m is the numbers of bits in the array, over all slices
+// - n is the amount of elements expected to be stored in the filter
+// - k is the number of hash functions used, and thus the number of slices
+func PartitionedFalsePositiveRate(m, n, k uint64) float64 {
+	// Every slice receives exactly one bit per element
+	slice := float64(m / k)
+
+	return math.Pow(1-math.Pow(1-1/slice, float64(n)), float64(k))
+}
+
+// BlockedFalsePositiveRate calculates the false positive rate of a blocked Bloom filter, where all k bits of an element are set in a single block of b bits
+// - m is the numbers of bits in the array, over all blocks
+// - n is the amount of elements expected to be stored in the filter
+// - k is the number of hash functions used
+// - b is the size of a single block in bits
+func BlockedFalsePositiveRate(m, n, k, b uint64) float64 {
+	// https://algo2.iti.kit.edu/documents/cacheefficientbloomfilters-jea.pdf
+	// The amount of elements in a block follows a Poisson distribution, each block is a standard bloom filter of b bits
+	lambda := float64(n) * float64(b) / float64(m)
+	if lambda == 0 {
+		return 0
+	}
+
+	return poissonSum(lambda, func(i uint64) float64 {
+		return FalsePositiveRate(b, i, k)
+	})
+}
+
+// SplitBlockFalsePositiveRate calculates the false positive rate of a split block Bloom filter, as used by Apache Parquet
+// - bytes is the size of the filter in bytes, a multiple of the 32 byte block size
+// - n is the amount of elements expected to be stored in the filter
+func SplitBlockFalsePositiveRate(bytes, n uint64) float64 {
+	// A block consists of 8 words of 32 bits, each element sets exactly one bit in every word
+	lambda := float64(n) * 32 / float64(bytes)
+	if lambda == 0 {
+		return 0
+	}
+
+	return poissonSum(lambda, func(i uint64) float64 {
+		return math.Pow(1-math.Pow(1-1.0/32, float64(i)), 8)
+	})
+}
+
+// SplitBlockBytes calculates the size in bytes of a split block Bloom filter, like Apache Parquet writers do
+// - n is the amount of elements expected to be stored in the filter
+// - p is the desired false positive rate
+// The result is rounded up to a power of 2, between 32 bytes and 128 MiB
+func SplitBlockBytes(n uint64, p float64) uint64 {
+	const minBytes, maxBytes = 32, 128 * 1024 * 1024
+
+	bits := -8 * float64(n) / math.Log(1-math.Pow(p, 1.0/8))
+	bytes := uint64(math.Ceil(bits / 8))
+	if bytes >= maxBytes {
+		return maxBytes
+	}
+
+	result := uint64(minBytes)
+	for result < bytes {
+		result <<= 1
+	}
+
+	return result
+}
+
+// poissonSum calculates the sum of f(i) weighted by the Poisson distribution with the given mean.
+func poissonSum(lambda float64, f func(i uint64) float64) float64 {
+	// Only sum the terms around the mean, the rest is negligible
+	spread := 10*math.Sqrt(lambda) + 20
+	from := uint64(max(lambda-spread, 0))
+	to := uint64(lambda + spread)
+
+	var sum float64
+	for i := from; i <= to; i++ {
+		lgamma, _ := math.Lgamma(float64(i) + 1)
+		poisson := math.Exp(float64(i)*math.Log(lambda) - lambda - lgamma)
+		sum += poisson * f(i)
+	}
+
+	return sum
+}
+
+// CuckooFalsePositiveRate calculates the upper bound of the false positive rate of a cuckoo filter
+// - f is the size of a fingerprint in bits
+// - b is the amount of fingerprints per bucket
+// A lookup compares against the 2b fingerprints of two buckets, each matching with a probability of 1/2^f
+func CuckooFalsePositiveRate(f, b uint64) float64 {
+	return 1 - math.Pow(1-1/math.Exp2(float64(f)), float64(2*b))
+}
+
+// QuotientFilterFalsePositiveRate calculates the expected false positive rate of a quotient filter
+// - q is the amount of quotient bits, the filter has 2^q slots
+// - r is the amount of remainder bits stored per slot
+// - n is the amount of elements stored in the filter
+// A lookup is a false positive if another element has the same q+r bit fingerprint
+func QuotientFilterFalsePositiveRate(q, r, n uint64) float64 {
+	return 1 - math.Exp(-float64(n)/math.Exp2(float64(q+r)))
+}
+
+// XorFalsePositiveRate calculates the false positive rate of a xor filter
+// - f is the size of a fingerprint in bits
+// A lookup is a false positive if the xor of three random fingerprints matches the fingerprint of the element
+func XorFalsePositiveRate(f uint64) float64 {
+	return 1 / math.Exp2(float64(f))
+}
+
+// RibbonFalsePositiveRate calculates the false positive rate of a ribbon filter
+// - r is the amount of result bits stored per slot
+// A lookup is a false positive if the xor of the selected slots happens to match the fingerprint of the element
+func RibbonFalsePositiveRate(r uint64) float64 {
+	return 1 / math.Exp2(float64(r))
+}
+
+// MinimumBitsPerKey calculates the information theoretic lower bound of the bits per element of any filter with the false positive rate p
+func MinimumBitsPerKey(p float64) float64 {
+	return -math.Log2(p)
+}
+
+// BloomBitsPerKey calculates the bits per element an optimally configured bloom filter needs for the false positive rate p, about 44% above [MinimumBitsPerKey]
+func BloomBitsPerKey(p float64) float64 {
+	return -math.Log2(p) / math.Ln2
+}
+
+// StableFalsePositiveRate calculates the false positive rate a stable Bloom filter converges to
+// - m is the amount of cells in the filter
+// - k is the amount of hash functions
+// - p is the amount of cells decremented per insert
+// - maxValue is the value a cell is set to on insert
+func StableFalsePositiveRate(m, k, p, maxValue uint64) float64 {
+	c := 1/float64(k) - 1/float64(m)
+	zeros := math.Pow(1/(1+1/(float64(p)*c)), float64(maxValue))
+
+	return math.Pow(1-zeros, float64(k))
+}
+
+// StableDecrements calculates the amount of cells a stable Bloom filter has to decrement per insert to converge to a false positive rate
+// - m is the amount of cells in the filter
+// - k is the amount of hash functions
+// - maxValue is the value a cell is set to on insert
+// - fpr is the desired false positive rate
+// The result is not rounded, it is the inverse of [StableFalsePositiveRate]
+func StableDecrements(m, k, maxValue uint64, fpr float64) float64 {
+	c := 1/float64(k) - 1/float64(m)
+	zeros := 1 - math.Pow(fpr, 1/float64(k))
+
+	return 1 / (c * (math.Pow(zeros, -1/float64(maxValue)) - 1))
+}
+
+// AgePartitionedFalsePositiveRate calculates the false positive rate of an age-partitioned Bloom filter, right before it shifts its slices
+// - k is the amount of slices an element is added to
+// - l is the amount of extra slices that keep older elements
+// - m is the numbers of bits in a single slice
+// - g is the amount of elements added per generation
+func AgePartitionedFalsePositiveRate(k, l, m, g uint64) float64 {
+	// https://arxiv.org/abs/2001.03147
+	// A slice receives elements while it is one of the k youngest slices, so the slice of age i has seen min(i+1, k) generations.
+	// A false positive is a run of k consecutive slices with the bit set, the distribution of the length of the current run is tracked slice by slice.
+	if k == 0 {
+		return 1
+	}
+
+	run := make([]float64, k)
+	run[0] = 1
+	rate := 0.0
+	for age := range k + l {
+		fill := 1 - math.Pow(1-1/float64(m), float64(min(age+1, k)*g))
+		next := make([]float64, k)
+		for length, p := range run {
+			next[0] += p * (1 - fill)
+			if uint64(length)+1 == k {
+				rate += p * fill
+			} else {
+				next[length+1] += p * fill
+			}
+		}
+		run = next
+	}
+
+	return rate
+}
+
+// GolombBitsPerKey calculates the expected bits per element of a Golomb-coded set
+// - p is the Golomb-Rice parameter, the amount of low bits of every delta that are stored as is
+// - m is the inverse false positive rate
+func GolombBitsPerKey(p, m uint64) float64 {
+	// https://github.com/bitcoin/bips/blob/master/bip-0158.mediawiki
+	// The deltas between the sorted hashes are geometric with a mean of m, every delta costs p bits, a stop bit and its quotient in unary.
+	return float64(p) + 1 + 1/math.Expm1(math.Ldexp(1, int(p))/float64(m))
+}
+
+// DeletableProbability calculates the probability an element in a deletable Bloom filter can be removed
+// - m is the numbers of bits in the filter
+// - n is the amount of elements in the filter
+// - k is the amount of hash functions
+// - r is the amount of regions the bits are split into
+func DeletableProbability(m, n, k, r uint64) float64 {
+	// https://arxiv.org/abs/1005.0352
+	// A bit has a collision if two or more elements set it, a region can only be cleared if none of its bits has a collision.
+	// An element can be removed if at least one of its k bits is in a collision-free region.
+	// Unlike the paper, the region of a bit of the element is known to hold that bit, so no other element may set it,
+	// and the other bits of the region are set by at most one element each.
+	if m == 0 || n == 0 || r == 0 {
+		return 0
+	}
+
+	placements := float64(k * n)
+	unset := math.Pow(1-1/float64(m), placements)
+	single := placements / float64(m) * math.Pow(1-1/float64(m), placements-1)
+	own := math.Pow(1-1/float64(m), placements-1)
+	free := own * math.Pow(unset+single, math.Ceil(float64(m)/float64(r))-1)
+
+	return 1 - math.Pow(1-free, float64(k))
+}
+
+// IBLTLoadThreshold calculates the most keys per cell an invertible Bloom lookup table can hold and still list all of its entries, as the amount of cells goes to infinity
+// - k is the number of hash functions, and thus the number of cells of every key
+// With less than 2 hash functions two keys sharing a cell can never be listed, and it returns 0.
+func IBLTLoadThreshold(k uint64) float64 {
+	// https://arxiv.org/abs/1101.2245
+	// Listing the entries peels the 2-core of a random k-uniform hypergraph, which is empty below min_x x / (k * (1 - e^-x)^(k-1))
+	if k < 2 {
+		return 0
+	}
+
+	f := func(x float64) float64 {
+		return x / (float64(k) * math.Pow(1-math.Exp(-x), float64(k-1)))
+	}
+	// Golden section search, f has a single minimum
+	lo, hi := 1e-9, 4*float64(k)
+	for range 200 {
+		a := hi - (hi-lo)/math.Phi
+		b := lo + (hi-lo)/math.Phi
+		if f(a) < f(b) {
+			hi = b
+		} else {
+			lo = a
+		}
+	}
+
+	return f((lo + hi) / 2)
+}
+
+// IBLTCells calculates the amount of cells an invertible Bloom lookup table needs to list a difference of d keys
+// - d is the amount of keys in the difference between two tables
+// - k is the number of hash functions
+// It adds 10% headroom above [IBLTLoadThreshold] and one cell per hash function, and rounds up to a multiple of k so every hash function gets the same amount of cells.
+// Less than 2 hash functions are sized as 2.
+func IBLTCells(d, k uint64) uint64 {
+	k = max(k, 1)
+	cells := uint64(math.Ceil(1.1*float64(d)/IBLTLoadThreshold(max(k, 2)))) + k
+
+	return (cells + k - 1) / k * k
+}
+
+// CountMinWidth calculates the amount of counters per row of a Count-Min sketch, so an estimate overshoots by at most epsilon times the total count
+// - epsilon is the error relative to the total of all counts
+func CountMinWidth(epsilon float64) uint64 {
+	// http://dimacs.rutgers.edu/~graham/pubs/papers/cm-full.pdf
+	return uint64(math.Ceil(math.E / epsilon))
+}
+
+// CountMinDepth calculates the amount of rows of a Count-Min sketch, so the error bound of [CountMinWidth] holds with probability 1 - delta
+// - delta is the probability an estimate overshoots by more than the error bound
+func CountMinDepth(delta float64) uint64 {
+	return uint64(math.Ceil(math.Log(1 / delta)))
+}
+
+// CascadeFalsePositiveRate calculates the false positive rate of the first level of a filter cascade that makes it the smallest
+// - r is the amount of included elements
+// - s is the amount of excluded elements
+// Deeper levels use a false positive rate of 1/2, the rate is capped to that as well.
+func CascadeFalsePositiveRate(r, s uint64) float64 {
+	// https://ieeexplore.ieee.org/document/7958597 (CRLite, section IV.B)
+	if s == 0 {
+		return 0.5
+	}
+
+	return min(float64(r)*math.Sqrt(0.5)/float64(s), 0.5)
+}
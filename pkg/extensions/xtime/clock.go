@@ -0,0 +1,48 @@
+package xtime
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the current time, so time based filters can be tested without waiting.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock that returns the current time of the system.
+type SystemClock struct{}
+
+// Now returns the current time of the system.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ManualClock is a Clock that only moves when told to, for deterministic tests.
+// It is safe to use from multiple goroutines.
+type ManualClock struct {
+	now atomic.Int64
+}
+
+// NewManualClock creates a new ManualClock set to the given time.
+func NewManualClock(now time.Time) *ManualClock {
+	c := &ManualClock{}
+	c.Set(now)
+
+	return c
+}
+
+// Now returns the time the clock is set to.
+func (c *ManualClock) Now() time.Time {
+	return time.Unix(0, c.now.Load())
+}
+
+// Set sets the clock to the given time.
+func (c *ManualClock) Set(now time.Time) {
+	c.now.Store(now.UnixNano())
+}
+
+// Advance moves the clock forward by the given duration.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.now.Add(int64(d))
+}
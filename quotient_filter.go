@@ -0,0 +1,394 @@
+package bloomfilters
+
+import (
+	"errors"
+	"math/bits"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+// DefaultRemainderBits is the default size in bits of the remainder stored per item in a quotient filter.
+const DefaultRemainderBits = 10
+
+var (
+	ErrInvalidQuotientBits  = errors.New("quotient bits must be at least 1, and quotient plus remainder bits at most 64")
+	ErrInvalidRemainderBits = errors.New("remainder bits must be between 1 and 61")
+	ErrCannotResize         = errors.New("filter has no remainder bits left to move into the quotient")
+	ErrIncompatibleFilters  = errors.New("filters do not use the same fingerprint size")
+)
+
+// Metadata bits stored in the lowest bits of every quotient filter slot.
+const (
+	qfOccupied     = 1 << 0 // The slot is the canonical slot of at least one stored fingerprint
+	qfContinuation = 1 << 1 // The slot holds a remainder of the same run as the slot before it
+	qfShifted      = 1 << 2 // The slot holds a remainder that is not in its canonical slot
+	qfMetadata     = qfOccupied | qfContinuation | qfShifted
+	qfMetadataBits = 3
+)
+
+// QuotientFilter is a probabilistic data structure that tests whether an element is a member of a set, based on "Don't Thrash: How to Cache Your Hash on Flash" by Bender et al.
+// The fingerprint of an item is split into a quotient, which picks one of 2^q slots, and a remainder that is stored in the slot.
+// Remainders of the same quotient are stored sorted in a run, and runs are shifted into the next slots when the canonical slot is taken,
+// with three metadata bits per slot to reconstruct which quotient a remainder belongs to.
+// Because the full fingerprint can be reconstructed, filters can be merged and resized without the original items.
+type QuotientFilter struct {
+	table Counters
+	hash  bloomhashes.HashFunction
+	qBits uint8
+	rBits uint8
+	count uint64
+
+	hashes []bloomhashes.HashFunction
+	size   uint64
+	words  []uint64
+}
+
+// NewQuotientFilter creates a new quotient filter with the given options, the amount of slots is rounded up to a power of 2.
+// It returns an error if the size of the filter, the quotient or remainder size is invalid, or if not exactly one hash function is given.
+func NewQuotientFilter(opts ...QuotientFilterOptions) (*QuotientFilter, error) {
+	qf := &QuotientFilter{
+		rBits: DefaultRemainderBits,
+	}
+	for _, opt := range opts {
+		opt.applyQuotient(qf)
+	}
+	if qf.qBits == 0 && qf.size == 0 && len(qf.words) == 0 {
+		return nil, ErrInvalidSize
+	}
+	if qf.rBits < 1 || qf.rBits > 64-qfMetadataBits {
+		return nil, ErrInvalidRemainderBits
+	}
+	if len(qf.hashes) == 0 {
+		return nil, ErrRequiredHashFunction
+	}
+	if qf.hashes[0] == nil {
+		return nil, ErrHashIsNil
+	}
+	if len(qf.hashes) != 1 {
+		return nil, ErrSingleHashFunction
+	}
+
+	width := qf.rBits + qfMetadataBits
+	if qf.size > 0 {
+		qf.qBits = uint8(bits.Len64(nextPowerOfTwo((qf.size+uint64(width)-1)/uint64(width))) - 1)
+	}
+	if qf.qBits < 1 || int(qf.qBits)+int(qf.rBits) > 64 {
+		return nil, ErrInvalidQuotientBits
+	}
+
+	qf.table = NewCounters(uint64(1)<<qf.qBits, width)
+	if len(qf.words) > 0 {
+		// The words only hold the slots, so the quotient size has to be given to know how many of them there are
+		if len(qf.words) != len(qf.table.data) {
+			return nil, ErrInvalidSize
+		}
+		qf.table.data = qf.words
+		for i := range qf.Capacity() {
+			if qf.table.Get(i)&qfMetadata != 0 {
+				qf.count++
+			}
+		}
+	}
+	qf.hash = qf.hashes[0]
+	qf.hashes, qf.words = nil, nil
+
+	return qf, nil
+}
+
+// Add adds the given data to the filter. Items are stored as a set, adding data with a fingerprint that is already stored does nothing.
+// It returns [ErrFilterFull] if every slot of the filter is in use, see [QuotientFilter.Resize].
+func (qf *QuotientFilter) Add(data []byte) error {
+	quotient, remainder := qf.split(qf.fingerprint(qf.hash(data)))
+
+	return qf.insert(quotient, remainder)
+}
+
+// Test checks if the given data is likely to be in the filter, by searching the run of its quotient for its remainder.
+func (qf *QuotientFilter) Test(data []byte) bool {
+	quotient, remainder := qf.split(qf.fingerprint(qf.hash(data)))
+	if qf.table.Get(quotient)&qfOccupied == 0 {
+		return false
+	}
+
+	s := qf.findRun(quotient)
+	for {
+		rem := qf.table.Get(s) >> qfMetadataBits
+		if rem == remainder {
+			return true
+		} else if rem > remainder {
+			// Runs are sorted
+			return false
+		}
+		s = qf.next(s)
+		if qf.table.Get(s)&qfContinuation == 0 {
+			return false
+		}
+	}
+}
+
+// Remove removes the given data from the filter.
+// It returns [ErrNotInFilter] if the fingerprint of the data is not in the filter.
+// Removing data that was never added, but is a false positive, will introduce false negatives for other items.
+func (qf *QuotientFilter) Remove(data []byte) error {
+	quotient, remainder := qf.split(qf.fingerprint(qf.hash(data)))
+	if qf.table.Get(quotient)&qfOccupied == 0 {
+		return ErrNotInFilter
+	}
+
+	// Deleting in place needs to fix the metadata of every following slot of the cluster,
+	// instead take the whole cluster out and insert all other fingerprints again, clusters are short on average.
+	start := quotient
+	for qf.table.Get(start)&qfShifted != 0 {
+		start = qf.prev(start)
+	}
+
+	var cluster [][2]uint64
+	found := false
+	qf.cluster(start, func(q, r uint64) {
+		if !found && q == quotient && r == remainder {
+			found = true
+
+			return
+		}
+		cluster = append(cluster, [2]uint64{q, r})
+	})
+	if !found {
+		return ErrNotInFilter
+	}
+
+	for s, n := start, 0; n <= len(cluster); s, n = qf.next(s), n+1 {
+		qf.table.Set(s, 0)
+	}
+	qf.count -= uint64(len(cluster)) + 1
+	for _, entry := range cluster {
+		_ = qf.insert(entry[0], entry[1])
+	}
+
+	return nil
+}
+
+// Merge adds all fingerprints of the other filter to this filter, the other filter is left untouched.
+// Both filters must use the same hash function and the same fingerprint size, the sum of quotient and remainder bits,
+// which is the case for a filter and any resized copy of it. It returns [ErrIncompatibleFilters] if the fingerprint sizes differ,
+// and [ErrFilterFull] without changing this filter if the combined items don't fit, see [QuotientFilter.Resize].
+func (qf *QuotientFilter) Merge(other *QuotientFilter) error {
+	if qf.qBits+qf.rBits != other.qBits+other.rBits {
+		return ErrIncompatibleFilters
+	}
+	if qf.count+other.count > qf.Capacity() {
+		return ErrFilterFull
+	}
+
+	other.fingerprints(func(fp uint64) {
+		_ = qf.insert(qf.split(fp))
+	})
+
+	return nil
+}
+
+// Resize doubles the capacity of the filter, by moving one bit of every remainder into the quotient.
+// The fingerprints stay the same, so the false positive rate doesn't change while the load factor halves.
+// It returns [ErrCannotResize] if the remainder is only a single bit.
+func (qf *QuotientFilter) Resize() error {
+	if qf.rBits <= 1 {
+		return ErrCannotResize
+	}
+
+	resized := &QuotientFilter{
+		table: NewCounters(uint64(1)<<(qf.qBits+1), qf.rBits-1+qfMetadataBits),
+		hash:  qf.hash,
+		qBits: qf.qBits + 1,
+		rBits: qf.rBits - 1,
+	}
+	qf.fingerprints(func(fp uint64) {
+		_ = resized.insert(resized.split(fp))
+	})
+	*qf = *resized
+
+	return nil
+}
+
+// Count returns the amount of fingerprints stored in the filter.
+func (qf *QuotientFilter) Count() uint64 {
+	return qf.count
+}
+
+// Capacity returns the amount of slots in the filter, 2^q.
+func (qf *QuotientFilter) Capacity() uint64 {
+	return uint64(1) << qf.qBits
+}
+
+// LoadFactor returns the fraction of slots that are in use.
+func (qf *QuotientFilter) LoadFactor() float64 {
+	return float64(qf.count) / float64(qf.Capacity())
+}
+
+// QuotientBits returns the amount of fingerprint bits used to pick a slot.
+func (qf *QuotientFilter) QuotientBits() uint8 {
+	return qf.qBits
+}
+
+// RemainderBits returns the amount of fingerprint bits stored in a slot.
+func (qf *QuotientFilter) RemainderBits() uint8 {
+	return qf.rBits
+}
+
+// Words returns a copy slice of uint64 words representing the slots of the filter.
+// A filter can be restored from these words with [WithWords], together with the same quotient size through [WithQuotientBits] and the same remainder size.
+func (qf *QuotientFilter) Words() []uint64 {
+	return qf.table.Words()
+}
+
+// fingerprint returns the top q+r bits of the hash.
+func (qf *QuotientFilter) fingerprint(hash uint64) uint64 {
+	return hash >> (64 - qf.qBits - qf.rBits)
+}
+
+// split splits a fingerprint into its quotient and remainder.
+func (qf *QuotientFilter) split(fp uint64) (quotient, remainder uint64) {
+	return fp >> qf.rBits, fp & (uint64(1)<<qf.rBits - 1)
+}
+
+func (qf *QuotientFilter) next(i uint64) uint64 {
+	return (i + 1) & (qf.Capacity() - 1)
+}
+
+func (qf *QuotientFilter) prev(i uint64) uint64 {
+	return (i - 1) & (qf.Capacity() - 1)
+}
+
+// findRun returns the slot where the run of the given quotient starts, or would start if the quotient has no run yet.
+func (qf *QuotientFilter) findRun(quotient uint64) uint64 {
+	// Walk back to the start of the cluster
+	b := quotient
+	for qf.table.Get(b)&qfShifted != 0 {
+		b = qf.prev(b)
+	}
+
+	// Walk forward, skipping a run for every occupied slot until the quotient is reached
+	s := b
+	for b != quotient {
+		for {
+			s = qf.next(s)
+			if qf.table.Get(s)&qfContinuation == 0 {
+				break
+			}
+		}
+		for {
+			b = qf.next(b)
+			if qf.table.Get(b)&qfOccupied != 0 {
+				break
+			}
+		}
+	}
+
+	return s
+}
+
+// insert adds a remainder to the run of its quotient, keeping the run sorted and shifting the following slots of the cluster.
+func (qf *QuotientFilter) insert(quotient, remainder uint64) error {
+	canonical := qf.table.Get(quotient)
+	entry := remainder << qfMetadataBits
+	if canonical&qfMetadata == 0 {
+		// Every slot in use has metadata, so the filter can't be full
+		qf.table.Set(quotient, entry|qfOccupied)
+		qf.count++
+
+		return nil
+	}
+	if canonical&qfOccupied == 0 {
+		if qf.count >= qf.Capacity() {
+			return ErrFilterFull
+		}
+		qf.table.Set(quotient, canonical|qfOccupied)
+	}
+
+	start := qf.findRun(quotient)
+	s := start
+	if canonical&qfOccupied != 0 {
+		// Find the insert position in the existing run
+		for {
+			rem := qf.table.Get(s) >> qfMetadataBits
+			if rem == remainder {
+				return nil
+			} else if rem > remainder {
+				break
+			}
+			s = qf.next(s)
+			if qf.table.Get(s)&qfContinuation == 0 {
+				break
+			}
+		}
+
+		// Only a remainder that isn't stored yet needs a free slot
+		if qf.count >= qf.Capacity() {
+			return ErrFilterFull
+		}
+		if s == start {
+			// The old head of the run becomes a continuation
+			qf.table.Set(start, qf.table.Get(start)|qfContinuation)
+		} else {
+			entry |= qfContinuation
+		}
+	}
+	if s != quotient {
+		entry |= qfShifted
+	}
+
+	// Shift everything up to the next empty slot one slot further, occupied bits stay with their slot
+	for {
+		prev := qf.table.Get(s)
+		empty := prev&qfMetadata == 0
+		if !empty {
+			prev |= qfShifted
+			if prev&qfOccupied != 0 {
+				entry |= qfOccupied
+				prev &^= qfOccupied
+			}
+		}
+		qf.table.Set(s, entry)
+		entry = prev
+		s = qf.next(s)
+		if empty {
+			break
+		}
+	}
+	qf.count++
+
+	return nil
+}
+
+// cluster calls fn with the quotient and remainder of every fingerprint in the cluster starting at the given slot.
+func (qf *QuotientFilter) cluster(start uint64, fn func(quotient, remainder uint64)) {
+	quotient, s := start, start
+	for n := uint64(0); n < qf.Capacity(); n++ {
+		e := qf.table.Get(s)
+		if e&qfMetadata == 0 || (s != start && e&(qfContinuation|qfShifted) == 0) {
+			// An empty slot or the start of the next cluster
+			return
+		}
+		if s != start && e&qfContinuation == 0 {
+			// A new run, which belongs to the next occupied slot
+			for {
+				quotient = qf.next(quotient)
+				if qf.table.Get(quotient)&qfOccupied != 0 {
+					break
+				}
+			}
+		}
+		fn(quotient, e>>qfMetadataBits)
+		s = qf.next(s)
+	}
+}
+
+// fingerprints calls fn with every fingerprint stored in the filter.
+func (qf *QuotientFilter) fingerprints(fn func(fp uint64)) {
+	for s := range qf.Capacity() {
+		e := qf.table.Get(s)
+		if e&qfMetadata != 0 && e&(qfContinuation|qfShifted) == 0 {
+			qf.cluster(s, func(quotient, remainder uint64) {
+				fn(quotient<<qf.rBits | remainder)
+			})
+		}
+	}
+}
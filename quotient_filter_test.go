@@ -0,0 +1,268 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newQuotientFilter(t testing.TB, opts ...bloomfilters.QuotientFilterOptions) *bloomfilters.QuotientFilter {
+	t.Helper()
+
+	opts = append(opts, bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64}))
+	qf, err := bloomfilters.NewQuotientFilter(opts...)
+	require.NoError(t, err)
+
+	return qf
+}
+
+func Test_QuotientFilter_AddTestRemove(t *testing.T) {
+	qf := newQuotientFilter(t, bloomfilters.WithQuotientBits(8))
+
+	require.NoError(t, qf.Add([]byte("hello")))
+	require.NoError(t, qf.Add([]byte("world")))
+	require.NoError(t, qf.Add([]byte("hello")))
+	assert.True(t, qf.Test([]byte("hello")))
+	assert.True(t, qf.Test([]byte("world")))
+	assert.Equal(t, uint64(2), qf.Count(), "Expected the duplicate to be stored once")
+
+	require.NoError(t, qf.Remove([]byte("hello")))
+	assert.False(t, qf.Test([]byte("hello")), "Expected 'hello' to be removed from the filter")
+	assert.True(t, qf.Test([]byte("world")), "Expected 'world' to still be in the filter")
+
+	err := qf.Remove([]byte("hello"))
+	require.ErrorIs(t, err, bloomfilters.ErrNotInFilter)
+	assert.Equal(t, uint64(1), qf.Count())
+}
+
+// Test_QuotientFilter_Model compares a small, crowded filter against a map, so runs and clusters are shifted and wrap around the table.
+func Test_QuotientFilter_Model(t *testing.T) {
+	// 40 bit remainders make fingerprint collisions between the test items practically impossible
+	qf := newQuotientFilter(t, bloomfilters.WithQuotientBits(6), bloomfilters.WithRemainderBits(40))
+	data := testutil.MoreBytes(200, 16)
+	r := testutil.Randomizer()
+
+	model := map[int]bool{}
+	for range 5000 {
+		i := r.IntN(len(data))
+		switch {
+		case model[i] && r.IntN(2) == 0:
+			require.NoError(t, qf.Remove(data[i]))
+			delete(model, i)
+		case !model[i] && len(model) < 60:
+			require.NoError(t, qf.Add(data[i]))
+			model[i] = true
+		}
+
+		require.Equal(t, uint64(len(model)), qf.Count())
+		for j, d := range data {
+			require.Equal(t, model[j], qf.Test(d), "Expected the filter to match the model for item %d", j)
+		}
+	}
+}
+
+func Test_QuotientFilter_Full(t *testing.T) {
+	qf := newQuotientFilter(t, bloomfilters.WithQuotientBits(4), bloomfilters.WithRemainderBits(40))
+	data := testutil.MoreBytes(17, 16)
+
+	for _, d := range data[:16] {
+		require.NoError(t, qf.Add(d))
+	}
+	require.ErrorIs(t, qf.Add(data[16]), bloomfilters.ErrFilterFull)
+	for _, d := range data[:16] {
+		require.True(t, qf.Test(d), "Expected added data to be found in a full filter")
+	}
+	words := qf.Words()
+	for _, d := range data[:16] {
+		require.NoError(t, qf.Add(d), "Expected adding stored data to a full filter to do nothing")
+	}
+	assert.Equal(t, uint64(16), qf.Count())
+	assert.Equal(t, words, qf.Words())
+
+	require.NoError(t, qf.Remove(data[0]))
+	require.NoError(t, qf.Add(data[16]))
+	for _, d := range data[1:] {
+		require.True(t, qf.Test(d), "Expected added data to be found after a remove")
+	}
+}
+
+func Test_QuotientFilter_FalsePositiveRate(t *testing.T) {
+	data := testutil.MoreBytes(3<<14+100000, 16)
+	added, others := data[:3<<14], data[3<<14:]
+
+	// Fill 2^16 slots to a load factor of 0.75
+	qf := newQuotientFilter(t, bloomfilters.WithQuotientBits(16), bloomfilters.WithRemainderBits(8))
+	for _, d := range added {
+		require.NoError(t, qf.Add(d))
+	}
+
+	var positives int
+	for _, d := range others {
+		if qf.Test(d) {
+			positives++
+		}
+	}
+	rate := float64(positives) / float64(len(others))
+	expected := bloomsettings.QuotientFilterFalsePositiveRate(16, 8, uint64(len(added)))
+	assert.InDelta(t, expected, rate, expected*0.2, "Expected the false positive rate to match the prediction")
+}
+
+func Test_QuotientFilter_Resize(t *testing.T) {
+	data := testutil.MoreBytes(1000, 16)
+	qf := newQuotientFilter(t, bloomfilters.WithQuotientBits(10), bloomfilters.WithRemainderBits(12))
+	for _, d := range data {
+		require.NoError(t, qf.Add(d))
+	}
+	count := qf.Count()
+
+	require.NoError(t, qf.Resize())
+	assert.Equal(t, uint8(11), qf.QuotientBits())
+	assert.Equal(t, uint8(11), qf.RemainderBits())
+	assert.Equal(t, uint64(2048), qf.Capacity())
+	assert.Equal(t, count, qf.Count(), "Expected resizing to keep every fingerprint")
+	for _, d := range data {
+		require.True(t, qf.Test(d), "Expected added data to be found after a resize")
+	}
+
+	// Resizing keeps going until the remainder is a single bit
+	small := newQuotientFilter(t, bloomfilters.WithQuotientBits(4), bloomfilters.WithRemainderBits(1))
+	require.ErrorIs(t, small.Resize(), bloomfilters.ErrCannotResize)
+}
+
+func Test_QuotientFilter_Merge(t *testing.T) {
+	data := testutil.MoreBytes(600, 16)
+
+	a := newQuotientFilter(t, bloomfilters.WithQuotientBits(10), bloomfilters.WithRemainderBits(12))
+	b := newQuotientFilter(t, bloomfilters.WithQuotientBits(9), bloomfilters.WithRemainderBits(13))
+	for _, d := range data[:300] {
+		require.NoError(t, a.Add(d))
+	}
+	for _, d := range data[300:] {
+		require.NoError(t, b.Add(d))
+	}
+
+	require.NoError(t, a.Merge(b))
+	for _, d := range data {
+		require.True(t, a.Test(d), "Expected the merged filter to contain the data of both filters")
+	}
+
+	// b is too small to hold both
+	require.ErrorIs(t, b.Merge(a), bloomfilters.ErrFilterFull)
+
+	c := newQuotientFilter(t, bloomfilters.WithQuotientBits(10), bloomfilters.WithRemainderBits(10))
+	require.ErrorIs(t, a.Merge(c), bloomfilters.ErrIncompatibleFilters)
+}
+
+func Test_QuotientFilter_WithWords(t *testing.T) {
+	data := testutil.MoreBytes(500, 16)
+	qf := newQuotientFilter(t, bloomfilters.WithSize(8192))
+	for _, d := range data {
+		require.NoError(t, qf.Add(d))
+	}
+
+	restored := newQuotientFilter(t, bloomfilters.WithWords(qf.Words()), bloomfilters.WithQuotientBits(qf.QuotientBits()))
+	assert.Equal(t, qf.Capacity(), restored.Capacity())
+	assert.Equal(t, qf.Count(), restored.Count())
+	for _, d := range data {
+		require.True(t, restored.Test(d), "Expected the restored filter to contain the added data")
+	}
+}
+
+func Test_QuotientFilter_WithWords_Small(t *testing.T) {
+	for q := uint8(1); q <= 6; q++ {
+		for r := uint8(1); r <= 4; r++ {
+			t.Run(fmt.Sprintf("q%d_r%d", q, r), func(t *testing.T) {
+				qf := newQuotientFilter(t, bloomfilters.WithQuotientBits(q), bloomfilters.WithRemainderBits(r))
+				data := testutil.MoreBytes(int(qf.Capacity()/2), 16)
+				for _, d := range data {
+					require.NoError(t, qf.Add(d))
+				}
+
+				restored := newQuotientFilter(t, bloomfilters.WithWords(qf.Words()), bloomfilters.WithQuotientBits(q), bloomfilters.WithRemainderBits(r))
+				assert.Equal(t, qf.QuotientBits(), restored.QuotientBits())
+				assert.Equal(t, qf.Count(), restored.Count())
+				assert.Equal(t, qf.Words(), restored.Words())
+				for _, d := range data {
+					require.True(t, restored.Test(d), "Expected the restored filter to contain the added data")
+				}
+			})
+		}
+	}
+}
+
+func Test_QuotientFilter_Invalid(t *testing.T) {
+	hashes := bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64})
+
+	tests := []struct {
+		name string
+		opts []bloomfilters.QuotientFilterOptions
+		err  error
+	}{
+		{"no size", []bloomfilters.QuotientFilterOptions{hashes}, bloomfilters.ErrInvalidSize},
+		{"remainder bits", []bloomfilters.QuotientFilterOptions{bloomfilters.WithQuotientBits(8), bloomfilters.WithRemainderBits(0), hashes}, bloomfilters.ErrInvalidRemainderBits},
+		{"fingerprint too large", []bloomfilters.QuotientFilterOptions{bloomfilters.WithQuotientBits(20), bloomfilters.WithRemainderBits(50), hashes}, bloomfilters.ErrInvalidQuotientBits},
+		{"words without quotient bits", []bloomfilters.QuotientFilterOptions{bloomfilters.WithWords(make([]uint64, 4)), hashes}, bloomfilters.ErrInvalidQuotientBits},
+		{"words of another size", []bloomfilters.QuotientFilterOptions{bloomfilters.WithWords(make([]uint64, 4)), bloomfilters.WithQuotientBits(8), hashes}, bloomfilters.ErrInvalidSize},
+		{"many hashes", []bloomfilters.QuotientFilterOptions{bloomfilters.WithQuotientBits(8), bloomfilters.WithDefaultHashFunctions()}, bloomfilters.ErrSingleHashFunction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qf, err := bloomfilters.NewQuotientFilter(tt.opts...)
+			require.ErrorIs(t, err, tt.err)
+			require.Nil(t, qf)
+		})
+	}
+}
+
+// Fuzz test for QuotientFilter Add, Test and Remove
+func Fuzz_QuotientFilter_AddRemove(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		qf := newQuotientFilter(t, bloomfilters.WithQuotientBits(8))
+
+		require.NoError(t, qf.Add(data))
+		require.True(t, qf.Test(data), "Expected added data to be found in the filter")
+
+		require.NoError(t, qf.Remove(data))
+		require.False(t, qf.Test(data), "Expected removed data to not be found in the filter")
+	})
+}
+
+// Example of merging and resizing QuotientFilters
+func ExampleQuotientFilter() {
+	hashes := bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.XXHash64})
+
+	// Create two filters with 2^10 slots and 10 bit remainders
+	a, _ := bloomfilters.NewQuotientFilter(bloomfilters.WithQuotientBits(10), hashes)
+	b, _ := bloomfilters.NewQuotientFilter(bloomfilters.WithQuotientBits(10), hashes)
+
+	_ = a.Add([]byte("apple"))
+	_ = b.Add([]byte("banana"))
+
+	// Double the capacity of a, then merge b into it
+	_ = a.Resize()
+	err := a.Merge(b)
+
+	fmt.Println(err)
+	fmt.Println(a.Capacity())
+	fmt.Println(a.Test([]byte("apple")))
+	fmt.Println(a.Test([]byte("banana")))
+
+	// Output:
+	// <nil>
+	// 2048
+	// true
+	// true
+}
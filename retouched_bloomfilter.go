@@ -0,0 +1,172 @@
+package bloomfilters
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+// RetouchStrategy selects which bit of a false positive a [RetouchedBloomFilter] clears.
+type RetouchStrategy uint8
+
+const (
+	// RetouchRandom clears a random bit of the false positive.
+	RetouchRandom RetouchStrategy = iota
+	// RetouchRatio clears the bit with the lowest ratio of false negatives it introduces to known false positives it removes.
+	RetouchRatio
+)
+
+var ErrInvalidRetouchStrategy = errors.New("unknown retouch strategy")
+
+var _ IBloomFilter = &RetouchedBloomFilter{}
+
+// RetouchedBloomFilter is a [BloomFilter] that can clear bits to stop reporting known false positives, based on "Retouched Bloom Filters: Allowing Networked Applications to Trade Off Selected False Positives Against False Negatives" by Donnet, Baynat and Friedman.
+// Next to the bits it keeps a small counter per bit of the items that set it, and of the known false positives that need it.
+// Clearing a bit turns every item that set it into a false negative, so the strategy picks a bit, see [RetouchStrategy], and the amount of possible false negatives is reported.
+// Counters that overflow saturate at their maximum value, so the reported amount is a lower bound for bits set by very many items.
+type RetouchedBloomFilter struct {
+	filter         *BloomFilter
+	items          Counters
+	falsePositives Counters
+	falseNegatives uint64
+	rng            *rand.Rand
+
+	bits     Bits
+	hashes   []bloomhashes.HashFunction
+	derived  derivedHashes
+	width    uint8
+	strategy RetouchStrategy
+}
+
+// NewRetouchedBloomFilter creates a new retouched bloom filter with the given options.
+// It returns an error if the size of the bloom filter, the counter width or the strategy is invalid, or if any of the hash functions are nil.
+func NewRetouchedBloomFilter(opts ...RetouchedBloomFilterOptions) (*RetouchedBloomFilter, error) {
+	bf := &RetouchedBloomFilter{
+		width:    DefaultCounterWidth,
+		strategy: RetouchRatio,
+	}
+	for _, opt := range opts {
+		opt.applyRetouched(bf)
+	}
+	if bf.bits.Size() == 0 {
+		return nil, ErrInvalidSize
+	}
+	if bf.width == 0 || bf.width > 64 {
+		return nil, ErrInvalidCounterWidth
+	}
+	if bf.strategy > RetouchRatio {
+		return nil, ErrInvalidRetouchStrategy
+	}
+	if len(bf.hashes) == 0 {
+		return nil, ErrRequiredHashFunction
+	}
+	for _, hashFunc := range bf.hashes {
+		if hashFunc == nil {
+			return nil, ErrHashIsNil
+		}
+	}
+
+	bf.filter = &BloomFilter{bits: bf.bits, hashes: bf.hashes, derived: bf.derived}
+	bf.items = NewCounters(bf.bits.Size(), bf.width)
+	bf.falsePositives = NewCounters(bf.bits.Size(), bf.width)
+	for i := range bf.bits.Size() {
+		// Every bit that was set, has been set by at least one item
+		if bf.bits.Getbit(i) {
+			bf.items.Set(i, 1)
+		}
+	}
+	if bf.rng == nil {
+		bf.rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())) // nolint:gosec // This is not used for cryptographic purposes.
+	}
+
+	return bf, nil
+}
+
+// Add adds the given data to the bloom filter, and counts it for every bit it sets.
+func (bf *RetouchedBloomFilter) Add(data []byte) {
+	for _, hash := range bf.derived.all(bf.hashes, data) {
+		bf.SetHash(hash)
+	}
+}
+
+// Test checks if the given data is likely to be in the bloom filter, see [BloomFilter.Test].
+func (bf *RetouchedBloomFilter) Test(data []byte) bool {
+	return bf.filter.Test(data)
+}
+
+// SetHash sets the bit corresponding to the given hash value to 1, and counts it as an item of that bit.
+func (bf *RetouchedBloomFilter) SetHash(hash uint64) {
+	index := bf.filter.index(hash)
+	bf.filter.bits.Setbit(index)
+	bf.items.Increment(index)
+}
+
+// GetHash checks if the bit corresponding to the given hash value is set to 1.
+func (bf *RetouchedBloomFilter) GetHash(hash uint64) bool {
+	return bf.filter.GetHash(hash)
+}
+
+// BitsCount returns the total number of bits that are set to 1 in the bloom filter.
+func (bf *RetouchedBloomFilter) BitsCount() uint64 {
+	return bf.filter.BitsCount()
+}
+
+// Bits returns a copy of the Bits struct representing the bits of the bloom filter.
+// Modifying the returned Bits will not affect the internal state of the bloom filter.
+func (bf *RetouchedBloomFilter) Bits() Bits {
+	return bf.filter.Bits()
+}
+
+// ClearFalsePositive records the given data as a known false positive and clears one of its bits, so it is no longer found.
+// It returns the amount of added items that set the cleared bit, which might now be false negatives, and false if the data wasn't found to begin with.
+// The data must really be a false positive, clearing an added item makes it a false negative as well.
+func (bf *RetouchedBloomFilter) ClearFalsePositive(data []byte) (falseNegatives uint64, cleared bool) {
+	indexes := make([]uint64, 0, len(bf.hashes))
+	for _, hash := range bf.derived.all(bf.hashes, data) {
+		index := bf.filter.index(hash)
+		if !bf.filter.bits.Getbit(index) {
+			return 0, false
+		}
+		indexes = append(indexes, index)
+	}
+	// A hash function that maps to the same bit as another would count the false positive twice
+	slices.Sort(indexes)
+	indexes = slices.Compact(indexes)
+	for _, index := range indexes {
+		bf.falsePositives.Increment(index)
+	}
+
+	index := bf.choose(indexes)
+	falseNegatives = bf.items.Get(index)
+	bf.filter.bits.Clearbit(index)
+	bf.items.Set(index, 0)
+	bf.falseNegatives += falseNegatives
+
+	return falseNegatives, true
+}
+
+// FalseNegatives returns the total amount of added items that set a cleared bit, which might now be false negatives.
+func (bf *RetouchedBloomFilter) FalseNegatives() uint64 {
+	return bf.falseNegatives
+}
+
+// choose returns the bit to clear out of the set bits of a false positive.
+func (bf *RetouchedBloomFilter) choose(indexes []uint64) uint64 {
+	if bf.strategy == RetouchRandom {
+		return indexes[bf.rng.IntN(len(indexes))]
+	}
+
+	// Every bit has at least the current false positive, so the ratio is always defined
+	best := indexes[0]
+	bestRatio := float64(bf.items.Get(best)) / float64(bf.falsePositives.Get(best))
+	for _, index := range indexes[1:] {
+		ratio := float64(bf.items.Get(index)) / float64(bf.falsePositives.Get(index))
+		if ratio < bestRatio {
+			best, bestRatio = index, ratio
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,171 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRetouched(t *testing.T, opts ...bloomfilters.RetouchedBloomFilterOptions) *bloomfilters.RetouchedBloomFilter {
+	t.Helper()
+
+	bf, err := bloomfilters.NewRetouchedBloomFilter(append([]bloomfilters.RetouchedBloomFilterOptions{
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithRandomSeed(42),
+	}, opts...)...)
+	require.NoError(t, err)
+
+	return bf
+}
+
+// retouchFalsePositives adds the items to the filter, then clears every false positive of the candidates.
+// It returns the amount of items that are no longer found, and the amount of false negatives reported by the filter.
+func retouchFalsePositives(t *testing.T, bf *bloomfilters.RetouchedBloomFilter, items, candidates [][]byte) (lost, reported uint64) {
+	t.Helper()
+
+	for _, item := range items {
+		bf.Add(item)
+	}
+	for _, candidate := range candidates {
+		if !bf.Test(candidate) {
+			continue
+		}
+		falseNegatives, cleared := bf.ClearFalsePositive(candidate)
+		require.True(t, cleared)
+		require.False(t, bf.Test(candidate), "Expected a cleared false positive to no longer be found")
+		reported += falseNegatives
+	}
+	for _, item := range items {
+		if !bf.Test(item) {
+			lost++
+		}
+	}
+
+	return lost, reported
+}
+
+func Test_RetouchedBloomFilter_ClearFalsePositive(t *testing.T) {
+	data := testutil.MoreBytes(1000, 16)
+	items, candidates := data[:150], data[150:]
+	bf := newRetouched(t)
+
+	lost, reported := retouchFalsePositives(t, bf, items, candidates)
+	assert.Positive(t, reported, "Expected the filter to have false positives to clear")
+	assert.Equal(t, reported, bf.FalseNegatives())
+	// Every lost item set a cleared bit, but an item can be counted once for every cleared bit it set
+	assert.LessOrEqual(t, lost, reported)
+	for _, candidate := range candidates {
+		assert.False(t, bf.Test(candidate), "Expected every false positive to be cleared")
+	}
+}
+
+func Test_RetouchedBloomFilter_Strategy(t *testing.T) {
+	data := testutil.MoreBytes(2000, 16)
+	items, candidates := data[:300], data[300:]
+
+	randomLost, random := retouchFalsePositives(t, newRetouched(t, bloomfilters.WithRetouchStrategy(bloomfilters.RetouchRandom)), items, candidates)
+	ratioLost, ratio := retouchFalsePositives(t, newRetouched(t, bloomfilters.WithRetouchStrategy(bloomfilters.RetouchRatio)), items, candidates)
+	assert.Less(t, ratio, random, "Expected the ratio strategy to introduce fewer false negatives")
+	assert.LessOrEqual(t, ratioLost, randomLost)
+}
+
+func Test_RetouchedBloomFilter_NotFound(t *testing.T) {
+	bf := newRetouched(t)
+	bf.Add([]byte("item"))
+	bits := bf.Bits()
+
+	falseNegatives, cleared := bf.ClearFalsePositive([]byte("missing"))
+	assert.False(t, cleared)
+	assert.Equal(t, uint64(0), falseNegatives)
+	assert.Equal(t, bits, bf.Bits(), "Expected nothing to be cleared for data that isn't found")
+}
+
+func Test_RetouchedBloomFilter_Seeded(t *testing.T) {
+	bits := bloomfilters.NewBits(64)
+	bits.Setbit(3)
+	bf := newRetouched(t, bloomfilters.WithBits(bits), bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{
+		func([]byte) uint64 { return 3 },
+	}))
+
+	// The bit was set by an item that was added before, so clearing it reports one false negative
+	falseNegatives, cleared := bf.ClearFalsePositive([]byte("anything"))
+	assert.True(t, cleared)
+	assert.Equal(t, uint64(1), falseNegatives)
+	assert.Equal(t, uint64(0), bf.BitsCount())
+}
+
+func Test_RetouchedBloomFilter_Invalid(t *testing.T) {
+	hashes := bloomfilters.WithDefaultHashFunctions()
+	size := bloomfilters.WithSize(1024)
+
+	tests := []struct {
+		name string
+		opts []bloomfilters.RetouchedBloomFilterOptions
+		err  error
+	}{
+		{"size", []bloomfilters.RetouchedBloomFilterOptions{hashes}, bloomfilters.ErrInvalidSize},
+		{"width", []bloomfilters.RetouchedBloomFilterOptions{size, hashes, bloomfilters.WithCounterWidth(0)}, bloomfilters.ErrInvalidCounterWidth},
+		{"strategy", []bloomfilters.RetouchedBloomFilterOptions{size, hashes, bloomfilters.WithRetouchStrategy(7)}, bloomfilters.ErrInvalidRetouchStrategy},
+		{"no hashes", []bloomfilters.RetouchedBloomFilterOptions{size}, bloomfilters.ErrRequiredHashFunction},
+		{"nil hash", []bloomfilters.RetouchedBloomFilterOptions{size, bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{nil})}, bloomfilters.ErrHashIsNil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bf, err := bloomfilters.NewRetouchedBloomFilter(tt.opts...)
+			require.ErrorIs(t, err, tt.err)
+			require.Nil(t, bf)
+		})
+	}
+}
+
+// Fuzz test for RetouchedBloomFilter ClearFalsePositive
+func Fuzz_RetouchedBloomFilter_ClearFalsePositive(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		bf := newRetouched(t)
+		bf.Add(data)
+		require.True(t, bf.Test(data))
+
+		falseNegatives, cleared := bf.ClearFalsePositive(data)
+		require.True(t, cleared)
+		require.False(t, bf.Test(data), "Expected cleared data to no longer be found")
+		require.Positive(t, falseNegatives, "Expected the added data to be reported as a false negative")
+		require.Equal(t, falseNegatives, bf.FalseNegatives())
+	})
+}
+
+// Example of clearing a known false positive from a RetouchedBloomFilter
+func ExampleRetouchedBloomFilter() {
+	bf, _ := bloomfilters.NewRetouchedBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithDefaultHashFunctions(),
+	)
+
+	bf.Add([]byte("blocked"))
+
+	// Clearing data that isn't found does nothing
+	_, cleared := bf.ClearFalsePositive([]byte("allowed"))
+	fmt.Println(cleared)
+
+	// Clearing an added item makes it a false negative, which is reported
+	falseNegatives, cleared := bf.ClearFalsePositive([]byte("blocked"))
+	fmt.Println(cleared, falseNegatives)
+	fmt.Println(bf.Test([]byte("blocked")))
+
+	// Output:
+	// false
+	// true 1
+	// false
+}
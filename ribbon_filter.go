@@ -0,0 +1,175 @@
+package bloomfilters
+
+import (
+	"errors"
+	"iter"
+	"math"
+	"math/bits"
+	"slices"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+const (
+	// RibbonWidth is the width in slots of the band of coefficients of a single key in a [RibbonFilter].
+	RibbonWidth = 64
+	// ribbonMaxAttempts is the amount of seeds tried before building a ribbon filter fails, every attempt adds more slack slots.
+	ribbonMaxAttempts = 32
+	// ribbonSlack is the fraction of slack slots of the first attempt, and ribbonSlackStep the fraction added for every next attempt.
+	ribbonSlack     = 0.04
+	ribbonSlackStep = 0.02
+
+	// Salts to derive independent coefficients and results from a single mixed hash.
+	ribbonCoefficientSalt uint64 = 0x2545f4914f6cdd1d
+	ribbonResultSalt      uint64 = 0x61c8864680b583eb
+)
+
+var (
+	ErrInvalidBitsPerKey = errors.New("bits per key must be between 1 and 64")
+	ErrRibbonBuildFailed = errors.New("could not build ribbon filter, too many attempts")
+)
+
+// RibbonFilter is a static filter built once from a known set of keys, based on "Ribbon filter: practically smaller than Bloom and Xor" by Dillinger and Walzer.
+// Every key gets a start slot and a random band of [RibbonWidth] coefficients, and the xor of the solution slots selected by the band equals the fingerprint of the key.
+// The banded linear system is solved by Gaussian elimination while inserting keys, followed by back substitution.
+// With a few percent of slack slots it gets within a few percent of the information theoretic bound of log2(1/p) bits per key,
+// where a bloom filter needs about 44% more, see bloomsettings.BloomBitsPerKey.
+// Keys are hashed with xxHash64, keys can't be added or removed after the filter is built.
+type RibbonFilter struct {
+	seed      uint64
+	starts    uint64
+	keys      uint64
+	solution  Counters
+	resultBit uint8
+}
+
+// BuildRibbonFilter builds a ribbon filter from the given keys, storing a fingerprint of bitsPerKey bits per slot for a false positive rate of 2^-bitsPerKey.
+// Duplicate keys are allowed. It returns [ErrInvalidBitsPerKey] if bitsPerKey is not between 1 and 64,
+// and [ErrRibbonBuildFailed] if the linear system could not be solved, which is extremely unlikely.
+func BuildRibbonFilter(keys iter.Seq[[]byte], bitsPerKey uint8) (*RibbonFilter, error) {
+	if bitsPerKey < 1 || bitsPerKey > 64 {
+		return nil, ErrInvalidBitsPerKey
+	}
+
+	var hashes []uint64
+	for key := range keys {
+		hashes = append(hashes, bloomhashes.XXHash64(key))
+	}
+	// Duplicate keys would produce the same row twice, which makes the system unsolvable
+	slices.Sort(hashes)
+	hashes = slices.Compact(hashes)
+
+	rng := uint64(0x853c49e6748fea9b)
+	for attempt := range ribbonMaxAttempts {
+		slack := ribbonSlack + ribbonSlackStep*float64(attempt)
+		rf := &RibbonFilter{
+			seed:      splitmix64(&rng),
+			starts:    max(uint64(math.Ceil(float64(len(hashes))*(1+slack))), 1),
+			keys:      uint64(len(hashes)),
+			resultBit: bitsPerKey,
+		}
+		if rf.solve(hashes) {
+			return rf, nil
+		}
+	}
+
+	return nil, ErrRibbonBuildFailed
+}
+
+// Test checks if the given data is likely to be one of the keys the filter was built from.
+func (rf *RibbonFilter) Test(data []byte) bool {
+	return rf.Contains(bloomhashes.XXHash64(data))
+}
+
+// Contains checks if the given xxHash64 hash of a key is likely to be one of the keys the filter was built from.
+func (rf *RibbonFilter) Contains(hash uint64) bool {
+	start, coefficients, result := rf.row(hash)
+
+	var value uint64
+	for coefficients != 0 {
+		offset := uint64(bits.TrailingZeros64(coefficients))
+		value ^= rf.solution.Get(start + offset)
+		coefficients &= coefficients - 1
+	}
+
+	return value == result
+}
+
+// Keys returns the amount of distinct keys the filter was built from.
+func (rf *RibbonFilter) Keys() uint64 {
+	return rf.keys
+}
+
+// Slots returns the amount of solution slots, the amount of keys plus the slack needed to solve the linear system.
+func (rf *RibbonFilter) Slots() uint64 {
+	return rf.starts + RibbonWidth - 1
+}
+
+// SizeInBits returns the size of the solution in bits.
+func (rf *RibbonFilter) SizeInBits() uint64 {
+	return rf.Slots() * uint64(rf.resultBit)
+}
+
+// BitsPerKey returns the size of the solution in bits divided by the amount of keys, including the slack slots.
+func (rf *RibbonFilter) BitsPerKey() float64 {
+	return float64(rf.SizeInBits()) / float64(max(rf.keys, 1))
+}
+
+// FalsePositiveRate returns the false positive rate of the filter, see bloomsettings.RibbonFalsePositiveRate.
+func (rf *RibbonFilter) FalsePositiveRate() float64 {
+	return 1 / math.Exp2(float64(rf.resultBit))
+}
+
+// solve bands the rows of all keys and solves them into the solution, it returns false if a row turned out to be linearly dependent.
+func (rf *RibbonFilter) solve(hashes []uint64) bool {
+	slots := rf.Slots()
+	coefficients := make([]uint64, slots)
+	results := make([]uint64, slots)
+
+	// Gaussian elimination, every slot holds a row whose first coefficient is that slot
+	for _, hash := range hashes {
+		i, c, r := rf.row(hash)
+		for {
+			if coefficients[i] == 0 {
+				coefficients[i], results[i] = c, r
+
+				break
+			}
+			c ^= coefficients[i]
+			r ^= results[i]
+			if c == 0 {
+				return false
+			}
+			shift := uint64(bits.TrailingZeros64(c))
+			i += shift
+			c >>= shift
+		}
+	}
+
+	// Back substitution, from the last slot to the first
+	rf.solution = NewCounters(slots, rf.resultBit)
+	for i := slots; i > 0; i-- {
+		slot := i - 1
+		value := results[slot]
+		for c := coefficients[slot] &^ 1; c != 0; c &= c - 1 {
+			value ^= rf.solution.Get(slot + uint64(bits.TrailingZeros64(c)))
+		}
+		rf.solution.Set(slot, value)
+	}
+
+	return true
+}
+
+// row returns the start slot, the coefficients and the expected result of the given key hash.
+func (rf *RibbonFilter) row(hash uint64) (start, coefficients, result uint64) {
+	h := xorMix(hash, rf.seed)
+	start, _ = bits.Mul64(h, rf.starts)
+	// The first coefficient is always 1, so every row has a pivot at its start slot
+	coefficients = xorMix(h, ribbonCoefficientSalt) | 1
+	result = xorMix(h, ribbonResultSalt)
+	if rf.resultBit < 64 {
+		result &= uint64(1)<<rf.resultBit - 1
+	}
+
+	return start, coefficients, result
+}
@@ -0,0 +1,64 @@
+package bloomfilters
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrInvalidRibbonData = errors.New("ribbon filter data is invalid")
+
+// ribbonHeaderSize is the size of the seed, start count, key count and bits per key at the start of a marshalled ribbon filter.
+const ribbonHeaderSize = 8 + 8 + 8 + 1
+
+var (
+	_ encoding.BinaryMarshaler   = (*RibbonFilter)(nil)
+	_ encoding.BinaryUnmarshaler = (*RibbonFilter)(nil)
+)
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler].
+func (rf *RibbonFilter) UnmarshalBinary(data []byte) error {
+	if len(data) < ribbonHeaderSize {
+		return ErrInvalidRibbonData
+	}
+
+	restored := RibbonFilter{
+		seed:      binary.LittleEndian.Uint64(data),
+		starts:    binary.LittleEndian.Uint64(data[8:]),
+		keys:      binary.LittleEndian.Uint64(data[16:]),
+		resultBit: data[24],
+	}
+	words := data[ribbonHeaderSize:]
+	// Every slot takes at least a bit, so check the amount of slots before it is used to calculate the size
+	if restored.starts == 0 || restored.starts > uint64(len(words))*8 || restored.resultBit < 1 || restored.resultBit > 64 {
+		return ErrInvalidRibbonData
+	}
+	if uint64(len(words)) != max((restored.SizeInBits()+63)/64, 1)*8 {
+		return ErrInvalidRibbonData
+	}
+
+	restored.solution = NewCounters(restored.Slots(), restored.resultBit)
+	for i := range restored.solution.data {
+		restored.solution.data[i] = binary.LittleEndian.Uint64(words[i*8:])
+	}
+
+	*rf = restored
+
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler].
+// The data is the seed, the amount of start slots and the amount of keys as little-endian uint64, the bits per key as a byte,
+// followed by the little-endian words of the packed solution.
+func (rf *RibbonFilter) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, ribbonHeaderSize+len(rf.solution.data)*8)
+	binary.LittleEndian.PutUint64(data, rf.seed)
+	binary.LittleEndian.PutUint64(data[8:], rf.starts)
+	binary.LittleEndian.PutUint64(data[16:], rf.keys)
+	data[24] = rf.resultBit
+	for i, word := range rf.solution.data {
+		binary.LittleEndian.PutUint64(data[ribbonHeaderSize+i*8:], word)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,146 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RibbonFilter_NoFalseNegatives(t *testing.T) {
+	keys := testutil.MoreBytes(10000, 16)
+
+	for _, bitsPerKey := range []uint8{1, 7, 8, 16, 64} {
+		t.Run(fmt.Sprintf("%d bits", bitsPerKey), func(t *testing.T) {
+			rf, err := bloomfilters.BuildRibbonFilter(slices.Values(keys), bitsPerKey)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(len(keys)), rf.Keys())
+
+			for _, key := range keys {
+				require.True(t, rf.Test(key), "Expected key to be found in the ribbon filter")
+			}
+		})
+	}
+}
+
+func Test_RibbonFilter_FalsePositiveRate(t *testing.T) {
+	data := testutil.MoreBytes(210000, 16)
+	keys, others := data[:10000], data[10000:]
+
+	rf, err := bloomfilters.BuildRibbonFilter(slices.Values(keys), 7)
+	require.NoError(t, err)
+
+	var positives int
+	for _, d := range others {
+		if rf.Test(d) {
+			positives++
+		}
+	}
+	expected := bloomsettings.RibbonFalsePositiveRate(7)
+	assert.Equal(t, expected, rf.FalsePositiveRate())
+	assert.InDelta(t, expected, float64(positives)/float64(len(others)), expected*0.1)
+}
+
+func Test_RibbonFilter_Space(t *testing.T) {
+	keys := testutil.MoreBytes(100000, 16)
+
+	rf, err := bloomfilters.BuildRibbonFilter(slices.Values(keys), 8)
+	require.NoError(t, err)
+
+	// Compare against a bloom filter of the same size and amount of keys
+	m := rf.SizeInBits()
+	n := uint64(len(keys))
+	bloomRate := bloomsettings.FalsePositiveRate(m, n, bloomsettings.OptimalHashFunctions(m, n))
+	assert.Less(t, rf.FalsePositiveRate(), bloomRate, "Expected a lower false positive rate than a bloom filter of the same size")
+
+	minimum := bloomsettings.MinimumBitsPerKey(rf.FalsePositiveRate())
+	assert.Less(t, rf.BitsPerKey(), minimum*1.1, "Expected to stay within 10% of the information theoretic bound")
+	assert.Less(t, rf.BitsPerKey(), bloomsettings.BloomBitsPerKey(rf.FalsePositiveRate()))
+}
+
+func Test_RibbonFilter_Duplicates(t *testing.T) {
+	keys := testutil.MoreBytes(100, 16)
+	keys = append(keys, keys...)
+
+	rf, err := bloomfilters.BuildRibbonFilter(slices.Values(keys), 8)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), rf.Keys())
+	for _, key := range keys {
+		require.True(t, rf.Test(key))
+	}
+}
+
+func Test_RibbonFilter_Contains(t *testing.T) {
+	keys := testutil.MoreBytes(1000, 16)
+	rf, err := bloomfilters.BuildRibbonFilter(slices.Values(keys), 12)
+	require.NoError(t, err)
+
+	for _, key := range keys {
+		require.True(t, rf.Contains(bloomhashes.XXHash64(key)))
+	}
+}
+
+func Test_RibbonFilter_InvalidBitsPerKey(t *testing.T) {
+	for _, bitsPerKey := range []uint8{0, 65} {
+		rf, err := bloomfilters.BuildRibbonFilter(slices.Values([][]byte{[]byte("a")}), bitsPerKey)
+		require.ErrorIs(t, err, bloomfilters.ErrInvalidBitsPerKey)
+		require.Nil(t, rf)
+	}
+}
+
+func Test_RibbonFilter_Marshal(t *testing.T) {
+	keys := testutil.MoreBytes(1000, 16)
+	rf, err := bloomfilters.BuildRibbonFilter(slices.Values(keys), 9)
+	require.NoError(t, err)
+
+	data, err := rf.MarshalBinary()
+	require.NoError(t, err)
+
+	var restored bloomfilters.RibbonFilter
+	require.NoError(t, restored.UnmarshalBinary(data))
+	assert.Equal(t, *rf, restored)
+	for _, key := range keys {
+		require.True(t, restored.Test(key))
+	}
+
+	require.ErrorIs(t, restored.UnmarshalBinary(data[:10]), bloomfilters.ErrInvalidRibbonData)
+	require.ErrorIs(t, restored.UnmarshalBinary(data[:len(data)-8]), bloomfilters.ErrInvalidRibbonData)
+}
+
+// Fuzz test for RibbonFilter Test
+func Fuzz_RibbonFilter_Test(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		rf, err := bloomfilters.BuildRibbonFilter(slices.Values([][]byte{data, []byte("other")}), 8)
+		require.NoError(t, err)
+		require.True(t, rf.Test(data), "Expected the key to be found in the filter")
+	})
+}
+
+// Example of building a RibbonFilter from a sequence of keys
+func ExampleRibbonFilter() {
+	keys := [][]byte{[]byte("apple"), []byte("banana"), []byte("cherry")}
+
+	// 7 bits per key for a false positive rate of 1/128
+	rf, _ := bloomfilters.BuildRibbonFilter(slices.Values(keys), 7)
+
+	fmt.Println(rf.Test([]byte("apple")))
+	fmt.Println(rf.Test([]byte("cherry")))
+	fmt.Println(rf.FalsePositiveRate())
+
+	// Output:
+	// true
+	// true
+	// 0.0078125
+}
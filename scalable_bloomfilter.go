@@ -0,0 +1,227 @@
+package bloomfilters
+
+import (
+	"errors"
+	"math"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+)
+
+const (
+	// DefaultGrowthFactor is the default factor by which each new stage of a scalable bloom filter is larger than the previous one.
+	DefaultGrowthFactor = 2.0
+	// DefaultTighteningRatio is the default ratio by which the false positive rate of each new stage of a scalable bloom filter is reduced.
+	DefaultTighteningRatio = 0.85
+	// DefaultFalsePositiveRate is the default false positive rate a filter aims for when none is given.
+	DefaultFalsePositiveRate = 0.01
+)
+
+var (
+	ErrInvalidGrowthFactor      = errors.New("growth factor must be at least 1")
+	ErrInvalidTighteningRatio   = errors.New("tightening ratio must be between 0 and 1")
+	ErrInvalidFalsePositiveRate = errors.New("false positive rate must be between 0 and 1")
+)
+
+var _ IBloomFilter = &ScalableBloomFilter{}
+
+// ScalableBloomFilter is a bloom filter that grows automatically as items are added, based on "Scalable Bloom Filters" by Almeida et al.
+// It chains [BloomFilter] stages, each stage is larger than the previous one by the growth factor and has a tighter false positive rate by the tightening ratio.
+// A new stage is added once the fill ratio of the current stage passes the ratio at which it would exceed its false positive rate,
+// so the compound false positive rate stays below the configured rate no matter how many items are added.
+type ScalableBloomFilter struct {
+	stages  []scalableStage
+	hashes  []bloomhashes.HashFunction
+	derived derivedHashes
+
+	initial    Bits
+	growth     float64
+	tightening float64
+	fpr        float64
+}
+
+type scalableStage struct {
+	filter  *BloomFilter
+	set     uint64 // Amount of bits set, tracked so we don't have to count all bits on every add
+	maxFill float64
+}
+
+// NewScalableBloomFilter creates a new scalable bloom filter with the given options, the size given is used for the first stage.
+// It returns an error if the size of the bloom filter is invalid, if any of the hash functions are nil or if the growth parameters are out of range.
+func NewScalableBloomFilter(opts ...ScalableBloomFilterOptions) (*ScalableBloomFilter, error) {
+	sbf := &ScalableBloomFilter{
+		growth:     DefaultGrowthFactor,
+		tightening: DefaultTighteningRatio,
+		fpr:        DefaultFalsePositiveRate,
+	}
+	for _, opt := range opts {
+		opt.applyScalable(sbf)
+	}
+	if sbf.initial.Size() == 0 {
+		return nil, ErrInvalidSize
+	}
+	if sbf.growth < 1 {
+		return nil, ErrInvalidGrowthFactor
+	}
+	if sbf.tightening <= 0 || sbf.tightening >= 1 {
+		return nil, ErrInvalidTighteningRatio
+	}
+	if sbf.fpr <= 0 || sbf.fpr >= 1 {
+		return nil, ErrInvalidFalsePositiveRate
+	}
+
+	first, err := NewBloomFilter(WithBits(sbf.initial), WithHashFunctions(sbf.hashes))
+	if err != nil {
+		return nil, err
+	}
+	sbf.stages = []scalableStage{{
+		filter:  first,
+		set:     first.BitsCount(),
+		maxFill: sbf.maxFill(0),
+	}}
+
+	return sbf, nil
+}
+
+// Add adds the given data to the newest stage of the bloom filter, adding a new stage when the current one is full.
+// Data that is already (likely) in the filter is not added again, so it won't take up any capacity.
+func (sbf *ScalableBloomFilter) Add(data []byte) {
+	hashes := sbf.hash(data)
+	if sbf.testHashes(hashes) {
+		return
+	}
+
+	sbf.setHashes(hashes...)
+}
+
+// Test checks if the given data is likely to be in any of the stages of the bloom filter.
+func (sbf *ScalableBloomFilter) Test(data []byte) bool {
+	return sbf.testHashes(sbf.hash(data))
+}
+
+// SetHash sets the bit at the index corresponding to the given hash value in the newest stage to 1.
+func (sbf *ScalableBloomFilter) SetHash(hash uint64) {
+	sbf.setHashes(hash)
+}
+
+// GetHash checks if the bit at the index corresponding to the given hash value is set to 1 in any of the stages.
+func (sbf *ScalableBloomFilter) GetHash(hash uint64) bool {
+	for _, stage := range sbf.stages {
+		if stage.filter.GetHash(hash) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BitsCount returns the total number of bits that are set to 1 over all the stages of the bloom filter.
+func (sbf *ScalableBloomFilter) BitsCount() uint64 {
+	var count uint64
+	for _, stage := range sbf.stages {
+		count += stage.set
+	}
+
+	return count
+}
+
+// Bits returns a copy of the bits of all stages, concatenated from the oldest to the newest stage.
+// Modifying the returned Bits will not affect the internal state of the bloom filter.
+func (sbf *ScalableBloomFilter) Bits() Bits {
+	var size uint64
+	for _, stage := range sbf.stages {
+		size += stage.filter.bits.Size()
+	}
+
+	words := make([]uint64, 0, size/64)
+	for _, stage := range sbf.stages {
+		words = append(words, stage.filter.bits.data...)
+	}
+
+	return Bits{data: words}
+}
+
+// Stages returns the amount of stages the bloom filter currently has.
+func (sbf *ScalableBloomFilter) Stages() int {
+	return len(sbf.stages)
+}
+
+// FalsePositiveRate estimates the current compound false positive rate of the bloom filter, based on the fill ratio of each stage.
+func (sbf *ScalableBloomFilter) FalsePositiveRate() float64 {
+	k := float64(len(sbf.hashes))
+	negative := 1.0
+	for _, stage := range sbf.stages {
+		negative *= 1 - math.Pow(stage.fill(), k)
+	}
+
+	return 1 - negative
+}
+
+func (sbf *ScalableBloomFilter) hash(data []byte) []uint64 {
+	hashes := make([]uint64, 0, len(sbf.hashes))
+	for _, hash := range sbf.derived.all(sbf.hashes, data) {
+		hashes = append(hashes, hash)
+	}
+
+	return hashes
+}
+
+func (sbf *ScalableBloomFilter) testHashes(hashes []uint64) bool {
+	for _, stage := range sbf.stages {
+		if stage.testHashes(hashes) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (sbf *ScalableBloomFilter) setHashes(hashes ...uint64) {
+	stage := &sbf.stages[len(sbf.stages)-1]
+	for _, hash := range hashes {
+		if !stage.filter.GetHash(hash) {
+			stage.filter.SetHash(hash)
+			stage.set++
+		}
+	}
+
+	if stage.fill() > stage.maxFill {
+		sbf.grow()
+	}
+}
+
+// grow adds a new, larger and stricter, stage to the bloom filter.
+func (sbf *ScalableBloomFilter) grow() {
+	i := len(sbf.stages)
+	size := float64(sbf.initial.Size()) * math.Pow(sbf.growth, float64(i))
+
+	sbf.stages = append(sbf.stages, scalableStage{
+		filter: &BloomFilter{
+			bits:   NewBits(uint64(math.Ceil(size))),
+			hashes: sbf.hashes,
+		},
+		maxFill: sbf.maxFill(i),
+	})
+}
+
+// maxFill returns the fill ratio at which the stage with the given index reaches its false positive rate.
+// The stages false positive rates form a geometric series: P0 * r^i, where P0 is chosen so the series sums to the configured rate.
+func (sbf *ScalableBloomFilter) maxFill(stage int) float64 {
+	p := sbf.fpr * (1 - sbf.tightening) * math.Pow(sbf.tightening, float64(stage))
+
+	// The false positive rate of a stage is its fill ratio to the power of k
+	return math.Pow(p, 1/float64(len(sbf.hashes)))
+}
+
+func (s *scalableStage) fill() float64 {
+	return float64(s.set) / float64(s.filter.bits.Size())
+}
+
+func (s *scalableStage) testHashes(hashes []uint64) bool {
+	for _, hash := range hashes {
+		if !s.filter.GetHash(hash) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,149 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ScalableBloomFilter_Grows(t *testing.T) {
+	data := testutil.MoreBytes(10_000, 32)
+
+	sbf, err := bloomfilters.NewScalableBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithDefaultHashFunctions(),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 1, sbf.Stages())
+
+	for _, d := range data {
+		sbf.Add(d)
+	}
+	assert.Greater(t, sbf.Stages(), 1, "Expected the filter to have grown")
+
+	for _, d := range data {
+		require.True(t, sbf.Test(d), "Expected added data to be found in the filter")
+	}
+}
+
+func Test_ScalableBloomFilter_FalsePositiveRate(t *testing.T) {
+	const fpr = 0.01
+	data := testutil.MoreBytes(20_000, 32)
+	added, other := data[:10_000], data[10_000:]
+
+	sbf, err := bloomfilters.NewScalableBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithFalsePositiveRate(fpr),
+	)
+	require.NoError(t, err)
+
+	for _, d := range added {
+		sbf.Add(d)
+	}
+	assert.LessOrEqual(t, sbf.FalsePositiveRate(), fpr, "Expected the estimated false positive rate to stay below the target")
+
+	falsePositives := 0
+	for _, d := range other {
+		if sbf.Test(d) {
+			falsePositives++
+		}
+	}
+	assert.LessOrEqual(t, float64(falsePositives)/float64(len(other)), fpr, "Expected the measured false positive rate to stay below the target")
+}
+
+func Test_ScalableBloomFilter_Bits(t *testing.T) {
+	data := testutil.MoreBytes(1000, 32)
+
+	sbf, err := bloomfilters.NewScalableBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithGrowthFactor(2),
+		bloomfilters.WithDefaultHashFunctions(),
+	)
+	require.NoError(t, err)
+	for _, d := range data {
+		sbf.Add(d)
+	}
+	require.Greater(t, sbf.Stages(), 1)
+
+	// Each stage doubles in size, so all stages together are 1024 * (2^stages - 1) bits
+	bits := sbf.Bits()
+	assert.Equal(t, uint64(1024)*(1<<sbf.Stages()-1), bits.Size())
+	assert.Equal(t, bits.BitsCount(), sbf.BitsCount(), "Expected the tracked bits count to match the actual bits")
+}
+
+func Test_ScalableBloomFilter_InvalidOptions(t *testing.T) {
+	testCases := map[string]struct {
+		opt bloomfilters.ScalableBloomFilterOptions
+		err error
+	}{
+		"GrowthFactor":           {opt: bloomfilters.WithGrowthFactor(0.5), err: bloomfilters.ErrInvalidGrowthFactor},
+		"TighteningRatio_Zero":   {opt: bloomfilters.WithTighteningRatio(0), err: bloomfilters.ErrInvalidTighteningRatio},
+		"TighteningRatio_One":    {opt: bloomfilters.WithTighteningRatio(1), err: bloomfilters.ErrInvalidTighteningRatio},
+		"FalsePositiveRate_Zero": {opt: bloomfilters.WithFalsePositiveRate(0), err: bloomfilters.ErrInvalidFalsePositiveRate},
+		"FalsePositiveRate_One":  {opt: bloomfilters.WithFalsePositiveRate(1), err: bloomfilters.ErrInvalidFalsePositiveRate},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sbf, err := bloomfilters.NewScalableBloomFilter(
+				bloomfilters.WithSize(1024),
+				bloomfilters.WithDefaultHashFunctions(),
+				tc.opt,
+			)
+			require.ErrorIs(t, err, tc.err)
+			require.Nil(t, sbf)
+		})
+	}
+}
+
+// Fuzz test for ScalableBloomFilter Add and Test
+func Fuzz_ScalableBloomFilter_AddTest(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		sbf, err := bloomfilters.NewScalableBloomFilter(
+			bloomfilters.WithSize(64),
+			bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{
+				bloomhashes.Fnv1_64,
+				bloomhashes.MD5,
+			}),
+		)
+		require.NoError(t, err)
+
+		sbf.Add(data)
+		require.True(t, sbf.Test(data), "Expected added data to be found in the filter")
+	})
+}
+
+// Example of basic ScalableBloomFilter usage
+func ExampleScalableBloomFilter() {
+	// Start small, the filter grows as more items are added
+	sbf, _ := bloomfilters.NewScalableBloomFilter(
+		bloomfilters.WithSize(256),
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithFalsePositiveRate(0.01),
+	)
+
+	for i := range 1000 {
+		sbf.Add(fmt.Appendf(nil, "item-%d", i))
+	}
+
+	fmt.Println(sbf.Stages() > 1)
+	fmt.Println(sbf.Test([]byte("item-42")))
+	fmt.Println(sbf.Test([]byte("item-1000")))
+
+	// Output:
+	// true
+	// true
+	// false
+}
@@ -0,0 +1,192 @@
+package bloomfilters
+
+import (
+	"errors"
+	"time"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/extensions/xtime"
+)
+
+// DefaultGenerations is the default amount of generations a sliding window bloom filter keeps.
+const DefaultGenerations = 4
+
+var (
+	ErrInvalidInterval    = errors.New("interval must be positive")
+	ErrInvalidGenerations = errors.New("generations must be at least 2")
+)
+
+var _ IBloomFilter = &SlidingWindowBloomFilter{}
+
+// SlidingWindowBloomFilter is a bloom filter that forgets items after a while, by keeping a ring of [BloomFilter] generations.
+// Items are added to the newest generation, and every interval the oldest generation is cleared and becomes the newest.
+// Test checks all generations, so an item is found for at least [SlidingWindowBloomFilter.Window], (generations - 1) intervals,
+// and forgotten after at most generations intervals.
+type SlidingWindowBloomFilter struct {
+	generations []*BloomFilter
+	current     int
+	rotated     time.Time
+
+	bits     Bits
+	hashes   []bloomhashes.HashFunction
+	derived  derivedHashes
+	interval time.Duration
+	count    int
+	clock    xtime.Clock
+}
+
+// NewSlidingWindowBloomFilter creates a new sliding window bloom filter with the given options, the size is the size in bits of a single generation.
+// An interval must be given with [WithInterval]. It returns an error if the size, the interval or the amount of generations is invalid, or if any of the hash functions are nil.
+func NewSlidingWindowBloomFilter(opts ...SlidingWindowBloomFilterOptions) (*SlidingWindowBloomFilter, error) {
+	bf := &SlidingWindowBloomFilter{
+		count: DefaultGenerations,
+		clock: xtime.SystemClock{},
+	}
+	for _, opt := range opts {
+		opt.applySliding(bf)
+	}
+	if bf.bits.Size() == 0 {
+		return nil, ErrInvalidSize
+	}
+	if bf.interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	if bf.count < 2 {
+		return nil, ErrInvalidGenerations
+	}
+	if len(bf.hashes) == 0 {
+		return nil, ErrRequiredHashFunction
+	}
+	for _, hashFunc := range bf.hashes {
+		if hashFunc == nil {
+			return nil, ErrHashIsNil
+		}
+	}
+
+	bf.generations = make([]*BloomFilter, bf.count)
+	for i := range bf.generations {
+		bits := NewBits(bf.bits.Size())
+		if i == 0 {
+			// The given bits are the newest generation
+			bits = bf.bits
+		}
+		bf.generations[i] = &BloomFilter{bits: bits, hashes: bf.hashes}
+	}
+	bf.rotated = bf.clock.Now()
+
+	return bf, nil
+}
+
+// Add adds the given data to the newest generation of the bloom filter.
+func (bf *SlidingWindowBloomFilter) Add(data []byte) {
+	indexes := bf.indexes(data)
+	bf.rotate()
+	bf.addIndexes(indexes)
+}
+
+// Test checks if the given data is likely to be in any generation of the bloom filter, which means it was added within the window.
+func (bf *SlidingWindowBloomFilter) Test(data []byte) bool {
+	indexes := bf.indexes(data)
+	bf.rotate()
+
+	return bf.testIndexes(indexes)
+}
+
+// SetHash sets the bit corresponding to the given hash value in the newest generation.
+func (bf *SlidingWindowBloomFilter) SetHash(hash uint64) {
+	bf.rotate()
+	bf.generations[bf.current].SetHash(hash)
+}
+
+// GetHash checks if the bit corresponding to the given hash value is set in any generation.
+func (bf *SlidingWindowBloomFilter) GetHash(hash uint64) bool {
+	bf.rotate()
+	for _, generation := range bf.generations {
+		if generation.GetHash(hash) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BitsCount returns the total number of bits that are set to 1 in any generation.
+func (bf *SlidingWindowBloomFilter) BitsCount() uint64 {
+	bits := bf.Bits()
+
+	return bits.BitsCount()
+}
+
+// Bits returns a Bits struct with every bit set that is set in any generation.
+// Modifying the returned Bits will not affect the internal state of the bloom filter.
+func (bf *SlidingWindowBloomFilter) Bits() Bits {
+	bf.rotate()
+
+	union := NewBits(bf.bits.Size())
+	for _, generation := range bf.generations {
+		for i, word := range generation.bits.data {
+			union.data[i] |= word
+		}
+	}
+
+	return union
+}
+
+// Window returns the duration an added item is guaranteed to be found for, (generations - 1) intervals.
+func (bf *SlidingWindowBloomFilter) Window() time.Duration {
+	return bf.interval * time.Duration(len(bf.generations)-1)
+}
+
+// Generations returns the amount of generations of the bloom filter.
+func (bf *SlidingWindowBloomFilter) Generations() int {
+	return len(bf.generations)
+}
+
+// rotate clears the oldest generations for every interval that passed since the last rotation.
+func (bf *SlidingWindowBloomFilter) rotate() {
+	now := bf.clock.Now()
+	steps := int64(now.Sub(bf.rotated) / bf.interval)
+	if steps <= 0 {
+		return
+	}
+
+	bf.rotated = bf.rotated.Add(time.Duration(steps) * bf.interval)
+	for range min(steps, int64(len(bf.generations))) {
+		bf.current = (bf.current + 1) % len(bf.generations)
+		clear(bf.generations[bf.current].bits.data)
+	}
+}
+
+// indexes returns the bit index of every hash function, the same for every generation since they have the same size.
+func (bf *SlidingWindowBloomFilter) indexes(data []byte) []uint64 {
+	indexes := make([]uint64, len(bf.hashes))
+	for i, hash := range bf.derived.all(bf.hashes, data) {
+		indexes[i] = bf.generations[0].index(hash)
+	}
+
+	return indexes
+}
+
+func (bf *SlidingWindowBloomFilter) addIndexes(indexes []uint64) {
+	for _, index := range indexes {
+		bf.generations[bf.current].bits.Setbit(index)
+	}
+}
+
+func (bf *SlidingWindowBloomFilter) testIndexes(indexes []uint64) bool {
+	for _, generation := range bf.generations {
+		found := true
+		for _, index := range indexes {
+			if !generation.bits.Getbit(index) {
+				found = false
+
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
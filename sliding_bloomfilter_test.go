@@ -0,0 +1,190 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/extensions/xtime"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slidingFilters returns a list of sliding window filter factories to test against.
+func slidingFilters() map[string]func(opts ...bloomfilters.SlidingWindowBloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+	return map[string]func(opts ...bloomfilters.SlidingWindowBloomFilterOptions) (bloomfilters.IBloomFilter, error){
+		"SlidingWindowBloomFilter": func(opts ...bloomfilters.SlidingWindowBloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewSlidingWindowBloomFilter(opts...)
+		},
+		"ConcurrentSlidingWindowBloomFilter": func(opts ...bloomfilters.SlidingWindowBloomFilterOptions) (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewConcurrentSlidingWindowBloomFilter(opts...)
+		},
+	}
+}
+
+func Test_SlidingWindowBloomFilter_Expiry(t *testing.T) {
+	for name, factory := range slidingFilters() {
+		t.Run(name, func(t *testing.T) {
+			clock := xtime.NewManualClock(time.Unix(1700000000, 0))
+			bf, err := factory(
+				bloomfilters.WithSize(4096),
+				bloomfilters.WithDefaultHashFunctions(),
+				bloomfilters.WithInterval(5*time.Minute),
+				bloomfilters.WithGenerations(3),
+				bloomfilters.WithClock(clock),
+			)
+			require.NoError(t, err)
+
+			bf.Add([]byte("hello"))
+			clock.Advance(4 * time.Minute)
+			bf.Add([]byte("world"))
+
+			// 10 minutes after "hello" was added, 2 rotations happened
+			clock.Advance(6 * time.Minute)
+			assert.True(t, bf.Test([]byte("hello")), "Expected 'hello' to be found within the window")
+			assert.True(t, bf.Test([]byte("world")))
+
+			// The generation of both items is cleared on the third rotation
+			clock.Advance(5 * time.Minute)
+			assert.False(t, bf.Test([]byte("hello")), "Expected 'hello' to be forgotten after the window")
+			assert.False(t, bf.Test([]byte("world")))
+			assert.Equal(t, uint64(0), bf.BitsCount())
+		})
+	}
+}
+
+func Test_SlidingWindowBloomFilter_Window(t *testing.T) {
+	data := testutil.MoreBytes(1000, 16)
+	clock := xtime.NewManualClock(time.Unix(0, 0))
+	bf, err := bloomfilters.NewSlidingWindowBloomFilter(
+		bloomfilters.WithSize(1<<16),
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithInterval(time.Minute),
+		bloomfilters.WithGenerations(4),
+		bloomfilters.WithClock(clock),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Minute, bf.Window())
+	assert.Equal(t, 4, bf.Generations())
+
+	// Add an item every second, and check every item added within the window is still found
+	for i, d := range data {
+		bf.Add(d)
+		for j := max(0, i-int(bf.Window()/time.Second)); j <= i; j++ {
+			require.True(t, bf.Test(data[j]), "Expected item %d to be found at %d seconds", j, i)
+		}
+		clock.Advance(time.Second)
+	}
+
+	// Long after the last add, nothing is left
+	clock.Advance(time.Hour)
+	assert.Equal(t, uint64(0), bf.BitsCount())
+}
+
+func Test_SlidingWindowBloomFilter_Invalid(t *testing.T) {
+	hashes := bloomfilters.WithDefaultHashFunctions()
+
+	tests := []struct {
+		name string
+		opts []bloomfilters.SlidingWindowBloomFilterOptions
+		err  error
+	}{
+		{"no interval", []bloomfilters.SlidingWindowBloomFilterOptions{bloomfilters.WithSize(1024), hashes}, bloomfilters.ErrInvalidInterval},
+		{"generations", []bloomfilters.SlidingWindowBloomFilterOptions{bloomfilters.WithSize(1024), bloomfilters.WithInterval(time.Second), bloomfilters.WithGenerations(1), hashes}, bloomfilters.ErrInvalidGenerations},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bf, err := bloomfilters.NewSlidingWindowBloomFilter(tt.opts...)
+			require.ErrorIs(t, err, tt.err)
+			require.Nil(t, bf)
+		})
+	}
+}
+
+func Test_ConcurrentSlidingWindowBloomFilter_Concurrent(t *testing.T) {
+	data := testutil.MoreBytes(800, 32)
+	clock := xtime.NewManualClock(time.Unix(0, 0))
+
+	bf, err := bloomfilters.NewConcurrentSlidingWindowBloomFilter(
+		bloomfilters.WithSize(16384),
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithInterval(time.Minute),
+		bloomfilters.WithClock(clock),
+	)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	for i := range 8 {
+		chunk := data[i*100 : (i+1)*100]
+		wg.Go(func() {
+			for _, d := range chunk {
+				bf.Add(d)
+				clock.Advance(time.Millisecond)
+				assert.True(t, bf.Test(d))
+			}
+		})
+	}
+	wg.Wait()
+
+	for _, d := range data {
+		require.True(t, bf.Test(d), "Expected data added within the window to be found in the filter")
+	}
+}
+
+// Fuzz test for SlidingWindowBloomFilter Add and Test
+func Fuzz_SlidingWindowBloomFilter_AddTest(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		clock := xtime.NewManualClock(time.Unix(0, 0))
+		bf, err := bloomfilters.NewSlidingWindowBloomFilter(
+			bloomfilters.WithSize(1024),
+			bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.MD5}),
+			bloomfilters.WithInterval(time.Minute),
+			bloomfilters.WithGenerations(2),
+			bloomfilters.WithClock(clock),
+		)
+		require.NoError(t, err)
+
+		bf.Add(data)
+		clock.Advance(time.Minute)
+		require.True(t, bf.Test(data), "Expected data to be found within the window")
+		clock.Advance(time.Minute)
+		require.False(t, bf.Test(data), "Expected data to be forgotten after the window")
+	})
+}
+
+// Example of remembering keys for a limited time with a SlidingWindowBloomFilter
+func ExampleSlidingWindowBloomFilter() {
+	clock := xtime.NewManualClock(time.Unix(0, 0))
+
+	// Remember keys for at least 10 minutes, with 3 generations of 5 minutes
+	bf, _ := bloomfilters.NewSlidingWindowBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithInterval(5*time.Minute),
+		bloomfilters.WithGenerations(3),
+		bloomfilters.WithClock(clock),
+	)
+
+	bf.Add([]byte("apple"))
+	clock.Advance(10 * time.Minute)
+	fmt.Println(bf.Test([]byte("apple")))
+	clock.Advance(5 * time.Minute)
+	fmt.Println(bf.Test([]byte("apple")))
+	fmt.Println(bf.Window())
+
+	// Output:
+	// true
+	// false
+	// 10m0s
+}
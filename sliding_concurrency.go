@@ -0,0 +1,104 @@
+package bloomfilters
+
+import (
+	"time"
+
+	"github.com/daanv2/go-bloom-filters/pkg/extensions/xsync"
+)
+
+var _ IBloomFilter = &ConcurrentSlidingWindowBloomFilter{}
+
+// ConcurrentSlidingWindowBloomFilter is a thread-safe sliding window bloom filter that uses a spinlock for concurrent access.
+// It is safe to call Add and Test methods from multiple goroutines.
+type ConcurrentSlidingWindowBloomFilter struct {
+	base *SlidingWindowBloomFilter
+	lock xsync.SpinLock
+}
+
+// NewConcurrentSlidingWindowBloomFilter creates a new concurrent sliding window bloom filter with the given options.
+// It returns an error if the size, the interval or the amount of generations is invalid, or if any of the hash functions are nil.
+func NewConcurrentSlidingWindowBloomFilter(opts ...SlidingWindowBloomFilterOptions) (*ConcurrentSlidingWindowBloomFilter, error) {
+	base, err := NewSlidingWindowBloomFilter(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConcurrentSlidingWindowBloomFilter{
+		base: base,
+		lock: *xsync.NewSpinLock(),
+	}, nil
+}
+
+// Add adds the given data to the newest generation of the bloom filter.
+// This method is thread-safe.
+func (bf *ConcurrentSlidingWindowBloomFilter) Add(data []byte) {
+	indexes := bf.base.indexes(data)
+
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	bf.base.rotate()
+	bf.base.addIndexes(indexes)
+}
+
+// Test checks if the given data is likely to be in any generation of the bloom filter, which means it was added within the window.
+// This method is thread-safe.
+func (bf *ConcurrentSlidingWindowBloomFilter) Test(data []byte) bool {
+	// Spent more time on hashing, so we don't have to lock for each bit access.
+	indexes := bf.base.indexes(data)
+
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	bf.base.rotate()
+
+	return bf.base.testIndexes(indexes)
+}
+
+// GetHash checks if the bit corresponding to the given hash value is set in any generation.
+// This method is thread-safe.
+func (bf *ConcurrentSlidingWindowBloomFilter) GetHash(hash uint64) bool {
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	return bf.base.GetHash(hash)
+}
+
+// SetHash sets the bit corresponding to the given hash value in the newest generation.
+// This method is thread-safe.
+func (bf *ConcurrentSlidingWindowBloomFilter) SetHash(hash uint64) {
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	bf.base.SetHash(hash)
+}
+
+// BitsCount returns the total number of bits that are set to 1 in any generation.
+// This method is thread-safe.
+func (bf *ConcurrentSlidingWindowBloomFilter) BitsCount() uint64 {
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	return bf.base.BitsCount()
+}
+
+// Bits returns a Bits struct with every bit set that is set in any generation.
+// This method is thread-safe. Modifying the returned Bits will not affect the internal state of the bloom filter.
+func (bf *ConcurrentSlidingWindowBloomFilter) Bits() Bits {
+	bf.lock.Lock()
+	defer bf.lock.Unlock()
+
+	return bf.base.Bits()
+}
+
+// Window returns the duration an added item is guaranteed to be found for, (generations - 1) intervals.
+// This method is thread-safe.
+func (bf *ConcurrentSlidingWindowBloomFilter) Window() time.Duration {
+	return bf.base.Window()
+}
+
+// Generations returns the amount of generations of the bloom filter.
+// This method is thread-safe.
+func (bf *ConcurrentSlidingWindowBloomFilter) Generations() int {
+	return bf.base.Generations()
+}
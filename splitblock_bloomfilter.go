@@ -0,0 +1,97 @@
+package bloomfilters
+
+import (
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+)
+
+// SplitBlockSize is the size in bytes of a single block of a [SplitBlockBloomFilter], 8 words of 32 bits.
+const SplitBlockSize = 32
+
+// splitBlockSalt are the SALT constants from the Apache Parquet specification, one for each word in a block.
+var splitBlockSalt = [8]uint32{
+	0x47b6137b, 0x44974d91, 0x8824ad5b, 0xa2b7289d,
+	0x705495c7, 0x2df1424b, 0x9efc4947, 0x5c6bfb31,
+}
+
+var _ IBloomFilter = &SplitBlockBloomFilter{}
+
+// SplitBlockBloomFilter is the split block bloom filter as specified by Apache Parquet, see https://github.com/apache/parquet-format/blob/master/BloomFilter.md
+// The filter consists of blocks of 256 bits, split into eight words of 32 bits. The upper 32 bits of the xxHash64 of a value pick a block,
+// and the lower 32 bits, multiplied with a SALT constant per word, set exactly one bit in each word of that block.
+// Its binary form is byte-exact with the bitset Parquet stores after the bloom filter header of a column chunk.
+type SplitBlockBloomFilter struct {
+	bits   Bits
+	blocks uint64
+}
+
+// NewSplitBlockBloomFilter creates a new split block bloom filter of the given size in bytes, rounded up to a whole number of 32 byte blocks.
+// Parquet readers expect the size to be a power of 2, see [NewSplitBlockBloomFilterFor].
+func NewSplitBlockBloomFilter(numBytes uint64) *SplitBlockBloomFilter {
+	blocks := max((numBytes+SplitBlockSize-1)/SplitBlockSize, 1)
+
+	return &SplitBlockBloomFilter{
+		bits:   NewBits(blocks * SplitBlockSize * 8),
+		blocks: blocks,
+	}
+}
+
+// NewSplitBlockBloomFilterFor creates a new split block bloom filter sized for n distinct values at the false positive rate p,
+// the size is calculated like Parquet writers do, see bloomsettings.SplitBlockBytes.
+func NewSplitBlockBloomFilterFor(n uint64, p float64) *SplitBlockBloomFilter {
+	return NewSplitBlockBloomFilter(bloomsettings.SplitBlockBytes(n, p))
+}
+
+// Add adds the given data to the bloom filter. Data must be the plain encoding of a Parquet value,
+// for example 8 little-endian bytes for an INT64 or the raw bytes, without length prefix, for a BYTE_ARRAY.
+func (sbf *SplitBlockBloomFilter) Add(data []byte) {
+	sbf.SetHash(bloomhashes.XXHash64(data))
+}
+
+// Test checks if the given plain encoded data is likely to be in the bloom filter.
+func (sbf *SplitBlockBloomFilter) Test(data []byte) bool {
+	return sbf.GetHash(bloomhashes.XXHash64(data))
+}
+
+// SetHash inserts the given xxHash64 hash value into the bloom filter, this is filter_insert from the specification.
+func (sbf *SplitBlockBloomFilter) SetHash(hash uint64) {
+	base, key := sbf.block(hash)
+	for i, salt := range splitBlockSalt {
+		sbf.bits.Setbit(base + uint64(i)*32 + uint64((key*salt)>>27))
+	}
+}
+
+// GetHash checks if the given xxHash64 hash value is likely to be in the bloom filter, this is filter_check from the specification.
+func (sbf *SplitBlockBloomFilter) GetHash(hash uint64) bool {
+	base, key := sbf.block(hash)
+	for i, salt := range splitBlockSalt {
+		if !sbf.bits.Getbit(base + uint64(i)*32 + uint64((key*salt)>>27)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// BitsCount returns the total number of bits that are set to 1 in the bloom filter.
+func (sbf *SplitBlockBloomFilter) BitsCount() uint64 {
+	return sbf.bits.BitsCount()
+}
+
+// Bits returns a copy of the Bits struct representing the bitset of the bloom filter.
+// Modifying the returned Bits will not affect the internal state of the bloom filter.
+func (sbf *SplitBlockBloomFilter) Bits() Bits {
+	return sbf.bits.Copy()
+}
+
+// NumBytes returns the size of the bitset in bytes, as written in the numBytes field of the Parquet bloom filter header.
+func (sbf *SplitBlockBloomFilter) NumBytes() uint64 {
+	return sbf.blocks * SplitBlockSize
+}
+
+// block returns the index of the first bit of the block picked by the upper 32 bits of the hash, and the lower 32 bits of the hash as key.
+func (sbf *SplitBlockBloomFilter) block(hash uint64) (base uint64, key uint32) {
+	block := ((hash >> 32) * sbf.blocks) >> 32
+
+	return block * SplitBlockSize * 8, uint32(hash)
+}
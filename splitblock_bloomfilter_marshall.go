@@ -0,0 +1,36 @@
+package bloomfilters
+
+import (
+	"encoding"
+	"errors"
+)
+
+var ErrInvalidSplitBlockData = errors.New("split block bloom filter data must be a non-empty multiple of 32 bytes")
+
+var (
+	_ encoding.BinaryMarshaler   = (*SplitBlockBloomFilter)(nil)
+	_ encoding.BinaryUnmarshaler = (*SplitBlockBloomFilter)(nil)
+)
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler].
+// The data is the bitset of a Parquet bloom filter, without the bloom filter header.
+func (sbf *SplitBlockBloomFilter) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || len(data)%SplitBlockSize != 0 {
+		return ErrInvalidSplitBlockData
+	}
+
+	err := sbf.bits.UnmarshalBinary(data)
+	if err != nil {
+		return err
+	}
+	sbf.blocks = uint64(len(data) / SplitBlockSize)
+
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler].
+// The data is the bitset of a Parquet bloom filter, blocks of eight little-endian 32 bit words, without the bloom filter header.
+func (sbf *SplitBlockBloomFilter) MarshalBinary() (data []byte, err error) {
+	// Two little-endian 32 bit words are the same bytes as one little-endian 64 bit word
+	return sbf.bits.MarshalBinary()
+}
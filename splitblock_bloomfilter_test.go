@@ -0,0 +1,188 @@
+package bloomfilters_test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// plainInt64 returns the Parquet plain encoding of an INT64 value.
+func plainInt64(v int64) []byte {
+	return binary.LittleEndian.AppendUint64(nil, uint64(v))
+}
+
+// The expected bitsets of the vector tests are not produced by this package, they are computed by the filter_insert,
+// block_insert and mask pseudo-code of the Parquet BloomFilter.md specification, transcribed to C and hashed with the xxHash C library.
+func Test_SplitBlockBloomFilter_Vectors(t *testing.T) {
+	// 2 blocks, with "hello", "parquet" and INT64 42
+	const expected = "04001000000240000004400080000800000200040020008010000010020000080001000080000000000000400040000000040000008000000000000810000000"
+
+	sbf := bloomfilters.NewSplitBlockBloomFilter(64)
+	sbf.Add([]byte("hello"))
+	sbf.Add([]byte("parquet"))
+	sbf.Add(plainInt64(42))
+
+	data, err := sbf.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, expected, hex.EncodeToString(data))
+	assert.Equal(t, uint64(24), sbf.BitsCount(), "Expected every value to set exactly one bit in each of the 8 words of its block")
+}
+
+func Test_SplitBlockBloomFilter_VectorsRestored(t *testing.T) {
+	// 8 blocks, with "hello", "parquet", "bloom" and "filter", one block per line
+	expected := strings.Join([]string{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0400900000024010010440008000081020020004002020801000005002000808",
+		"0000200000080000000100000000002000008000000000080001000000100000",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+	}, "")
+	members := []string{"hello", "parquet", "bloom", "filter"}
+
+	bitset, err := hex.DecodeString(expected)
+	require.NoError(t, err)
+	var restored bloomfilters.SplitBlockBloomFilter
+	require.NoError(t, restored.UnmarshalBinary(bitset))
+	for _, member := range members {
+		assert.True(t, restored.Test([]byte(member)), "Expected %q to be in the reference bitset", member)
+	}
+
+	sbf := bloomfilters.NewSplitBlockBloomFilter(uint64(len(bitset)))
+	for _, member := range members {
+		sbf.Add([]byte(member))
+	}
+	data, err := sbf.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, expected, hex.EncodeToString(data))
+}
+
+func Test_SplitBlockBloomFilter_Mask(t *testing.T) {
+	// With a key of 1, the bit in each word is the top 5 bits of the SALT constant
+	salts := []uint32{0x47b6137b, 0x44974d91, 0x8824ad5b, 0xa2b7289d, 0x705495c7, 0x2df1424b, 0x9efc4947, 0x5c6bfb31}
+
+	sbf := bloomfilters.NewSplitBlockBloomFilter(bloomfilters.SplitBlockSize)
+	sbf.SetHash(1)
+
+	data, err := sbf.MarshalBinary()
+	require.NoError(t, err)
+	for i, salt := range salts {
+		word := binary.LittleEndian.Uint32(data[i*4:])
+		assert.Equal(t, uint32(1)<<(salt>>27), word, "Expected word %d to be the mask of its SALT constant", i)
+	}
+}
+
+func Test_SplitBlockBloomFilter_BlockSelection(t *testing.T) {
+	// The upper 32 bits of the hash pick the block: ((hash >> 32) * blocks) >> 32
+	sbf := bloomfilters.NewSplitBlockBloomFilter(4 * bloomfilters.SplitBlockSize)
+	sbf.SetHash(0xC0000000_00000001) // 0.75 of the way, so the 4th block
+
+	data, err := sbf.MarshalBinary()
+	require.NoError(t, err)
+	for block := range 4 {
+		empty := true
+		for _, b := range data[block*bloomfilters.SplitBlockSize : (block+1)*bloomfilters.SplitBlockSize] {
+			if b != 0 {
+				empty = false
+			}
+		}
+		assert.Equal(t, block != 3, empty, "Expected only block 3 to have bits set")
+	}
+}
+
+func Test_SplitBlockBloomFilter_Marshal(t *testing.T) {
+	data := testutil.MoreBytes(1000, 32)
+
+	sbf := bloomfilters.NewSplitBlockBloomFilterFor(1000, 0.01)
+	for _, d := range data {
+		sbf.Add(d)
+	}
+
+	bin, err := sbf.MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, sbf.NumBytes(), uint64(len(bin)))
+
+	var unmarshaled bloomfilters.SplitBlockBloomFilter
+	require.NoError(t, unmarshaled.UnmarshalBinary(bin))
+	assert.Equal(t, sbf.NumBytes(), unmarshaled.NumBytes())
+	for _, d := range data {
+		require.True(t, unmarshaled.Test(d), "Expected added data to be found in the unmarshaled filter")
+	}
+}
+
+func Test_SplitBlockBloomFilter_UnmarshalInvalid(t *testing.T) {
+	var sbf bloomfilters.SplitBlockBloomFilter
+	require.ErrorIs(t, sbf.UnmarshalBinary(nil), bloomfilters.ErrInvalidSplitBlockData)
+	require.ErrorIs(t, sbf.UnmarshalBinary(make([]byte, 40)), bloomfilters.ErrInvalidSplitBlockData)
+}
+
+func Test_SplitBlockBloomFilter_FalsePositiveRate(t *testing.T) {
+	const n = 10_000
+	data := testutil.MoreBytes(2*n, 32)
+	added, other := data[:n], data[n:]
+
+	sbf := bloomfilters.NewSplitBlockBloomFilterFor(n, 0.01)
+	assert.Equal(t, uint64(16384), sbf.NumBytes(), "Expected the size to be rounded up to a power of 2")
+
+	for _, d := range added {
+		sbf.Add(d)
+	}
+
+	falsePositives := 0
+	for _, d := range other {
+		if sbf.Test(d) {
+			falsePositives++
+		}
+	}
+
+	expected := bloomsettings.SplitBlockFalsePositiveRate(sbf.NumBytes(), n)
+	assert.InDelta(t, expected, float64(falsePositives)/float64(len(other)), 0.005, "Expected the measured false positive rate to match the predicted rate")
+	assert.Less(t, expected, 0.01)
+}
+
+// Fuzz test for SplitBlockBloomFilter Add and Test
+func Fuzz_SplitBlockBloomFilter_AddTest(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		sbf := bloomfilters.NewSplitBlockBloomFilter(1024)
+
+		sbf.Add(data)
+		require.True(t, sbf.Test(data), "Expected added data to be found in the filter")
+	})
+}
+
+// Example of checking a Parquet column bloom filter
+func ExampleSplitBlockBloomFilter() {
+	sbf := bloomfilters.NewSplitBlockBloomFilter(1024)
+	sbf.Add([]byte("apple")) // BYTE_ARRAY values are hashed without length prefix
+
+	// The bitset as stored after the bloom filter header in a Parquet file
+	bitset, _ := sbf.MarshalBinary()
+
+	var reader bloomfilters.SplitBlockBloomFilter
+	_ = reader.UnmarshalBinary(bitset)
+
+	fmt.Println(len(bitset))
+	fmt.Println(reader.Test([]byte("apple")))
+	fmt.Println(reader.Test([]byte("grape")))
+
+	// Output:
+	// 1024
+	// true
+	// false
+}
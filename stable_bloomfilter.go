@@ -0,0 +1,182 @@
+package bloomfilters
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+)
+
+// DefaultStableCounterWidth is the default width in bits of a single cell of a stable bloom filter, cells count down from 3.
+const DefaultStableCounterWidth = 2
+
+var _ IBloomFilter = &StableBloomFilter{}
+
+// StableBloomFilter is a bloom filter for unbounded streams, based on "Approximately Detecting Duplicates for Streaming Data using Stable Bloom Filters" by Deng and Rafiei.
+// Every cell is a small counter. Adding an item first decrements a few random cells by one, and then sets the cells of the item to the maximum value.
+// Old items slowly fade out, so the fraction of zero cells converges to a stable point instead of the filter saturating,
+// at the cost of false negatives for items that haven't been seen for a while.
+// The amount of cells decremented per add is derived from the target false positive rate, see bloomsettings.StableDecrements.
+type StableBloomFilter struct {
+	counters   Counters
+	hashes     []bloomhashes.HashFunction
+	derived    derivedHashes
+	decrements uint64
+	rng        *rand.Rand
+
+	size  uint64
+	width uint8
+	fpr   float64
+	seed  *Bits
+}
+
+// NewStableBloomFilter creates a new stable bloom filter with the given options, the size is the amount of cells.
+// Unless set with [WithDecrements], the amount of cells decremented per add is derived from [WithFalsePositiveRate], which defaults to [DefaultFalsePositiveRate].
+// It returns an error if the size, the counter width or the false positive rate are invalid, or if any of the hash functions are nil.
+func NewStableBloomFilter(opts ...StableBloomFilterOptions) (*StableBloomFilter, error) {
+	bf := &StableBloomFilter{
+		width: DefaultStableCounterWidth,
+		fpr:   DefaultFalsePositiveRate,
+	}
+	for _, opt := range opts {
+		opt.applyStable(bf)
+	}
+	if bf.size == 0 {
+		return nil, ErrInvalidSize
+	}
+	if bf.width == 0 || bf.width > 64 {
+		return nil, ErrInvalidCounterWidth
+	}
+	if bf.fpr <= 0 || bf.fpr >= 1 {
+		return nil, ErrInvalidFalsePositiveRate
+	}
+	if len(bf.hashes) == 0 {
+		return nil, ErrRequiredHashFunction
+	}
+	for _, hashFunc := range bf.hashes {
+		if hashFunc == nil {
+			return nil, ErrHashIsNil
+		}
+	}
+
+	bf.counters = NewCounters(bf.size, bf.width)
+	if bf.decrements == 0 {
+		p := bloomsettings.StableDecrements(bf.counters.Size(), uint64(len(bf.hashes)), bf.counters.Max(), bf.fpr)
+		if math.IsNaN(p) || p < 1 {
+			p = 1
+		}
+		bf.decrements = uint64(math.Round(min(p, float64(bf.counters.Size()))))
+	}
+	if bf.rng == nil {
+		bf.rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())) // nolint:gosec // This is not used for cryptographic purposes.
+	}
+	if bf.seed != nil {
+		// Every bit that was set, belongs to an item that has just been seen
+		for i := range bf.seed.Size() {
+			if bf.seed.Getbit(i) {
+				bf.counters.Set(i, bf.counters.Max())
+			}
+		}
+		bf.seed = nil
+	}
+
+	return bf, nil
+}
+
+// Add adds the given data to the bloom filter, decrementing random cells first and then setting the cells of each hash function to the maximum value.
+func (bf *StableBloomFilter) Add(data []byte) {
+	bf.decrement()
+	for _, hash := range bf.derived.all(bf.hashes, data) {
+		bf.SetHash(hash)
+	}
+}
+
+// Test checks if the given data has likely been added to the bloom filter recently, by checking if the cells of each hash function are not zero.
+func (bf *StableBloomFilter) Test(data []byte) bool {
+	for _, hash := range bf.derived.all(bf.hashes, data) {
+		if !bf.GetHash(hash) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// TestAndAdd checks if the given data has likely been added to the bloom filter recently, and then adds it.
+// This is the operation to detect duplicates in a stream, it hashes the data only once.
+func (bf *StableBloomFilter) TestAndAdd(data []byte) bool {
+	present := true
+	indexes := make([]uint64, len(bf.hashes))
+	for i, hash := range bf.derived.all(bf.hashes, data) {
+		indexes[i] = bf.index(hash)
+		present = present && bf.counters.Get(indexes[i]) != 0
+	}
+
+	bf.decrement()
+	for _, index := range indexes {
+		bf.counters.Set(index, bf.counters.Max())
+	}
+
+	return present
+}
+
+// SetHash sets the cell corresponding to the given hash value to the maximum value, without decrementing other cells.
+func (bf *StableBloomFilter) SetHash(hash uint64) {
+	bf.counters.Set(bf.index(hash), bf.counters.Max())
+}
+
+// GetHash checks if the cell corresponding to the given hash value is not zero.
+func (bf *StableBloomFilter) GetHash(hash uint64) bool {
+	return bf.counters.Get(bf.index(hash)) != 0
+}
+
+// BitsCount returns the total number of cells that are not zero in the bloom filter.
+func (bf *StableBloomFilter) BitsCount() uint64 {
+	return bf.counters.Count()
+}
+
+// Bits returns a Bits struct where each bit is set if the corresponding cell is not zero.
+// Modifying the returned Bits will not affect the internal state of the bloom filter.
+func (bf *StableBloomFilter) Bits() Bits {
+	bits := NewBits(bf.counters.Size())
+	for i := range bf.counters.Size() {
+		if bf.counters.Get(i) != 0 {
+			bits.Setbit(i)
+		}
+	}
+
+	return bits
+}
+
+// Counters returns a copy of the Counters struct representing the cells of the bloom filter.
+// Modifying the returned Counters will not affect the internal state of the bloom filter.
+func (bf *StableBloomFilter) Counters() Counters {
+	return bf.counters.Copy()
+}
+
+// Decrements returns the amount of cells decremented per add.
+func (bf *StableBloomFilter) Decrements() uint64 {
+	return bf.decrements
+}
+
+// FalsePositiveRate returns the false positive rate the filter converges to, see bloomsettings.StableFalsePositiveRate.
+func (bf *StableBloomFilter) FalsePositiveRate() float64 {
+	return bloomsettings.StableFalsePositiveRate(bf.counters.Size(), uint64(len(bf.hashes)), bf.decrements, bf.counters.Max())
+}
+
+// decrement decrements a run of cells starting at a random cell, which is as good as random cells but needs a single random number.
+func (bf *StableBloomFilter) decrement() {
+	size := bf.counters.Size()
+	start := bf.rng.Uint64N(size)
+	for i := range bf.decrements {
+		index := (start + i) % size
+		if v := bf.counters.Get(index); v != 0 {
+			bf.counters.Set(index, v-1)
+		}
+	}
+}
+
+func (bf *StableBloomFilter) index(hash uint64) uint64 {
+	return hash % bf.counters.Size()
+}
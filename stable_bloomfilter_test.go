@@ -0,0 +1,159 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStableBloomFilter(t testing.TB, opts ...bloomfilters.StableBloomFilterOptions) *bloomfilters.StableBloomFilter {
+	t.Helper()
+
+	opts = append([]bloomfilters.StableBloomFilterOptions{
+		bloomfilters.WithHashFunctions([]bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.Fnv1_64a, bloomhashes.XXHash64}),
+		bloomfilters.WithRandomSeed(42),
+	}, opts...)
+	bf, err := bloomfilters.NewStableBloomFilter(opts...)
+	require.NoError(t, err)
+
+	return bf
+}
+
+func Test_StableBloomFilter_TestAndAdd(t *testing.T) {
+	bf := newStableBloomFilter(t, bloomfilters.WithSize(10000))
+
+	assert.False(t, bf.TestAndAdd([]byte("hello")), "Expected the first occurrence to be new")
+	assert.True(t, bf.TestAndAdd([]byte("hello")), "Expected the second occurrence to be a duplicate")
+	assert.True(t, bf.Test([]byte("hello")))
+	assert.False(t, bf.Test([]byte("world")))
+}
+
+func Test_StableBloomFilter_RecentItems(t *testing.T) {
+	data := testutil.MoreBytes(100000, 16)
+	bf := newStableBloomFilter(t, bloomfilters.WithSize(10000))
+
+	// The stream is much longer than the filter could hold, but the last items are never forgotten
+	for i, d := range data {
+		bf.Add(d)
+		if i > 0 {
+			require.True(t, bf.Test(data[i-1]), "Expected the previous item to still be in the filter")
+		}
+	}
+}
+
+func Test_StableBloomFilter_Converges(t *testing.T) {
+	data := testutil.MoreBytes(120000, 16)
+	stream, others := data[:100000], data[100000:]
+
+	for _, rate := range []float64{0.01, 0.05} {
+		t.Run(fmt.Sprint(rate), func(t *testing.T) {
+			bf := newStableBloomFilter(t, bloomfilters.WithSize(10000), bloomfilters.WithFalsePositiveRate(rate))
+			assert.InDelta(t, rate, bf.FalsePositiveRate(), rate*0.1, "Expected the derived decrements to give the target rate")
+
+			// A plain bloom filter of the same size would be saturated after this stream
+			for _, d := range stream {
+				bf.Add(d)
+			}
+
+			var positives int
+			for _, d := range others {
+				if bf.Test(d) {
+					positives++
+				}
+			}
+			assert.InDelta(t, rate, float64(positives)/float64(len(others)), rate*0.3, "Expected the false positive rate to converge to the target")
+		})
+	}
+}
+
+func Test_StableBloomFilter_Decrements(t *testing.T) {
+	bf := newStableBloomFilter(t, bloomfilters.WithSize(10000), bloomfilters.WithDecrements(7))
+	assert.Equal(t, uint64(7), bf.Decrements())
+
+	p := bloomsettings.StableDecrements(10000, 3, 3, 0.01)
+	assert.InDelta(t, 0.01, bloomsettings.StableFalsePositiveRate(10000, 3, uint64(p+0.5), 3), 0.001)
+}
+
+func Test_StableBloomFilter_WithBits(t *testing.T) {
+	hashes := []bloomhashes.HashFunction{bloomhashes.Fnv1_64, bloomhashes.Fnv1_64a}
+	bf, err := bloomfilters.NewBloomFilter(
+		bloomfilters.WithSize(1024),
+		bloomfilters.WithHashFunctions(hashes),
+	)
+	require.NoError(t, err)
+	bf.Add([]byte("hello"))
+
+	sbf, err := bloomfilters.NewStableBloomFilter(
+		bloomfilters.WithBits(bf.Bits()),
+		bloomfilters.WithHashFunctions(hashes),
+	)
+	require.NoError(t, err)
+	assert.True(t, sbf.Test([]byte("hello")), "Expected the stable filter to start from the given bits")
+
+	bits, expected := sbf.Bits(), bf.Bits()
+	assert.True(t, bits.Equals(&expected), "Expected every full cell to show up as a set bit")
+}
+
+func Test_StableBloomFilter_Invalid(t *testing.T) {
+	hashes := bloomfilters.WithDefaultHashFunctions()
+
+	tests := []struct {
+		name string
+		opts []bloomfilters.StableBloomFilterOptions
+		err  error
+	}{
+		{"no size", []bloomfilters.StableBloomFilterOptions{hashes}, bloomfilters.ErrInvalidSize},
+		{"counter width", []bloomfilters.StableBloomFilterOptions{bloomfilters.WithSize(1024), bloomfilters.WithCounterWidth(0), hashes}, bloomfilters.ErrInvalidCounterWidth},
+		{"false positive rate", []bloomfilters.StableBloomFilterOptions{bloomfilters.WithSize(1024), bloomfilters.WithFalsePositiveRate(1), hashes}, bloomfilters.ErrInvalidFalsePositiveRate},
+		{"no hash", []bloomfilters.StableBloomFilterOptions{bloomfilters.WithSize(1024)}, bloomfilters.ErrRequiredHashFunction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bf, err := bloomfilters.NewStableBloomFilter(tt.opts...)
+			require.ErrorIs(t, err, tt.err)
+			require.Nil(t, bf)
+		})
+	}
+}
+
+// Fuzz test for StableBloomFilter TestAndAdd
+func Fuzz_StableBloomFilter_TestAndAdd(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		bf := newStableBloomFilter(t, bloomfilters.WithSize(1024))
+
+		bf.TestAndAdd(data)
+		require.True(t, bf.TestAndAdd(data), "Expected a repeated item to be detected as duplicate")
+	})
+}
+
+// Example of detecting duplicates in a stream with a StableBloomFilter
+func ExampleStableBloomFilter() {
+	bf, _ := bloomfilters.NewStableBloomFilter(
+		bloomfilters.WithSize(10000),
+		bloomfilters.WithDefaultHashFunctions(),
+		bloomfilters.WithFalsePositiveRate(0.01),
+		bloomfilters.WithRandomSeed(1),
+	)
+
+	for _, event := range []string{"login", "click", "login"} {
+		if bf.TestAndAdd([]byte(event)) {
+			fmt.Println("duplicate", event)
+		}
+	}
+
+	// Output:
+	// duplicate login
+}
@@ -0,0 +1,85 @@
+package bloomfilters_test
+
+import (
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// Benchmark_Blocked compares the blocked bloom filter against the standard bloom filter,
+// on a filter large enough (16 MiB) to not fit in the CPU caches.
+func Benchmark_Blocked(b *testing.B) {
+	const bf_size = 1 << 27
+	const arrays = 1000
+	const array_length = 32
+
+	data := testutil.MoreBytes(arrays, array_length)
+
+	type testFilter struct {
+		name    string
+		factory func() (bloomfilters.IBloomFilter, error)
+	}
+
+	filters := []testFilter{
+		{name: "BloomFilter", factory: func() (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewBloomFilter(
+				bloomfilters.WithDefaultHashFunctions(),
+				bloomfilters.WithSize(bf_size),
+			)
+		}},
+		{name: "BlockedBloomFilter", factory: func() (bloomfilters.IBloomFilter, error) {
+			return bloomfilters.NewBlockedBloomFilter(
+				bloomfilters.WithDefaultHashFunctions(),
+				bloomfilters.WithSize(bf_size),
+			)
+		}},
+	}
+
+	for _, filter := range filters {
+		name, factory := filter.name, filter.factory
+
+		b.Run(name+"/Add", func(b *testing.B) {
+			bg, err := factory()
+			require.NoError(b, err)
+
+			for b.Loop() {
+				for i := range data {
+					bg.Add(data[i])
+				}
+			}
+		})
+
+		b.Run(name+"/Add_Test", func(b *testing.B) {
+			bg, err := factory()
+			require.NoError(b, err)
+
+			for b.Loop() {
+				for i := range data {
+					bg.Add(data[i])
+				}
+				for i := range data {
+					v := bg.Test(data[i])
+					if !v {
+						b.Fatalf("expected to find %v", data[i])
+					}
+				}
+			}
+		})
+
+		b.Run(name+"/Test_Nothing", func(b *testing.B) {
+			bg, err := factory()
+			require.NoError(b, err)
+
+			for b.Loop() {
+				for i := range data {
+					v := bg.Test(data[i])
+					if v {
+						b.Fatalf("found %v", data[i])
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package bloomfilters_test
+
+import (
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// Benchmark_Derived compares 12 hash functions against 12 hash values derived from a single 128-bit hash.
+func Benchmark_Derived(b *testing.B) {
+	const bf_size = 100_000
+	const arrays = 1000
+	const array_length = 32
+
+	data := testutil.MoreBytes(arrays, array_length)
+	options := map[string]bloomfilters.BloomFilterOptions{
+		"AllHashFunctions":      bloomfilters.WithAllHashFunctions(),
+		"DoubleHashing":         bloomfilters.WithDerivedHashes(bloomhashes.Fnv1_128aSum, bloomhashes.DoubleHashing, 12),
+		"EnhancedDoubleHashing": bloomfilters.WithDerivedHashes(bloomhashes.Fnv1_128aSum, bloomhashes.EnhancedDoubleHashing, 12),
+		"TripleHashing":         bloomfilters.WithDerivedHashes(bloomhashes.Fnv1_128aSum, bloomhashes.TripleHashing, 12),
+	}
+
+	for name, option := range options {
+		b.Run(name, func(b *testing.B) {
+			bg, err := bloomfilters.NewBloomFilter(
+				option,
+				bloomfilters.WithSize(bf_size),
+			)
+			require.NoError(b, err)
+
+			for b.Loop() {
+				for i := range data {
+					bg.Add(data[i])
+				}
+				for i := range data {
+					if !bg.Test(data[i]) {
+						b.Fatalf("expected to find %v", data[i])
+					}
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package bloomhashes_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
@@ -26,6 +27,13 @@ func Benchmark_Hashes_Cost(b *testing.B) {
 		{name: "Fnv1_64a", hash: bloomhashes.Fnv1_64a},
 		{name: "Fnv1_128", hash: bloomhashes.Fnv1_128},
 		{name: "Fnv1_128a", hash: bloomhashes.Fnv1_128a},
+		{name: "XXHash64", hash: bloomhashes.XXHash64},
+		{name: "XXH3_64", hash: bloomhashes.XXH3_64},
+		{name: "XXH3_128", hash: func(data []byte) uint64 {
+			low, _ := bloomhashes.XXH3_128(data)
+
+			return low
+		}},
 	}
 
 	d := testutil.MoreBytes(1000, 32)
@@ -43,3 +51,36 @@ func Benchmark_Hashes_Cost(b *testing.B) {
 		})
 	}
 }
+
+// Benchmark_Hashes_Cost_KeyLength compares the fastest hashes for short and long keys.
+func Benchmark_Hashes_Cost_KeyLength(b *testing.B) {
+	type testHash struct {
+		name string
+		hash func([]byte) uint64
+	}
+
+	tests := []testHash{
+		{name: "Fnv1_64a", hash: bloomhashes.Fnv1_64a},
+		{name: "Crc64_ECMA", hash: bloomhashes.Crc64_ECMA},
+		{name: "XXHash64", hash: bloomhashes.XXHash64},
+		{name: "XXH3_64", hash: bloomhashes.XXH3_64},
+	}
+
+	for _, length := range []int{8, 32, 256, 1024} {
+		d := testutil.MoreBytes(1000, length)
+
+		for _, test := range tests {
+			b.Run(fmt.Sprintf("%s/%d", test.name, length), func(b *testing.B) {
+				b.SetBytes(int64(len(d) * length))
+				for b.Loop() {
+					for i := range d {
+						v := test.hash(d[i])
+						if v == 0 {
+							b.Fatalf("unexpected zero value")
+						}
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package bloomfilters
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrInvalidXorData = errors.New("xor filter data has an invalid length")
+
+// xorHeaderSize is the size of the seed and the block length at the start of a marshalled xor filter.
+const xorHeaderSize = 8 + 4
+
+var (
+	_ encoding.BinaryMarshaler   = (*XorFilter[uint8])(nil)
+	_ encoding.BinaryUnmarshaler = (*XorFilter[uint8])(nil)
+)
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler].
+// The fingerprint size of the data must match the fingerprint size of the filter.
+func (xf *XorFilter[T]) UnmarshalBinary(data []byte) error {
+	if len(data) < xorHeaderSize {
+		return ErrInvalidXorData
+	}
+
+	seed := binary.LittleEndian.Uint64(data)
+	blockLength := binary.LittleEndian.Uint32(data[8:])
+	data = data[xorHeaderSize:]
+
+	width := int(xorFingerprintBytes[T]())
+	if blockLength == 0 || uint64(len(data)) != 3*uint64(blockLength)*uint64(width) {
+		return ErrInvalidXorData
+	}
+
+	fingerprints := make([]T, 3*blockLength)
+	for i := range fingerprints {
+		if width == 1 {
+			fingerprints[i] = T(data[i])
+		} else {
+			fingerprints[i] = T(binary.LittleEndian.Uint16(data[2*i:]))
+		}
+	}
+
+	xf.seed, xf.blockLength, xf.fingerprints = seed, blockLength, fingerprints
+
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler].
+// The data is the seed as little-endian uint64, the block length as little-endian uint32, followed by the little-endian fingerprints.
+func (xf *XorFilter[T]) MarshalBinary() (data []byte, err error) {
+	width := int(xorFingerprintBytes[T]())
+
+	data = make([]byte, xorHeaderSize+len(xf.fingerprints)*width)
+	binary.LittleEndian.PutUint64(data, xf.seed)
+	binary.LittleEndian.PutUint32(data[8:], xf.blockLength)
+	for i, fp := range xf.fingerprints {
+		if width == 1 {
+			data[xorHeaderSize+i] = byte(fp)
+		} else {
+			binary.LittleEndian.PutUint16(data[xorHeaderSize+2*i:], uint16(fp))
+		}
+	}
+
+	return data, nil
+}
+
+// xorFingerprintBytes returns the size in bytes of a fingerprint.
+func xorFingerprintBytes[T XorFingerprint]() uint64 {
+	if uint16(^T(0)) > 0xff {
+		return 2
+	}
+
+	return 1
+}
@@ -0,0 +1,167 @@
+package bloomfilters_test
+
+import (
+	"fmt"
+	"testing"
+
+	bloomfilters "github.com/daanv2/go-bloom-filters"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomhashes"
+	"github.com/daanv2/go-bloom-filters/pkg/bloomsettings"
+	"github.com/daanv2/go-bloom-filters/tests/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_XorFilter_NoFalseNegatives(t *testing.T) {
+	keys := testutil.MoreBytes(10000, 16)
+
+	xf8, err := bloomfilters.BuildXorFilter(keys)
+	require.NoError(t, err)
+	xf16, err := bloomfilters.BuildXor16Filter(keys)
+	require.NoError(t, err)
+
+	for _, key := range keys {
+		require.True(t, xf8.Test(key), "Expected key to be found in the xor8 filter")
+		require.True(t, xf16.Test(key), "Expected key to be found in the xor16 filter")
+	}
+}
+
+func Test_XorFilter_FalsePositiveRate(t *testing.T) {
+	data := testutil.MoreBytes(210000, 16)
+	keys, others := data[:10000], data[10000:]
+
+	xf8, err := bloomfilters.BuildXorFilter(keys)
+	require.NoError(t, err)
+	xf16, err := bloomfilters.BuildXor16Filter(keys)
+	require.NoError(t, err)
+
+	var positives8, positives16 int
+	for _, d := range others {
+		if xf8.Test(d) {
+			positives8++
+		}
+		if xf16.Test(d) {
+			positives16++
+		}
+	}
+
+	expected8 := bloomsettings.XorFalsePositiveRate(8)
+	assert.InDelta(t, expected8, float64(positives8)/float64(len(others)), expected8*0.2)
+	expected16 := bloomsettings.XorFalsePositiveRate(16)
+	assert.LessOrEqual(t, float64(positives16)/float64(len(others)), expected16*3)
+}
+
+func Test_XorFilter_Size(t *testing.T) {
+	keys := testutil.MoreBytes(100000, 16)
+
+	xf, err := bloomfilters.BuildXorFilter(keys)
+	require.NoError(t, err)
+
+	// A bloom filter needs about 1.44 * log2(1/p) bits per key for the same false positive rate
+	bitsPerKey := float64(xf.SizeInBits()) / float64(len(keys))
+	assert.Less(t, bitsPerKey, 10.0)
+	assert.Less(t, bitsPerKey, 0.9*1.44*8, "Expected the xor filter to be smaller than a bloom filter")
+}
+
+func Test_XorFilter_Duplicates(t *testing.T) {
+	keys := testutil.MoreBytes(100, 16)
+	keys = append(keys, keys...)
+
+	xf, err := bloomfilters.BuildXorFilter(keys)
+	require.NoError(t, err)
+	for _, key := range keys {
+		require.True(t, xf.Test(key))
+	}
+}
+
+func Test_XorFilter_Empty(t *testing.T) {
+	xf, err := bloomfilters.BuildXorFilter(nil)
+	require.NoError(t, err)
+
+	var positives int
+	for _, d := range testutil.MoreBytes(1000, 16) {
+		if xf.Test(d) {
+			positives++
+		}
+	}
+	assert.Less(t, positives, 20)
+}
+
+func Test_XorFilter_Contains(t *testing.T) {
+	keys := testutil.MoreBytes(1000, 16)
+	xf, err := bloomfilters.BuildXor16Filter(keys)
+	require.NoError(t, err)
+
+	for _, key := range keys {
+		require.True(t, xf.Contains(bloomhashes.XXHash64(key)))
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		xf.Contains(bloomhashes.XXHash64(keys[0]))
+		xf.Test(keys[1])
+	})
+	assert.Zero(t, allocs, "Expected lookups to not allocate")
+}
+
+func Test_XorFilter_Marshal(t *testing.T) {
+	keys := testutil.MoreBytes(1000, 16)
+
+	xf8, err := bloomfilters.BuildXorFilter(keys)
+	require.NoError(t, err)
+	data, err := xf8.MarshalBinary()
+	require.NoError(t, err)
+	assert.Len(t, data, 12+int(xf8.SizeInBits()/8))
+
+	var restored8 bloomfilters.XorFilter[uint8]
+	require.NoError(t, restored8.UnmarshalBinary(data))
+	assert.Equal(t, *xf8, restored8)
+
+	xf16, err := bloomfilters.BuildXor16Filter(keys)
+	require.NoError(t, err)
+	data, err = xf16.MarshalBinary()
+	require.NoError(t, err)
+
+	var restored16 bloomfilters.XorFilter[uint16]
+	require.NoError(t, restored16.UnmarshalBinary(data))
+	assert.Equal(t, *xf16, restored16)
+	for _, key := range keys {
+		require.True(t, restored16.Test(key))
+	}
+
+	// The fingerprint size has to match
+	require.ErrorIs(t, restored8.UnmarshalBinary(data), bloomfilters.ErrInvalidXorData)
+	require.ErrorIs(t, restored8.UnmarshalBinary(data[:5]), bloomfilters.ErrInvalidXorData)
+	// A block length of 0 has no fingerprints to look up
+	require.ErrorIs(t, restored8.UnmarshalBinary(make([]byte, 12)), bloomfilters.ErrInvalidXorData)
+}
+
+// Fuzz test for XorFilter Test
+func Fuzz_XorFilter_Test(f *testing.F) {
+	// Add seed corpus
+	f.Add([]byte("test"))
+	f.Add([]byte("hello world"))
+	f.Add([]byte(""))
+	f.Add([]byte("a"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		xf, err := bloomfilters.BuildXorFilter([][]byte{data, []byte("other")})
+		require.NoError(t, err)
+		require.True(t, xf.Test(data), "Expected the key to be found in the filter")
+	})
+}
+
+// Example of building a XorFilter from a fixed set of keys
+func ExampleXorFilter() {
+	xf, _ := bloomfilters.BuildXorFilter([][]byte{
+		[]byte("apple"),
+		[]byte("banana"),
+		[]byte("cherry"),
+	})
+
+	fmt.Println(xf.Test([]byte("apple")))
+	fmt.Println(xf.Test([]byte("cherry")))
+
+	// Output:
+	// true
+	// true
+}